@@ -1,20 +1,44 @@
 package app
 
 import (
+	"errors"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/fzdwx/ge/config"
 	"github.com/fzdwx/ge/ui"
 )
 
+// ErrCrashed is returned by StartUp when the editor stopped because of a panic.
+var ErrCrashed = errors.New("ge crashed, the unsaved changes are kept in swap files and offered for recovery on the next start")
+
 type App struct {
 	ui *ui.Ui
 }
 
 func New(filenames []string) *App {
-	return &App{ui: ui.New(config.New(filenames))}
+	return FromConfig(config.New(filenames))
+}
+
+// FromConfig creates an App configured by cfg.
+func FromConfig(cfg *config.Config) *App {
+	return &App{ui: ui.New(cfg)}
+}
+
+// NewDiff creates an App that shows the differences between a and b.
+func NewDiff(a, b string) *App {
+	return &App{ui: ui.New(config.NewDiff(a, b))}
 }
 
 func (a App) StartUp(ops ...tea.ProgramOption) error {
-	a.ui.Program = tea.NewProgram(a.ui, ops...)
-	return a.ui.Program.Start()
+	// the clipboard writes to the terminal too, see ui.Output.
+	a.ui.Program = tea.NewProgram(a.ui, append(ops, tea.WithOutput(a.ui.Output))...)
+	err := a.ui.Program.Start()
+	if closeErr := a.ui.Close(); err == nil {
+		err = closeErr
+	}
+
+	if a.ui.Crashed() {
+		return ErrCrashed
+	}
+	return err
 }
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/app"
+	"github.com/fzdwx/ge/internal/logx"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd shows two files side-by-side
+var diffCmd = &cobra.Command{
+	Use:   "diff <a> <b>",
+	Short: "Show the differences between two files side-by-side",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		logx.InitLog(*debugP, "./ge.log")
+		for _, filename := range args {
+			if _, err := os.Stat(filename); err != nil {
+				exit(err)
+			}
+		}
+		if err := app.NewDiff(args[0], args[1]).StartUp(tea.WithAltScreen()); err != nil {
+			exit(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fzdwx/ge/internal/diff"
+	"github.com/fzdwx/ge/internal/views"
+	"github.com/fzdwx/ge/ui"
+	"github.com/spf13/cobra"
+)
+
+// diffContext the number of unchanged lines around the changes of --dry-run.
+const diffContext = 3
+
+var (
+	execCommands []string
+	execScript   string
+	execDryRun   bool
+)
+
+// execCmd runs editor commands on files without starting the editor.
+var execCmd = &cobra.Command{
+	Use:   "exec [-e command]... [-f script] <file>...",
+	Short: "Run editor commands on files without starting the editor",
+	Long: `Run editor commands on files without starting the editor.
+
+The commands are the ones of the command prompt, e.g.
+
+  ge exec -e 'replace /foo(\d+)/bar${1}/' -e 'goto 1' -e 'insert // Code generated.\n' main.go
+  ge exec -e 'search TODO' -e 'play-macro fix 3' *.go
+
+They run in order on every file, a file is saved once all of them succeeded.
+A script has one command per line, blank lines and lines starting with # are
+skipped. The file - is the standard input, the result is written to the
+standard output. ge exec exits with status 1 if a command failed on any file.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if execScript == views.Stdin && readsStdin(args) {
+			exit(errors.New("the standard input can't be both the script and a file"))
+		}
+
+		lines := execCommands
+		if execScript != "" {
+			script, err := readScript(execScript)
+			if err != nil {
+				exit(err)
+			}
+			lines = append(lines, script...)
+		}
+		if len(lines) <= 0 {
+			exit(errors.New("no commands, use -e or -f"))
+		}
+
+		batch, err := ui.NewBatch()
+		if err != nil {
+			exit(err)
+		}
+
+		failed := false
+		for _, filename := range args {
+			if err := execFile(batch, filename, lines, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+			}
+		}
+		_ = batch.Close()
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// execFile runs the command lines on filename and saves it, or writes the
+// diff of the changes to out with --dry-run.
+func execFile(batch *ui.Batch, filename string, lines []string, out io.Writer) error {
+	if filename == views.Stdin {
+		return execStdin(batch, lines, out)
+	}
+
+	document, err := batch.Run(filename, lines)
+	if err != nil {
+		return err
+	}
+
+	if !execDryRun {
+		if !document.Modified() {
+			return nil
+		}
+		return document.Save()
+	}
+
+	original, err := views.LoadDocument(filename)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, diff.UnifiedText("a/"+diffPath(filename), "b/"+diffPath(filename), string(original.Bytes()), string(document.Bytes()), diffContext))
+	return err
+}
+
+// execStdin runs the command lines on the standard input, it can't be saved
+// or read again, the result or the diff of the changes with --dry-run is
+// written to out.
+func execStdin(batch *ui.Batch, lines []string, out io.Writer) error {
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	// the original is read like the document, e.g. its line endings.
+	original, document := views.NewDocument(), views.NewDocument()
+	if err := original.Read(bytes.NewReader(input)); err != nil {
+		return err
+	}
+	if err := document.Read(bytes.NewReader(input)); err != nil {
+		return err
+	}
+	if err := batch.RunDocument(document, lines); err != nil {
+		return fmt.Errorf("%s: %w", views.Stdin, err)
+	}
+
+	if !execDryRun {
+		_, err := out.Write(document.Bytes())
+		return err
+	}
+	name := diffPath(views.Stdin)
+	_, err = io.WriteString(out, diff.UnifiedText("a/"+name, "b/"+name, string(original.Bytes()), string(document.Bytes()), diffContext))
+	return err
+}
+
+// diffPath returns the path of filename in the headers of a diff, it is
+// cleaned and slash separated, an absolute path loses its leading slash.
+func diffPath(filename string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(filename)), "/")
+}
+
+// readScript reads the command lines of the script filename, - is the
+// standard input.
+func readScript(filename string) ([]string, error) {
+	r := io.Reader(os.Stdin)
+	if filename != views.Stdin {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func init() {
+	execCmd.Flags().StringArrayVarP(&execCommands, "command", "e", nil, "a command to run, can be repeated")
+	execCmd.Flags().StringVarP(&execScript, "script", "f", "", "a file of commands to run after the -e ones, - reads the standard input")
+	execCmd.Flags().BoolVar(&execDryRun, "dry-run", false, "print the changes as a unified diff instead of saving the files")
+	rootCmd.AddCommand(execCmd)
+}
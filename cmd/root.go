@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/fzdwx/ge/app"
+	"github.com/fzdwx/ge/config"
 	"github.com/fzdwx/ge/internal/logx"
+	"github.com/fzdwx/ge/internal/views"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -11,19 +14,44 @@ import (
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "ge",
+	Use:   "ge [file]",
 	Short: "A editor written in Go",
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
 
 		logx.InitLog(*debugP, "./ge.log")
-		if err := app.New(args).StartUp(tea.WithAltScreen()); err != nil {
-			panic(err)
+		ops := []tea.ProgramOption{tea.WithAltScreen()}
+		if readsStdin(args) {
+			// the standard input is the document, the keys are read from the terminal.
+			ops = append(ops, tea.WithInputTTY())
+		}
+		cfg := config.New(args)
+		cfg.CompileCommand, cfg.TestCommand = *compileP, *testP
+		cfg.FormatOnSave = *formatOnSaveP
+		if err := app.FromConfig(cfg).StartUp(ops...); err != nil {
+			exit(err)
 		}
 	},
 }
 
+// readsStdin reports whether a document is read from the standard input, see
+// views.Stdin.
+func readsStdin(filenames []string) bool {
+	for _, filename := range filenames {
+		if filename == views.Stdin {
+			return true
+		}
+	}
+	return false
+}
+
+// exit reports err and exits with a non-zero status.
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -34,7 +62,11 @@ func Execute() {
 }
 
 var (
-	debugP *bool
+	debugP   *bool
+	compileP *string
+	testP    *string
+
+	formatOnSaveP *bool
 )
 
 func init() {
@@ -48,4 +80,7 @@ func init() {
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	debugP = rootCmd.Flags().BoolP("debug", "d", true, "sets log level to debug")
+	compileP = rootCmd.Flags().String("compile", config.DefaultCompileCommand, "the command run by compile")
+	testP = rootCmd.Flags().String("test", config.DefaultTestCommand, "the command run by test")
+	formatOnSaveP = rootCmd.Flags().Bool("format-on-save", true, "run the formatter of the file, e.g. gofmt, before saving it")
 }
@@ -1,9 +1,40 @@
 package config
 
+const (
+	// DefaultCompileCommand the command run by compile.
+	DefaultCompileCommand = "go build ./..."
+	// DefaultTestCommand the command run by test.
+	DefaultTestCommand = "go test ./..."
+)
+
 type Config struct {
 	Filenames []string
+
+	// Diff whether the first two Filenames are compared side-by-side on startup.
+	Diff bool
+
+	// CompileCommand and TestCommand the shell commands run by the compile
+	// and test commands when they are given none.
+	CompileCommand string
+	TestCommand    string
+
+	// FormatOnSave whether the formatter of the document runs before it is
+	// saved.
+	FormatOnSave bool
 }
 
 func New(filenames []string) *Config {
-	return &Config{Filenames: filenames}
+	return &Config{
+		Filenames:      filenames,
+		CompileCommand: DefaultCompileCommand,
+		TestCommand:    DefaultTestCommand,
+		FormatOnSave:   true,
+	}
+}
+
+// NewDiff returns a config that compares a and b on startup.
+func NewDiff(a, b string) *Config {
+	cfg := New([]string{a, b})
+	cfg.Diff = true
+	return cfg
 }
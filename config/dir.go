@@ -0,0 +1,16 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Dir returns the directory of the user's configuration of ge, e.g.
+// ~/.config/ge, it may not exist yet.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ge"), nil
+}
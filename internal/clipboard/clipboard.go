@@ -0,0 +1,123 @@
+// Package clipboard provides access to the system clipboard with fallbacks
+// for terminals without one, e.g. over SSH.
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/atotto/clipboard"
+)
+
+// ErrUnsupported is returned by providers that can't perform an operation.
+var ErrUnsupported = errors.New("clipboard: unsupported")
+
+// Provider reads and writes a clipboard.
+type Provider interface {
+	Read() (string, error)
+	Write(text string) error
+}
+
+// New returns the system clipboard, falling back to OSC 52 written to out
+// when there is no clipboard tool, and to memory when nothing can be read.
+// Over SSH OSC 52 comes first, the clipboard of the remote machine is of no
+// use.
+func New(out io.Writer) Provider {
+	providers := []Provider{System{}, OSC52{Out: out}}
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		providers[0], providers[1] = providers[1], providers[0]
+	}
+	return &Fallback{Providers: providers}
+}
+
+// System is the clipboard of the desktop, accessed through tools like xclip,
+// xsel or wl-clipboard.
+type System struct{}
+
+func (System) Read() (string, error) {
+	if clipboard.Unsupported {
+		return "", ErrUnsupported
+	}
+	return clipboard.ReadAll()
+}
+
+func (System) Write(text string) error {
+	if clipboard.Unsupported {
+		return ErrUnsupported
+	}
+	return clipboard.WriteAll(text)
+}
+
+// OSC52 writes the text as an OSC 52 escape sequence, the terminal puts it
+// into the clipboard of the machine it runs on. Terminals don't reliably
+// answer read requests, so reading is unsupported.
+type OSC52 struct {
+	Out io.Writer
+}
+
+func (OSC52) Read() (string, error) {
+	return "", ErrUnsupported
+}
+
+func (o OSC52) Write(text string) error {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		// tmux passes the sequence on to the outer terminal.
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	_, err := io.WriteString(o.Out, seq)
+	return err
+}
+
+// Memory is a clipboard that only lives in memory, used in tests and when
+// no other clipboard can be read.
+type Memory struct {
+	mu   sync.Mutex
+	text string
+}
+
+func (m *Memory) Read() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.text, nil
+}
+
+func (m *Memory) Write(text string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.text = text
+	return nil
+}
+
+// Fallback uses the first of the providers that works. What was written is
+// remembered, so it can be read back when none of the providers can read.
+type Fallback struct {
+	Providers []Provider
+
+	last Memory
+}
+
+func (f *Fallback) Read() (string, error) {
+	for _, p := range f.Providers {
+		if text, err := p.Read(); err == nil {
+			return text, nil
+		}
+	}
+	return f.last.Read()
+}
+
+func (f *Fallback) Write(text string) error {
+	_ = f.last.Write(text)
+
+	err := error(ErrUnsupported)
+	for _, p := range f.Providers {
+		if err = p.Write(text); err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type failing struct{}
+
+func (failing) Read() (string, error) { return "", errors.New("no display") }
+
+func (failing) Write(string) error { return errors.New("no display") }
+
+func TestOSC52(t *testing.T) {
+	t.Setenv("TMUX", "")
+
+	var out bytes.Buffer
+	if err := (OSC52{Out: &out}).Write("hello"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "\x1b]52;c;aGVsbG8=\a" {
+		t.Fatalf("unexpected %q", out.String())
+	}
+}
+
+func TestFallback(t *testing.T) {
+	var out bytes.Buffer
+	f := &Fallback{Providers: []Provider{failing{}, OSC52{Out: &out}}}
+
+	if err := f.Write("hello"); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() <= 0 {
+		t.Fatal("expected the text to be written as OSC 52")
+	}
+
+	if text, err := f.Read(); err != nil || text != "hello" {
+		t.Fatalf("unexpected %q %v", text, err)
+	}
+
+	f = &Fallback{Providers: []Provider{failing{}}}
+	if err := f.Write("hello"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	var m Memory
+	_ = m.Write("hello")
+	if text, _ := m.Read(); text != "hello" {
+		t.Fatalf("unexpected %q", text)
+	}
+}
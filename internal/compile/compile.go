@@ -0,0 +1,163 @@
+// Package compile runs build and test commands and finds the locations of
+// the errors in their output.
+package compile
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Location is a position in a file reported by a command, e.g. a compiler
+// error or a failed test.
+type Location struct {
+	Filename string
+	// Row and Col are 0-based, Col is 0 when the command reported no column.
+	Row int
+	Col int
+	// Message is the text after the position.
+	Message string
+}
+
+// locationRe matches `file:line:col: message` and `file:line: message`, the
+// file may start with a drive letter.
+var locationRe = regexp.MustCompile(`^\s*((?:[A-Za-z]:)?[^\s:]+):(\d+)(?::(\d+))?:\s*(.*)$`)
+
+// ParseLocation parses the location at the start of line.
+func ParseLocation(line string) (Location, bool) {
+	m := locationRe.FindStringSubmatch(line)
+	// a file name has an extension or a directory, unlike e.g. a time.
+	if m == nil || !strings.ContainsAny(m[1], `./\`) {
+		return Location{}, false
+	}
+
+	row, err := strconv.Atoi(m[2])
+	if err != nil || row < 1 {
+		return Location{}, false
+	}
+	col := 1
+	if m[3] != "" {
+		if col, err = strconv.Atoi(m[3]); err != nil || col < 1 {
+			return Location{}, false
+		}
+	}
+	return Location{Filename: m[1], Row: row - 1, Col: col - 1, Message: m[4]}, true
+}
+
+// Event is a line of output of a command, or its end.
+type Event struct {
+	Line string
+	// Done whether the command exited, Err is its error, e.g. a non-zero
+	// exit status.
+	Done bool
+	Err  error
+}
+
+// Run runs the program name with args, the lines of its standard output and
+// error are sent on the returned channel in order, the last event is Done.
+// The program and its subprocesses are killed when ctx is cancelled.
+func Run(ctx context.Context, name string, args ...string) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+
+		send := func(e Event) bool {
+			select {
+			case events <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		r, w := io.Pipe()
+		cmd := exec.Command(name, args...)
+		cmd.Stdout, cmd.Stderr = w, w
+		setGroup(cmd)
+		if err := cmd.Start(); err != nil {
+			send(Event{Done: true, Err: err})
+			return
+		}
+
+		// the subprocesses of the program, e.g. the compiler of a build, are
+		// killed too, Wait would wait for them otherwise, they hold the
+		// output.
+		exited := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				_ = killGroup(cmd)
+				_ = w.CloseWithError(ctx.Err())
+			case <-exited:
+			}
+		}()
+
+		waited := make(chan error, 1)
+		go func() {
+			err := cmd.Wait()
+			close(exited)
+			_ = w.Close()
+			waited <- err
+		}()
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if !send(Event{Line: scanner.Text()}) {
+				break
+			}
+		}
+		// drain the output so that the program doesn't block on a full pipe.
+		_, _ = io.Copy(io.Discard, r)
+
+		err := <-waited
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		send(Event{Done: true, Err: err})
+	}()
+	return events
+}
+
+// Resolve returns the file of a location relative to dir. Test failures
+// report the file relative to their package, so a file that doesn't exist
+// is looked up in the subdirectories of dir, the first match wins.
+func Resolve(dir, filename string) string {
+	if filepath.IsAbs(filename) {
+		return filename
+	}
+
+	path := filepath.Join(dir, filename)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	found := path
+	suffix := string(filepath.Separator) + filepath.Clean(filename)
+	errFound := errors.New("found")
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(p, suffix) {
+			found = p
+			return errFound
+		}
+		return nil
+	})
+	return found
+}
@@ -0,0 +1,135 @@
+package compile
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		line string
+		want Location
+		ok   bool
+	}{
+		{"./main.go:12:5: undefined: foo", Location{Filename: "./main.go", Row: 11, Col: 4, Message: "undefined: foo"}, true},
+		{"    doc_test.go:30: got 1, want 2", Location{Filename: "doc_test.go", Row: 29, Message: "got 1, want 2"}, true},
+		{`C:\ge\ui.go:3:1: syntax error`, Location{Filename: `C:\ge\ui.go`, Row: 2, Message: "syntax error"}, true},
+		{"src/app.ts:7:10: error TS2304", Location{Filename: "src/app.ts", Row: 6, Col: 9, Message: "error TS2304"}, true},
+		{"12:30:45: not a file", Location{}, false},
+		{"# github.com/fzdwx/ge/ui", Location{}, false},
+		{"main.go:0: no line", Location{}, false},
+		{"ok  \tgithub.com/fzdwx/ge/internal/diff\t0.002s", Location{}, false},
+	}
+
+	for _, test := range tests {
+		got, ok := ParseLocation(test.line)
+		if ok != test.ok || got != test.want {
+			t.Errorf("ParseLocation(%q) = %+v, %v, want %+v, %v", test.line, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	var (
+		lines []string
+		last  Event
+	)
+	for e := range Run(context.Background(), "sh", "-c", "echo out; echo err >&2; exit 3") {
+		if e.Done {
+			last = e
+			continue
+		}
+		lines = append(lines, e.Line)
+	}
+
+	if len(lines) != 2 {
+		t.Errorf("unexpected lines %q", lines)
+	}
+	if !last.Done || last.Err == nil || last.Err.Error() != "exit status 3" {
+		t.Errorf("unexpected end %+v", last)
+	}
+}
+
+func TestRun_Cancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// the sleep is a subprocess, it outlives sh unless its group is killed.
+	events := Run(ctx, "sh", "-c", "sleep 60 & echo $!; wait")
+	e := <-events
+	pid, err := strconv.Atoi(e.Line)
+	if err != nil {
+		t.Fatalf("unexpected event %+v", e)
+	}
+
+	start := time.Now()
+	cancel()
+	for range events {
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Run returned %v after the cancel", d)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); alive(pid); {
+		if time.Now().After(deadline) {
+			t.Fatalf("the subprocess %d is still running", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// alive reports whether the process pid runs, a zombie doesn't.
+func alive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil || p.Signal(syscall.Signal(0)) != nil {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return true
+	}
+	// the state follows the command, which is in parentheses.
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pkg", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"main.go", "pkg/sub/sub_test.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := []string{
+		Resolve(dir, "main.go"),
+		Resolve(dir, "sub_test.go"),
+		Resolve(dir, "missing.go"),
+	}
+	want := []string{
+		filepath.Join(dir, "main.go"),
+		filepath.Join(dir, "pkg", "sub", "sub_test.go"),
+		filepath.Join(dir, "missing.go"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package compile
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setGroup starts cmd in its own process group, so that its subprocesses
+// are killed with it.
+func setGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killGroup kills the process group of cmd.
+func killGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package compile
+
+import "os/exec"
+
+func setGroup(*exec.Cmd) {}
+
+// killGroup kills cmd, Windows has no process groups to kill its
+// subprocesses with it.
+func killGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
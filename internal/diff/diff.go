@@ -0,0 +1,172 @@
+package diff
+
+type (
+	// Hunk is a run of changes, A[A:AEnd] is replaced by B[B:BEnd].
+	Hunk struct {
+		A    int
+		AEnd int
+		B    int
+		BEnd int
+	}
+
+	op int
+)
+
+const (
+	equal op = iota
+	insert
+	remove
+)
+
+// maxEditDistance when the inputs have more differences than this, the rest
+// of them is reported as a single hunk, this bounds the memory used by the
+// trace to O(maxEditDistance²).
+const maxEditDistance = 1024
+
+// Lines computes the hunks that turn a into b.
+func Lines(a, b []string) []Hunk {
+	return Compute(a, b)
+}
+
+// Runes computes the hunks that turn a into b, used for intra-line changes.
+func Runes(a, b []rune) []Hunk {
+	return Compute(a, b)
+}
+
+// Compute computes the hunks that turn a into b with the Myers algorithm.
+func Compute[T comparable](a, b []T) []Hunk {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+
+	var (
+		hunks []Hunk
+		x, y  = prefix, prefix
+	)
+	for i := 0; i < len(ops); {
+		if ops[i] == equal {
+			x, y, i = x+1, y+1, i+1
+			continue
+		}
+
+		hunk := Hunk{A: x, B: y}
+		for ; i < len(ops) && ops[i] != equal; i++ {
+			if ops[i] == remove {
+				x++
+			} else {
+				y++
+			}
+		}
+		hunk.AEnd, hunk.BEnd = x, y
+		hunks = append(hunks, hunk)
+	}
+
+	return hunks
+}
+
+// myers returns the shortest edit script of a to b.
+func myers[T comparable](a, b []T) []op {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(n, m)
+	}
+
+	var (
+		max   = n + m
+		off   = max + 1
+		v     = make([]int, 2*max+3)
+		trace [][]int
+	)
+
+	for d := 0; d <= max; d++ {
+		if d > maxEditDistance {
+			return replaceAll(n, m)
+		}
+
+		// keep the window [-d, d] of v, which is all the backtracking needs.
+		trace = append(trace, append([]int{}, v[off-d:off+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+
+	return replaceAll(n, m)
+}
+
+func backtrack(trace [][]int, n, m int) []op {
+	var (
+		ops  []op
+		x, y = n, m
+	)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[prevK+d]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, equal)
+			x, y = x-1, y-1
+		}
+
+		if x == prevX {
+			ops = append(ops, insert)
+		} else {
+			ops = append(ops, remove)
+		}
+		x, y = prevX, prevY
+	}
+
+	for x > 0 && y > 0 {
+		ops = append(ops, equal)
+		x, y = x-1, y-1
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+func replaceAll(n, m int) []op {
+	ops := make([]op, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, remove)
+	}
+	for i := 0; i < m; i++ {
+		ops = append(ops, insert)
+	}
+	return ops
+}
@@ -0,0 +1,122 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want []Hunk
+	}{
+		{"a b c", "a b c", nil},
+		{"a b c", "a x c", []Hunk{{A: 1, AEnd: 2, B: 1, BEnd: 2}}},
+		{"a b c", "a c", []Hunk{{A: 1, AEnd: 2, B: 1, BEnd: 1}}},
+		{"a c", "a b c", []Hunk{{A: 1, AEnd: 1, B: 1, BEnd: 2}}},
+		{"", "a b", []Hunk{{A: 0, AEnd: 0, B: 0, BEnd: 2}}},
+		{"a b c d e f", "x b c y e", []Hunk{{A: 0, AEnd: 1, B: 0, BEnd: 1}, {A: 3, AEnd: 4, B: 3, BEnd: 4}, {A: 5, AEnd: 6, B: 5, BEnd: 5}}},
+	}
+
+	for _, test := range tests {
+		got := Lines(strings.Fields(test.a), strings.Fields(test.b))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Lines(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestLines_Apply(t *testing.T) {
+	a := strings.Fields("the quick brown fox jumps over the lazy dog")
+	b := strings.Fields("a quick red fox jumped over the dog and cat")
+
+	var (
+		out  []string
+		last int
+	)
+	for _, hunk := range Lines(a, b) {
+		out = append(out, a[last:hunk.A]...)
+		out = append(out, b[hunk.B:hunk.BEnd]...)
+		last = hunk.AEnd
+	}
+	out = append(out, a[last:]...)
+
+	if !reflect.DeepEqual(out, b) {
+		t.Fatalf("got %v, want %v", out, b)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := strings.Fields("1 2 3 4 5 6 7 8 9 10 11 12")
+	b := strings.Fields("1 2 x 4 5 6 7 8 9 10 12 13")
+
+	want := `--- a
++++ b
+@@ -2,3 +2,3 @@
+ 2
+-3
++x
+ 4
+@@ -10,3 +10,3 @@
+ 10
+-11
+ 12
++13
+`
+	if got := Unified("a", "b", a, b, 1); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	want = `--- a
++++ b
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++x
+ 4
+ 5
+ 6
+@@ -8,5 +8,5 @@
+ 8
+ 9
+ 10
+-11
+ 12
++13
+`
+	if got := Unified("a", "b", a, b, 3); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if got := Unified("a", "b", a, a, 3); got != "" {
+		t.Errorf("equal inputs: got %q", got)
+	}
+	if got, want := Unified("a", "b", nil, []string{"x"}, 3), "--- a\n+++ b\n@@ -0,0 +1 @@\n+x\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestUnifiedText(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"1\n2", "1\n2\n", "@@ -1,2 +1,2 @@\n 1\n-2\n\\ No newline at end of file\n+2\n"},
+		{"1\n2\n", "1\n2", "@@ -1,2 +1,2 @@\n 1\n-2\n+2\n\\ No newline at end of file\n"},
+		{"1\n2", "1\n3", "@@ -1,2 +1,2 @@\n 1\n-2\n\\ No newline at end of file\n+3\n\\ No newline at end of file\n"},
+		{"1\n2", "0\n1\n2", "@@ -1,2 +1,3 @@\n+0\n 1\n 2\n\\ No newline at end of file\n"},
+		{"", "x", "@@ -0,0 +1 @@\n+x\n\\ No newline at end of file\n"},
+		{"1\n2", "1\n2", ""},
+	}
+	for _, test := range tests {
+		want := test.want
+		if want != "" {
+			want = "--- a\n+++ b\n" + want
+		}
+		if got := UnifiedText("a", "b", test.a, test.b, 3); got != want {
+			t.Errorf("UnifiedText(%q, %q) = %q, want %q", test.a, test.b, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unified formats the changes that turn a into b as a unified diff with
+// context unchanged lines around each change, aName and bName are the names
+// of the files in the header. The diff of equal inputs is empty.
+func Unified(aName, bName string, a, b []string, context int) string {
+	return unified(aName, bName, text{lines: a}, text{lines: b}, context)
+}
+
+// UnifiedText is Unified of the lines of the texts a and b, a text that
+// doesn't end with a newline is marked like by diff(1), so that the diff
+// applies with patch.
+func UnifiedText(aName, bName, a, b string, context int) string {
+	return unified(aName, bName, splitText(a), splitText(b), context)
+}
+
+// text the lines of a file, noEOL whether its last line has no newline.
+type text struct {
+	lines []string
+	noEOL bool
+}
+
+func splitText(s string) text {
+	if s == "" {
+		return text{}
+	}
+	lines := strings.Split(s, "\n")
+	if lines[len(lines)-1] == "" {
+		return text{lines: lines[:len(lines)-1]}
+	}
+	return text{lines: lines, noEOL: true}
+}
+
+// keys returns the lines compared, a last line without a newline differs
+// from the same line with one. The lines have no newlines otherwise.
+func (t text) keys() []string {
+	if !t.noEOL {
+		return t.lines
+	}
+	keys := append([]string(nil), t.lines...)
+	keys[len(keys)-1] += "\n"
+	return keys
+}
+
+// write writes the lines [start, end) of t with prefix.
+func (t text) write(sb *strings.Builder, prefix byte, start, end int) {
+	for i := start; i < end; i++ {
+		sb.WriteByte(prefix)
+		sb.WriteString(t.lines[i])
+		sb.WriteByte('\n')
+		if t.noEOL && i == len(t.lines)-1 {
+			sb.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
+
+func unified(aName, bName string, a, b text, context int) string {
+	hunks := Lines(a.keys(), b.keys())
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+	for len(hunks) > 0 {
+		// hunks closer than twice the context share their context lines.
+		n := 1
+		for n < len(hunks) && hunks[n].A-hunks[n-1].AEnd <= 2*context {
+			n++
+		}
+		group := hunks[:n]
+		hunks = hunks[n:]
+
+		first, last := group[0], group[len(group)-1]
+		aStart, bStart := max(first.A-context, 0), max(first.B-context, 0)
+		aEnd, bEnd := min(last.AEnd+context, len(a.lines)), min(last.BEnd+context, len(b.lines))
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", lineRange(aStart, aEnd), lineRange(bStart, bEnd))
+
+		x := aStart
+		for _, hunk := range group {
+			a.write(&sb, ' ', x, hunk.A)
+			a.write(&sb, '-', hunk.A, hunk.AEnd)
+			b.write(&sb, '+', hunk.B, hunk.BEnd)
+			x = hunk.AEnd
+		}
+		a.write(&sb, ' ', x, aEnd)
+	}
+	return sb.String()
+}
+
+// lineRange formats the lines [start, end) for a hunk header, lines are
+// counted from 1 and an empty range names the line before it.
+func lineRange(start, end int) string {
+	switch end - start {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, end-start)
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,144 @@
+// Package editorconfig reads the settings of a file from the .editorconfig
+// files of its directory and the ones above, see https://editorconfig.org.
+package editorconfig
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Filename the name of the files holding the settings.
+const Filename = ".editorconfig"
+
+// Properties the settings of a file, the names and the values are lower
+// case, e.g. indent_style = tab.
+type Properties map[string]string
+
+type (
+	// file is a parsed .editorconfig.
+	file struct {
+		root     bool
+		sections []section
+	}
+
+	// section the properties of the files matching its glob.
+	section struct {
+		glob       *glob
+		properties [][2]string
+	}
+)
+
+// Lookup returns the properties of filename. The files closer to filename
+// take precedence, and the later sections of a file over the earlier ones.
+// The lookup stops at a file marked as root.
+func Lookup(filename string) (Properties, error) {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// the closest file first.
+	var files []*file
+	for dir := filepath.Dir(path); ; {
+		f, err := parseFile(filepath.Join(dir, Filename), dir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if f != nil {
+			files = append(files, f)
+			if f.root {
+				break
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	properties := Properties{}
+	target := filepath.ToSlash(path)
+	for i := len(files) - 1; i >= 0; i-- {
+		for _, s := range files[i].sections {
+			if !s.glob.match(target) {
+				continue
+			}
+			for _, p := range s.properties {
+				properties[p[0]] = p[1]
+			}
+		}
+	}
+
+	for name, value := range properties {
+		// unset removes a property set by a file further away.
+		if value == "unset" {
+			delete(properties, name)
+		}
+	}
+	if properties["indent_size"] == "tab" {
+		if width, ok := properties["tab_width"]; ok {
+			properties["indent_size"] = width
+		}
+	}
+	if _, ok := properties["tab_width"]; !ok {
+		if size, err := strconv.Atoi(properties["indent_size"]); err == nil {
+			properties["tab_width"] = strconv.Itoa(size)
+		}
+	}
+	return properties, nil
+}
+
+// parseFile parses the .editorconfig at path, the globs of its sections are
+// relative to dir.
+func parseFile(path, dir string) (*file, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	f := &file{}
+	var current *section
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			g, err := compileGlob(filepath.ToSlash(dir), line[1:len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+			}
+			f.sections = append(f.sections, section{glob: g})
+			current = &f.sections[len(f.sections)-1]
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected a section or name = value", path, n)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		if current == nil {
+			// the properties before the first section are about the file.
+			if name == "root" {
+				f.root = value == "true"
+			}
+			continue
+		}
+		current.properties = append(current.properties, [2]string{name, value})
+	}
+	return f, scanner.Err()
+}
@@ -0,0 +1,105 @@
+package editorconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func write(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "outside", Filename), "[*]\ncharset = latin1\n")
+	root := filepath.Join(dir, "outside", "project")
+	write(t, filepath.Join(root, Filename), `# top
+root = true
+
+[*]
+indent_style = space
+indent_size = 4
+end_of_line = LF
+
+[*.{go,mod}]
+indent_style = tab
+indent_size = tab
+tab_width = 8
+
+[Makefile]
+indent_style = tab
+
+[/docs/**.md]
+trim_trailing_whitespace = false
+`)
+	write(t, filepath.Join(root, "docs", Filename), "[*.md]\nindent_size = 2\nend_of_line = unset\n")
+
+	tests := []struct {
+		file string
+		want Properties
+	}{
+		{"main.go", Properties{"indent_style": "tab", "indent_size": "8", "tab_width": "8", "end_of_line": "lf"}},
+		{"sub/dir/Makefile", Properties{"indent_style": "tab", "indent_size": "4", "tab_width": "4", "end_of_line": "lf"}},
+		{"docs/a/b.md", Properties{"indent_style": "space", "indent_size": "2", "tab_width": "2", "trim_trailing_whitespace": "false"}},
+		{"sub/docs/b.md", Properties{"indent_style": "space", "indent_size": "4", "tab_width": "4", "end_of_line": "lf"}},
+	}
+	for _, tt := range tests {
+		got, err := Lookup(filepath.Join(root, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%s) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestLookup_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, Filename), "[*]\nindent_style\n")
+	if _, err := Lookup(filepath.Join(dir, "a.go")); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*", "/p/a.go", true},
+		{"*.go", "/p/x/y/a.go", true},
+		{"*.go", "/p/a.goo", false},
+		{"x/*.go", "/p/x/a.go", true},
+		{"x/*.go", "/p/x/y/a.go", false},
+		{"x/**.go", "/p/x/y/a.go", true},
+		{"/a.go", "/p/x/a.go", false},
+		{"a?.txt", "/p/ab.txt", true},
+		{"[Mm]akefile", "/p/makefile", true},
+		{"[!M]akefile", "/p/Makefile", false},
+		{"{*.js,*.ts}", "/p/a.ts", true},
+		{"{*.js,*.ts}", "/p/a.go", false},
+		{"file{1..3}.txt", "/p/file2.txt", true},
+		{"file{1..3}.txt", "/p/file4.txt", false},
+		{"{single}.txt", "/p/{single}.txt", true},
+		{`a\*.txt`, "/p/a*.txt", true},
+		{`a\*.txt`, "/p/ab.txt", false},
+	}
+	for _, tt := range tests {
+		g, err := compileGlob("/p", tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := g.match(tt.path); got != tt.want {
+			t.Errorf("glob %s matches %s = %v, want %v (%s)", tt.pattern, tt.path, got, tt.want, g.re)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package editorconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var numberRangeRe = regexp.MustCompile(`^([+-]?\d+)\.\.([+-]?\d+)$`)
+
+// glob matches the paths of a section, the numeric ranges like {1..3} are
+// checked once the regexp matched.
+type glob struct {
+	re     *regexp.Regexp
+	ranges [][2]int
+}
+
+// compileGlob compiles the glob of a section of the .editorconfig in dir. A
+// glob without a slash matches the file name in any directory below dir.
+func compileGlob(dir, pattern string) (*glob, error) {
+	g := &glob{}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	sb.WriteString(regexp.QuoteMeta(strings.TrimSuffix(dir, "/")))
+	switch {
+	case strings.HasPrefix(pattern, "/"):
+		sb.WriteString("/")
+		pattern = pattern[1:]
+	case strings.Contains(pattern, "/"):
+		sb.WriteString("/")
+	default:
+		sb.WriteString("/(?:.*/)?")
+	}
+	g.translate(&sb, []rune(pattern))
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %s: %w", pattern, err)
+	}
+	g.re = re
+	return g, nil
+}
+
+func (g *glob) match(path string) bool {
+	m := g.re.FindStringSubmatch(path)
+	if m == nil {
+		return false
+	}
+	for i, r := range g.ranges {
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil || n < r[0] || n > r[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// translate writes the regexp of the glob p to sb.
+func (g *glob) translate(sb *strings.Builder, p []rune) {
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '\\':
+			if i+1 < len(p) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(p[i])))
+			} else {
+				sb.WriteString(`\\`)
+			}
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				i++
+				sb.WriteString(".*")
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := indexRune(p, i+1, ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := p[i+1 : end]
+			sb.WriteString("[")
+			if len(class) > 0 && class[0] == '!' {
+				sb.WriteString("^")
+				class = class[1:]
+			}
+			for _, r := range class {
+				if r == '\\' || r == '[' || r == ']' || r == '^' {
+					sb.WriteRune('\\')
+				}
+				sb.WriteRune(r)
+			}
+			sb.WriteString("]")
+			i = end
+		case '{':
+			end := closingBrace(p, i)
+			if end < 0 {
+				sb.WriteString(`\{`)
+				continue
+			}
+			g.translateBraces(sb, p[i+1:end])
+			i = end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+}
+
+// translateBraces writes the regexp of {inner}, either a range of numbers or
+// alternatives separated by commas.
+func (g *glob) translateBraces(sb *strings.Builder, inner []rune) {
+	if m := numberRangeRe.FindStringSubmatch(string(inner)); m != nil {
+		lo, _ := strconv.Atoi(m[1])
+		hi, _ := strconv.Atoi(m[2])
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		g.ranges = append(g.ranges, [2]int{lo, hi})
+		sb.WriteString(`([+-]?\d+)`)
+		return
+	}
+
+	alternatives := splitAlternatives(inner)
+	if len(alternatives) < 2 {
+		// a single word is taken literally.
+		sb.WriteString(`\{`)
+		g.translate(sb, inner)
+		sb.WriteString(`\}`)
+		return
+	}
+
+	sb.WriteString("(?:")
+	for i, alternative := range alternatives {
+		if i > 0 {
+			sb.WriteString("|")
+		}
+		g.translate(sb, alternative)
+	}
+	sb.WriteString(")")
+}
+
+// closingBrace returns the index of the brace closing the one at start, -1
+// if there is none.
+func closingBrace(p []rune, start int) int {
+	depth := 0
+	for i := start; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitAlternatives splits p at the commas outside of nested braces.
+func splitAlternatives(p []rune) [][]rune {
+	var (
+		alternatives [][]rune
+		depth, start int
+	)
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alternatives = append(alternatives, p[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(alternatives, p[start:])
+}
+
+func indexRune(p []rune, start int, r rune) int {
+	for i := start; i < len(p); i++ {
+		if p[i] == r {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,87 @@
+// Package format runs the formatters of the languages, e.g. gofmt, on the
+// content of a document.
+package format
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Timeout the time a formatter may run.
+const Timeout = 10 * time.Second
+
+// Formatter is a program that reads the source on its standard input and
+// writes it formatted to its standard output.
+type Formatter struct {
+	Name string
+	// Args the arguments of the program, {file} is replaced by the name of the
+	// file, which the tools use to find their configuration or parser.
+	Args []string
+}
+
+var (
+	prettier = Formatter{Name: "prettier", Args: []string{"--stdin-filepath", "{file}"}}
+
+	// formatters the formatters of a syntax type, the first installed one is
+	// used.
+	formatters = map[string][]Formatter{
+		"go":         {{Name: "goimports", Args: []string{"-srcdir", "{file}"}}, {Name: "gofmt"}},
+		"javascript": {prettier},
+		"typescript": {prettier},
+		"json":       {prettier},
+		"css":        {prettier},
+		"scss":       {prettier},
+		"html":       {prettier},
+		"yaml":       {prettier},
+		"md":         {prettier},
+	}
+
+	lookPath = exec.LookPath
+)
+
+// For returns the formatter of the syntax type typ, false if none of them is
+// installed.
+func For(typ string) (Formatter, bool) {
+	for _, f := range formatters[typ] {
+		if _, err := lookPath(f.Name); err == nil {
+			return f, true
+		}
+	}
+	return Formatter{}, false
+}
+
+// Format runs f on src, the content of filename. The error of a failed
+// formatter holds what it wrote to its standard error.
+func (f Formatter) Format(ctx context.Context, filename string, src []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	args := make([]string, len(f.Args))
+	for i, arg := range f.Args {
+		args[i] = strings.ReplaceAll(arg, "{file}", filename)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, f.Name, args...)
+	cmd.Stdin = bytes.NewReader(src)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s: timed out after %s", f.Name, Timeout)
+		}
+
+		// the tools report the errors of the standard input, e.g. gofmt.
+		message := strings.TrimSpace(strings.ReplaceAll(stderr.String(), "<standard input>", filename))
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("%s: %s", f.Name, message)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package format
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFor(t *testing.T) {
+	defer func() { lookPath = exec.LookPath }()
+	lookPath = func(name string) (string, error) {
+		if name == "gofmt" {
+			return "/usr/bin/gofmt", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	if f, ok := For("go"); !ok || f.Name != "gofmt" {
+		t.Fatalf("unexpected %v %v", f, ok)
+	}
+	if _, ok := For("typescript"); ok {
+		t.Fatal("expected no formatter without prettier")
+	}
+	if _, ok := For("unknown"); ok {
+		t.Fatal("expected no formatter")
+	}
+}
+
+func TestFormatter_Format(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	upper := Formatter{Name: "sh", Args: []string{"-c", "echo {file}; tr a-z A-Z"}}
+	out, err := upper.Format(context.Background(), "a.txt", []byte("hello\n"))
+	if err != nil || string(out) != "a.txt\nHELLO\n" {
+		t.Fatalf("unexpected %q %v", out, err)
+	}
+
+	failing := Formatter{Name: "sh", Args: []string{"-c", "echo '<standard input>:1:1: bad' >&2; exit 2"}}
+	if _, err := failing.Format(context.Background(), "a.go", nil); err == nil || err.Error() != "sh: a.go:1:1: bad" {
+		t.Fatalf("unexpected %v", err)
+	}
+}
+
+func TestFormatter_Format_Gofmt(t *testing.T) {
+	if _, err := exec.LookPath("gofmt"); err != nil {
+		t.Skip("needs gofmt")
+	}
+
+	gofmt := Formatter{Name: "gofmt"}
+	out, err := gofmt.Format(context.Background(), "a.go", []byte("package a\nfunc f(){\nreturn}\n"))
+	if err != nil || string(out) != "package a\n\nfunc f() {\n\treturn\n}\n" {
+		t.Fatalf("unexpected %q %v", out, err)
+	}
+
+	if _, err := gofmt.Format(context.Background(), "a.go", []byte("package a\nfunc f(){")); err == nil || !strings.HasPrefix(err.Error(), "gofmt: a.go:2:") {
+		t.Fatalf("unexpected %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package fuzzy matches a pattern against names the way pickers do, the
+// runes of the pattern have to appear in order but not next to each other.
+package fuzzy
+
+import (
+	"sort"
+	"unicode"
+)
+
+const (
+	// the bonuses of a matched rune.
+	bonusFirst       = 8
+	bonusWordStart   = 6
+	bonusConsecutive = 4
+	// penaltyGap is subtracted for every skipped rune between two matches.
+	penaltyGap = 1
+)
+
+// Match is a name matching a pattern.
+type Match struct {
+	// Index the index of the name in the matched names.
+	Index int
+	Score int
+	// Positions the indexes of the matched runes of the name.
+	Positions []int
+}
+
+// MatchString reports whether pattern matches s, ignoring the case unless
+// the pattern has upper case runes. A match at the start of a word scores
+// higher, e.g. the b of fooBar or foo_bar.
+func MatchString(pattern, s string) (Match, bool) {
+	p, runes := []rune(pattern), []rune(s)
+	fold := !hasUpper(p)
+
+	m := Match{Positions: make([]int, 0, len(p))}
+	j := 0
+	for i := 0; i < len(runes) && j < len(p); i++ {
+		r := runes[i]
+		if fold {
+			r = unicode.ToLower(r)
+		}
+		if r != p[j] {
+			continue
+		}
+
+		switch {
+		case i == 0:
+			m.Score += bonusFirst
+		case wordStart(runes, i):
+			m.Score += bonusWordStart
+		}
+		if n := len(m.Positions); n > 0 {
+			if last := m.Positions[n-1]; last == i-1 {
+				m.Score += bonusConsecutive
+			} else {
+				m.Score -= penaltyGap * (i - last - 1)
+			}
+		}
+		m.Positions = append(m.Positions, i)
+		j++
+	}
+	if j < len(p) {
+		return Match{}, false
+	}
+	return m, true
+}
+
+// Find returns the names matching pattern, the best first, the shorter
+// names win a tie. An empty pattern matches every name in order.
+func Find(pattern string, names []string) []Match {
+	var matches []Match
+	for i, name := range names {
+		if m, ok := MatchString(pattern, name); ok {
+			m.Index = i
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return len(names[matches[a].Index]) < len(names[matches[b].Index])
+	})
+	if pattern == "" {
+		sort.SliceStable(matches, func(a, b int) bool {
+			return matches[a].Index < matches[b].Index
+		})
+	}
+	return matches
+}
+
+// wordStart reports whether runes[i] starts a word, it follows a separator
+// or is an upper case rune after a lower case one.
+func wordStart(runes []rune, i int) bool {
+	prev, r := runes[i-1], runes[i]
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsUpper(r) && unicode.IsLower(prev)
+}
+
+func hasUpper(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package fuzzy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchString(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		ok         bool
+		positions  []int
+	}{
+		{"nd", "NewDocument", true, []int{0, 3}},
+		{"ND", "NewDocument", true, []int{0, 3}},
+		{"Nd", "NewDocument", false, nil},
+		{"dcm", "(*Document).Save", true, []int{2, 4, 6}},
+		{"xyz", "Save", false, nil},
+		{"", "Save", true, []int{}},
+	}
+	for _, tt := range tests {
+		m, ok := MatchString(tt.pattern, tt.s)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(m.Positions, tt.positions)) {
+			t.Errorf("MatchString(%q, %q) = %v, %v, want %v, %v", tt.pattern, tt.s, m.Positions, ok, tt.positions, tt.ok)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	names := []string{"renderLine", "readLines", "Reload", "rl"}
+
+	var got []string
+	for _, m := range Find("rl", names) {
+		got = append(got, names[m.Index])
+	}
+	want := []string{"rl", "readLines", "renderLine", "Reload"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find(rl) = %v, want %v", got, want)
+	}
+
+	if all := Find("", names); len(all) != len(names) || all[1].Index != 1 {
+		t.Errorf("Find() = %v, want every name in order", all)
+	}
+}
@@ -0,0 +1,55 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotTracked is returned when a file is not in a git repository or not
+// committed yet.
+var ErrNotTracked = errors.New("file is not tracked by git")
+
+// Root returns the top level directory of the work tree that contains dir.
+func Root(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", ErrNotTracked
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Head returns the content of filename in the HEAD commit.
+func Head(filename string) ([]byte, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, name := filepath.Split(abs)
+	if _, err := Root(dir); err != nil {
+		return nil, err
+	}
+
+	// `./` makes the path relative to dir instead of the top level directory.
+	out, err := run(dir, "show", "HEAD:./"+name)
+	if err != nil {
+		return nil, ErrNotTracked
+	}
+	return out, nil
+}
+
+func run(dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
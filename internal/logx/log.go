@@ -23,7 +23,7 @@ func InitLog(debug bool, logFilename string) {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 	}
 
-	file, err := os.OpenFile(logFilename, os.O_APPEND|os.O_CREATE, 0755)
+	file, err := os.OpenFile(logFilename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,111 @@
+// Package macro stores keyboard macros, recorded key sequences that can be
+// replayed, in a human-readable file.
+package macro
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/config"
+)
+
+// Macro is a recorded sequence of keys.
+type Macro []tea.KeyMsg
+
+// keyTypes the key types by name, e.g. "ctrl+k" or "enter".
+var keyTypes = map[string]tea.KeyType{}
+
+func init() {
+	// the named key types are the control characters and the negative
+	// special keys.
+	for t := tea.KeyType(-128); t <= 127; t++ {
+		if name := t.String(); name != "" && t != tea.KeyRunes {
+			keyTypes[name] = t
+		}
+	}
+}
+
+// ParseKey parses a key as written by tea.KeyMsg.String, e.g. "alt+d",
+// "ctrl+k" or "x".
+func ParseKey(s string) tea.KeyMsg {
+	var k tea.Key
+	if name := strings.TrimPrefix(s, "alt+"); name != s && name != "" {
+		k.Alt, s = true, name
+	}
+
+	if t, ok := keyTypes[s]; ok {
+		k.Type = t
+		if t == tea.KeySpace {
+			k.Runes = []rune{' '}
+		}
+		return tea.KeyMsg(k)
+	}
+
+	k.Type, k.Runes = tea.KeyRunes, []rune(s)
+	return tea.KeyMsg(k)
+}
+
+func (m Macro) MarshalJSON() ([]byte, error) {
+	keys := make([]string, len(m))
+	for i, k := range m {
+		keys[i] = k.String()
+	}
+	return json.Marshal(keys)
+}
+
+func (m *Macro) UnmarshalJSON(data []byte) error {
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+
+	*m = make(Macro, len(keys))
+	for i, k := range keys {
+		(*m)[i] = ParseKey(k)
+	}
+	return nil
+}
+
+// Path returns the default file of the macros in the config directory.
+func Path() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "macros.json"), nil
+}
+
+// Load reads the macros by name from filename, a missing file has none.
+func Load(filename string) (map[string]Macro, error) {
+	macros := map[string]Macro{}
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return macros, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &macros); err != nil {
+		return nil, err
+	}
+	return macros, nil
+}
+
+// Save writes the macros by name to filename.
+func Save(filename string, macros map[string]Macro) error {
+	data, err := json.MarshalIndent(macros, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
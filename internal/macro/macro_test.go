@@ -0,0 +1,48 @@
+package macro
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseKey(t *testing.T) {
+	for _, k := range []tea.KeyMsg{
+		{Type: tea.KeyCtrlK},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyUp},
+		{Type: tea.KeyRunes, Runes: []rune("x")},
+		{Type: tea.KeyRunes, Runes: []rune("我"), Alt: true},
+		{Type: tea.KeyBackspace, Alt: true},
+		{Type: tea.KeySpace, Runes: []rune(" ")},
+	} {
+		if got := ParseKey(k.String()); !reflect.DeepEqual(got, k) {
+			t.Errorf("ParseKey(%q) = %#v, want %#v", k.String(), got, k)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ge", "macros.json")
+
+	macros := map[string]Macro{
+		"q": {{Type: tea.KeyCtrlA}, {Type: tea.KeyRunes, Runes: []rune("// ")}, {Type: tea.KeyDown}},
+	}
+	if err := Save(filename, macros); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, macros) {
+		t.Fatalf("unexpected %v", loaded)
+	}
+
+	if loaded, err := Load(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(loaded) != 0 {
+		t.Fatalf("unexpected %v %v", loaded, err)
+	}
+}
@@ -0,0 +1,376 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	rw "github.com/mattn/go-runewidth"
+)
+
+// ListItem the marker of a list item, e.g. `  1. [ ] `.
+type ListItem struct {
+	Indent string
+	// Bullet is -, * or +, it is empty for an ordered item.
+	Bullet string
+	// Number and Delimiter, . or ), of an ordered item.
+	Number    int
+	Delimiter string
+	// Checkbox whether the item is a task, Checked whether it is done.
+	Checkbox bool
+	Checked  bool
+	// Content the column where the text of the item starts.
+	Content int
+}
+
+var listItemRe = regexp.MustCompile(`^([ \t]*)(?:([-*+])|(\d{1,9})([.)]))(?:[ \t]+|$)(?:\[([ xX])\](?:[ \t]+|$))?`)
+
+// ParseListItem parses the list item marker at the start of line.
+func ParseListItem(line string) (ListItem, bool) {
+	m := listItemRe.FindStringSubmatch(line)
+	if m == nil {
+		return ListItem{}, false
+	}
+
+	item := ListItem{
+		Indent:    m[1],
+		Bullet:    m[2],
+		Delimiter: m[4],
+		Checkbox:  m[5] != "",
+		Checked:   m[5] == "x" || m[5] == "X",
+		Content:   len([]rune(m[0])),
+	}
+	if m[3] != "" {
+		item.Number, _ = strconv.Atoi(m[3])
+	}
+	return item, true
+}
+
+// Ordered reports whether the item is numbered.
+func (i ListItem) Ordered() bool {
+	return i.Bullet == ""
+}
+
+// Marker returns the marker of the item, with a space after it.
+func (i ListItem) Marker() string {
+	marker := i.Indent + i.Bullet
+	if i.Ordered() {
+		marker += strconv.Itoa(i.Number) + i.Delimiter
+	}
+	marker += " "
+	switch {
+	case i.Checked:
+		marker += "[x] "
+	case i.Checkbox:
+		marker += "[ ] "
+	}
+	return marker
+}
+
+// Next returns the item after i, the next number and an open task.
+func (i ListItem) Next() ListItem {
+	next := i
+	if next.Ordered() {
+		next.Number++
+	}
+	next.Checked = false
+	next.Content = len([]rune(next.Marker()))
+	return next
+}
+
+// Renumber numbers the ordered items of the list after the one at row
+// consecutively, it returns the changed lines keyed by row. The nested
+// items and the blank lines between the items are skipped.
+func Renumber(lines []string, row int) map[int]string {
+	item, ok := ParseListItem(lines[row])
+	if !ok || !item.Ordered() {
+		return nil
+	}
+
+	changed := map[int]string{}
+	number := item.Number
+	for i := row + 1; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || indentWidth(line) > indentWidth(item.Indent) {
+			continue
+		}
+
+		next, ok := ParseListItem(line)
+		if !ok || !next.Ordered() || next.Indent != item.Indent {
+			break
+		}
+		number++
+		if next.Number != number {
+			content := []rune(line)[next.Content:]
+			next.Number = number
+			changed[i] = next.Marker() + string(content)
+		}
+	}
+	return changed
+}
+
+// ToggleCheckbox ticks or clears the task of the list item line, an item
+// without a checkbox gets an open one. ok is false if line isn't an item.
+func ToggleCheckbox(line string) (string, bool) {
+	item, ok := ParseListItem(line)
+	if !ok {
+		return line, false
+	}
+
+	content := []rune(line)[item.Content:]
+	if item.Checkbox {
+		item.Checked = !item.Checked
+	}
+	item.Checkbox = true
+	return item.Marker() + string(content), true
+}
+
+var delimiterCellRe = regexp.MustCompile(`^:?-+:?$`)
+
+// IsTableRow reports whether line is a row of a table, it starts with a
+// pipe.
+func IsTableRow(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "|")
+}
+
+// TableAt returns the rows [start, end) of the table around row, ok is false
+// if row isn't in a table with a delimiter row.
+func TableAt(lines []string, row int) (int, int, bool) {
+	if row < 0 || row >= len(lines) || !IsTableRow(lines[row]) {
+		return 0, 0, false
+	}
+
+	start, end := row, row+1
+	for start > 0 && IsTableRow(lines[start-1]) {
+		start--
+	}
+	for end < len(lines) && IsTableRow(lines[end]) {
+		end++
+	}
+	if end-start < 2 || !isDelimiterRow(lines[start+1]) {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+func isDelimiterRow(line string) bool {
+	for _, cell := range splitCells(line) {
+		if !delimiterCellRe.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// AlignTable pads the cells of the table rows so that the columns line up
+// on the screen, the delimiter row is the second one. The columns are
+// aligned by the colons of the delimiter row.
+func AlignTable(rows []string) []string {
+	var (
+		cells  = make([][]string, len(rows))
+		widths []int
+	)
+	for i, row := range rows {
+		cells[i] = splitCells(row)
+		for len(widths) < len(cells[i]) {
+			// the delimiter needs 3 dashes.
+			widths = append(widths, 3)
+		}
+		for j, cell := range cells[i] {
+			if i != 1 {
+				widths[j] = max(widths[j], rw.StringWidth(cell))
+			}
+		}
+	}
+
+	aligns := make([]string, len(widths))
+	for j := range aligns {
+		if j < len(cells[1]) {
+			aligns[j] = cells[1][j]
+		}
+	}
+
+	indent := rows[0][:len(rows[0])-len(strings.TrimLeft(rows[0], " \t"))]
+	aligned := make([]string, len(rows))
+	for i := range rows {
+		var sb strings.Builder
+		sb.WriteString(indent)
+		sb.WriteString("|")
+		for j, width := range widths {
+			cell := ""
+			if j < len(cells[i]) {
+				cell = cells[i][j]
+			}
+			sb.WriteString(" ")
+			if i == 1 {
+				sb.WriteString(delimiter(aligns[j], width))
+			} else {
+				sb.WriteString(pad(cell, width, aligns[j]))
+			}
+			sb.WriteString(" |")
+		}
+		aligned[i] = sb.String()
+	}
+	return aligned
+}
+
+// delimiter returns the delimiter cell of a column of width aligned like
+// align, e.g. :--.
+func delimiter(align string, width int) string {
+	left, right := strings.HasPrefix(align, ":"), strings.HasSuffix(align, ":")
+	dashes := width
+	if left {
+		dashes--
+	}
+	if right {
+		dashes--
+	}
+
+	cell := strings.Repeat("-", dashes)
+	if left {
+		cell = ":" + cell
+	}
+	if right {
+		cell += ":"
+	}
+	return cell
+}
+
+// pad pads cell to width, on the left of a column aligned to the right and
+// on both sides of a centered one.
+func pad(cell string, width int, align string) string {
+	n := width - rw.StringWidth(cell)
+	switch {
+	case strings.HasPrefix(align, ":") && strings.HasSuffix(align, ":"):
+		return strings.Repeat(" ", n/2) + cell + strings.Repeat(" ", n-n/2)
+	case strings.HasSuffix(align, ":"):
+		return strings.Repeat(" ", n) + cell
+	default:
+		return cell + strings.Repeat(" ", n)
+	}
+}
+
+// splitCells returns the trimmed cells of a table row, the pipes escaped by
+// a backslash are part of the cells.
+func splitCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var (
+		cells []string
+		cell  strings.Builder
+	)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteString(`\|`)
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// Cell returns the index of the cell of the table row at the rune column
+// col, and the column in the trimmed text of the cell.
+func Cell(line string, col int) (int, int) {
+	runes := []rune(line)
+	cell, start := -1, 0
+	for i := 0; i < len(runes) && i < col; i++ {
+		if runes[i] == '|' && (i == 0 || runes[i-1] != '\\') {
+			cell, start = cell+1, i+1
+		}
+	}
+	for start < col && start < len(runes) && runes[start] == ' ' {
+		start++
+	}
+	return max(cell, 0), max(col-start, 0)
+}
+
+// CellCol returns the rune column of offset in the trimmed text of the cell
+// of an aligned table row, the end of the row if there is no such cell.
+func CellCol(line string, cell, offset int) int {
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '|' || (i > 0 && runes[i-1] == '\\') {
+			continue
+		}
+		if cell == 0 {
+			return min(i+2+offset, len(runes))
+		}
+		cell--
+	}
+	return len(runes)
+}
+
+var (
+	linkRe     = regexp.MustCompile(`!?\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	autolinkRe = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9+.-]*:[^>\s]+)>`)
+)
+
+// LinkAt returns the target of the inline link of line at the rune column
+// col, e.g. docs/usage.md#install.
+func LinkAt(line string, col int) (string, bool) {
+	for _, re := range []*regexp.Regexp{linkRe, autolinkRe} {
+		for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+			start, end := len([]rune(line[:m[0]])), len([]rune(line[:m[1]]))
+			if start <= col && col < end {
+				return line[m[2]:m[3]], true
+			}
+		}
+	}
+	return "", false
+}
+
+// Slug returns the anchor of a heading, like GitHub does: the lowercase
+// letters, digits, - and _ of the text with dashes for the spaces.
+func Slug(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, strings.TrimSpace(text))
+}
+
+// indentWidth returns the width of the indentation of line, a tab counts as
+// 4 spaces.
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,101 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseListItem(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+		next string
+	}{
+		{"- a", true, "- "},
+		{"  * [x] done", true, "  * [ ] "},
+		{"9) b", true, "10) "},
+		{"1.", true, "2. "},
+		{"---", false, ""},
+		{"*emphasis*", false, ""},
+		{"2020. was a year", true, "2021. "},
+	}
+	for _, tt := range tests {
+		item, ok := ParseListItem(tt.line)
+		if ok != tt.ok {
+			t.Errorf("ParseListItem(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			continue
+		}
+		if next := item.Next(); ok && (next.Marker() != tt.next || next.Content != len(tt.next)) {
+			t.Errorf("ParseListItem(%q).Next() = %q, want %q", tt.line, next.Marker(), tt.next)
+		}
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	lines := strings.Split("1. a\n2. new\n2. b\n   more\n   1. nested\n\n3. c\ntext\n1. other", "\n")
+	want := map[int]string{2: "3. b", 6: "4. c"}
+	if got := Renumber(lines, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Renumber() = %q, want %q", got, want)
+	}
+}
+
+func TestToggleCheckbox(t *testing.T) {
+	for line, want := range map[string]string{
+		"- [ ] a":    "- [x] a",
+		"  1. [X] b": "  1. [ ] b",
+		"- c":        "- [ ] c",
+	} {
+		if got, ok := ToggleCheckbox(line); !ok || got != want {
+			t.Errorf("ToggleCheckbox(%q) = %q, want %q", line, got, want)
+		}
+	}
+	if _, ok := ToggleCheckbox("text"); ok {
+		t.Errorf("ToggleCheckbox(text) ok = true")
+	}
+}
+
+func TestAlignTable(t *testing.T) {
+	lines := strings.Split("text\n| a | 名前 |x\n|:-|--:|\n|ccc|d\\|e|\ntext", "\n")
+	start, end, ok := TableAt(lines, 3)
+	if !ok || start != 1 || end != 4 {
+		t.Fatalf("TableAt() = %d, %d, %v", start, end, ok)
+	}
+
+	want := []string{
+		"| a   | 名前 | x   |",
+		"| :-- | ---: | --- |",
+		"| ccc | d\\|e |     |",
+	}
+	got := AlignTable(lines[start:end])
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("AlignTable() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	cell, offset := Cell(lines[3], 6)
+	if cell != 1 || offset != 1 {
+		t.Errorf("Cell() = %d, %d", cell, offset)
+	}
+	if col := CellCol(got[2], cell, offset); col != 9 {
+		t.Errorf("CellCol() = %d, want 9", col)
+	}
+}
+
+func TestLinkAt(t *testing.T) {
+	line := `see [the docs](docs/a.md#install "title") or <https://x.org>`
+	if target, ok := LinkAt(line, 6); !ok || target != "docs/a.md#install" {
+		t.Errorf("LinkAt(6) = %q, %v", target, ok)
+	}
+	if target, ok := LinkAt(line, 50); !ok || target != "https://x.org" {
+		t.Errorf("LinkAt(50) = %q, %v", target, ok)
+	}
+	if _, ok := LinkAt(line, 1); ok {
+		t.Errorf("LinkAt(1) ok = true")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	if got := Slug("The `Usage` of C++ & Go_1"); got != "the-usage-of-c--go_1" {
+		t.Errorf("Slug() = %q", got)
+	}
+}
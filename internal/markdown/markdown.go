@@ -0,0 +1,144 @@
+// Package markdown renders Markdown documents for the terminal, see Render.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/fzdwx/ge/internal/syntax"
+)
+
+// Style the glamour style of the rendered documents.
+var Style = "dark"
+
+// Preview a rendered Markdown document.
+type Preview struct {
+	// Lines the rendered lines, styled with escape sequences.
+	Lines []string
+	// Headings the headings of the source in order.
+	Headings []Heading
+}
+
+// Heading is where a heading of the source is rendered.
+type Heading struct {
+	// Row the row of the heading in the source.
+	Row int
+	// Line the index of the heading in Lines, -1 when it wasn't found.
+	Line int
+}
+
+// escapeRe matches the escape sequences of the styles.
+var escapeRe = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// Renderer renders Markdown documents, it keeps the glamour renderer of the
+// last width. A Renderer renders one document at a time.
+type Renderer struct {
+	width int
+	term  *glamour.TermRenderer
+}
+
+// Render renders the Markdown rows wrapped at width.
+func Render(rows []string, width int) (Preview, error) {
+	return new(Renderer).Render(rows, width)
+}
+
+// Render renders the Markdown rows wrapped at width.
+func (r *Renderer) Render(rows []string, width int) (Preview, error) {
+	if r.term == nil || r.width != width {
+		term, err := glamour.NewTermRenderer(glamour.WithStandardStyle(Style), glamour.WithWordWrap(width))
+		if err != nil {
+			return Preview{}, err
+		}
+		r.term, r.width = term, width
+	}
+	out, err := r.term.Render(strings.Join(rows, "\n"))
+	if err != nil {
+		return Preview{}, err
+	}
+
+	// glamour surrounds the document with blank lines.
+	lines := strings.Split(out, "\n")
+	for len(lines) > 0 && isBlank(lines[0]) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && isBlank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+
+	runes := make([][]rune, len(rows))
+	for i, row := range rows {
+		runes[i] = []rune(row)
+	}
+	return Preview{Lines: lines, Headings: locate(syntax.Headings(runes), lines)}, nil
+}
+
+// locate finds the headings in the rendered lines, they are searched in
+// order after each other.
+func locate(headings []syntax.Heading, lines []string) []Heading {
+	located := make([]Heading, len(headings))
+	next := 0
+	for i, h := range headings {
+		located[i] = Heading{Row: h.Row, Line: -1}
+
+		text := normalize(h.Text)
+		if text == "" {
+			continue
+		}
+		for j := next; j < len(lines); j++ {
+			if n := headingLines(text, lines[j:]); n > 0 {
+				located[i].Line = j
+				next = j + n
+				break
+			}
+		}
+	}
+	return located
+}
+
+// headingLines returns the number of lines the normalized heading text is
+// rendered to at the start of lines, 0 if it isn't. A long heading is
+// wrapped, together its lines are the whole text.
+func headingLines(text string, lines []string) int {
+	for n, line := range lines {
+		line = normalize(line)
+		if line == "" || !strings.HasPrefix(text, line) {
+			return 0
+		}
+		if text = text[len(line):]; text == "" {
+			return n + 1
+		}
+	}
+	return 0
+}
+
+// LineOf returns the line of the heading of the source row, the first line
+// when the row is before the first heading.
+func (p Preview) LineOf(row int) int {
+	line := 0
+	for _, h := range p.Headings {
+		if h.Row > row {
+			break
+		}
+		if h.Line >= 0 {
+			line = h.Line
+		}
+	}
+	return line
+}
+
+// normalize returns the letters and digits of s, the styles and the markup
+// differ between the source and the rendered lines.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, escapeRe.ReplaceAllString(s, ""))
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(escapeRe.ReplaceAllString(line, "")) == ""
+}
@@ -0,0 +1,56 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fzdwx/ge/internal/syntax"
+)
+
+func TestRender(t *testing.T) {
+	rows := strings.Split("# Title\n\nSome *text*.\n\n```\n# not a heading\n```\n\n## The `Usage`\n\nmore", "\n")
+	p, err := Render(rows, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Lines) == 0 || isBlank(p.Lines[0]) || isBlank(p.Lines[len(p.Lines)-1]) {
+		t.Fatalf("Render() = %q, want no blank lines around it", p.Lines)
+	}
+	if len(p.Headings) != 2 {
+		t.Fatalf("Headings = %v, want 2", p.Headings)
+	}
+	for _, tt := range []struct {
+		row  int
+		want string
+	}{
+		{0, "title"},
+		{3, "title"},
+		{8, "theusage"},
+		{10, "theusage"},
+	} {
+		if got := normalize(p.Lines[p.LineOf(tt.row)]); got != tt.want {
+			t.Errorf("LineOf(%d) = %q, want %q", tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	headings := syntax.Headings([][]rune{
+		[]rune("# The Usage"),
+		[]rune("## A long heading wrapped over lines"),
+	})
+	lines := []string{
+		"The",
+		"usage of the tool.",
+		"  \x1b[1mThe Usage\x1b[0m",
+		"text",
+		"## A long heading",
+		"wrapped over lines",
+	}
+
+	located := locate(headings, lines)
+	if located[0].Line != 2 || located[1].Line != 4 {
+		t.Errorf("locate() = %v, want the lines 2 and 4", located)
+	}
+}
@@ -0,0 +1,268 @@
+// Package rpcplugin runs the plugins that are separate executables and talk
+// to ge over stdio, see package plugin/protocol.
+package rpcplugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fzdwx/ge/config"
+	"github.com/fzdwx/ge/internal/logx"
+	"github.com/fzdwx/ge/plugin/jsonrpc"
+	"github.com/fzdwx/ge/plugin/protocol"
+)
+
+const (
+	// initializeTimeout how long a plugin may take to answer initialize.
+	initializeTimeout = 5 * time.Second
+	// shutdownTimeout how long a plugin may take to exit after shutdown.
+	shutdownTimeout = time.Second
+	// eventQueueSize the number of notifications queued for a plugin.
+	eventQueueSize = 1024
+)
+
+// Request is a request or a notification of a plugin, it is handled by the
+// editor, which calls Reply exactly once.
+type Request struct {
+	Plugin *Plugin
+	Method string
+	Params json.RawMessage
+
+	reply chan reply
+}
+
+type reply struct {
+	result interface{}
+	err    error
+}
+
+// Reply answers the request, the answer of a notification is dropped.
+func (r *Request) Reply(result interface{}, err error) {
+	r.reply <- reply{result: result, err: err}
+}
+
+// Plugin is a running plugin.
+type Plugin struct {
+	Path string
+	Info protocol.InitializeResult
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	conn   *jsonrpc.Conn
+	events chan event
+	exited chan struct{}
+
+	// closeOnce shuts the plugin down once, closeErr is the result.
+	closeOnce sync.Once
+	closeErr  error
+}
+
+type event struct {
+	method string
+	params interface{}
+}
+
+// Dir returns the directory of the executable plugins in the config
+// directory. It is apart from the plugins of package script, they run
+// sandboxed while the executables run with the rights of the user, so only
+// the ones put there on purpose are started.
+func Dir() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rpc-plugins"), nil
+}
+
+// Discover returns the executables in dir, a missing directory has none.
+func Discover(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Start runs the plugin path and initializes it, its requests are sent to
+// requests.
+func Start(path string, requests chan<- *Request) (*Plugin, error) {
+	p := &Plugin{
+		Path:   path,
+		cmd:    exec.Command(path),
+		events: make(chan event, eventQueueSize),
+		exited: make(chan struct{}),
+	}
+
+	stdin, err := p.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := p.cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p.stdin = stdin
+	p.conn = jsonrpc.NewConn(stdout, stdin, func(method string, params json.RawMessage) (interface{}, error) {
+		r := &Request{Plugin: p, Method: method, Params: params, reply: make(chan reply, 1)}
+		requests <- r
+		answer := <-r.reply
+		return answer.result, answer.err
+	})
+
+	go p.log(stderr)
+	go func() {
+		if err := p.conn.Run(); err != nil {
+			logx.Warn().Err(err).Str("plugin", path).Msg("plugin connection failed")
+		}
+		_ = p.cmd.Wait()
+		close(p.exited)
+	}()
+
+	if err := p.initialize(); err != nil {
+		p.kill()
+		return nil, fmt.Errorf("plugin %s: %w", filepath.Base(path), err)
+	}
+
+	go p.sendEvents()
+	return p, nil
+}
+
+func (p *Plugin) initialize() error {
+	done := make(chan error, 1)
+	go func() {
+		done <- p.conn.Call(protocol.MethodInitialize, protocol.InitializeParams{
+			ProtocolVersion: protocol.Version,
+			Editor:          "ge",
+		}, &p.Info)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case <-time.After(initializeTimeout):
+		return errors.New("no answer to initialize")
+	}
+
+	if p.Info.ProtocolVersion != protocol.Version {
+		return fmt.Errorf("speaks protocol version %d, ge speaks %d", p.Info.ProtocolVersion, protocol.Version)
+	}
+	if p.Info.Name == "" {
+		p.Info.Name = filepath.Base(p.Path)
+	}
+	return nil
+}
+
+// log writes the stderr of the plugin to the log.
+func (p *Plugin) log(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		logx.Info().Str("plugin", p.Path).Msg(scanner.Text())
+	}
+}
+
+// sendEvents sends the queued notifications in order.
+func (p *Plugin) sendEvents() {
+	for e := range p.events {
+		if err := p.conn.Notify(e.method, e.params); err != nil {
+			logx.Warn().Err(err).Str("plugin", p.Path).Str("method", e.method).Msg("could not notify plugin")
+		}
+	}
+}
+
+// Name returns the name of the plugin.
+func (p *Plugin) Name() string {
+	return p.Info.Name
+}
+
+// Wants reports whether the plugin subscribed to the event, see
+// protocol.EventChange and protocol.EventSave.
+func (p *Plugin) Wants(event string) bool {
+	for _, e := range p.Info.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// RunCommand runs a command of the plugin and waits until it is done.
+func (p *Plugin) RunCommand(params protocol.RunCommandParams) error {
+	return p.conn.Call(protocol.MethodRunCommand, params, nil)
+}
+
+// DidChange queues the notification of a change, it doesn't block.
+func (p *Plugin) DidChange(params protocol.DidChangeParams) {
+	p.notify(protocol.MethodDidChange, params)
+}
+
+// DidSave queues the notification of a save, it doesn't block.
+func (p *Plugin) DidSave(params protocol.DidSaveParams) {
+	p.notify(protocol.MethodDidSave, params)
+}
+
+func (p *Plugin) notify(method string, params interface{}) {
+	select {
+	case p.events <- event{method: method, params: params}:
+	default:
+		logx.Warn().Str("plugin", p.Path).Str("method", method).Msg("plugin is too slow, dropped notification")
+	}
+}
+
+// Close shuts the plugin down, it is killed if it doesn't exit in time.
+// Closing it again returns the same result.
+func (p *Plugin) Close() error {
+	p.closeOnce.Do(func() { p.closeErr = p.shutdown() })
+	return p.closeErr
+}
+
+func (p *Plugin) shutdown() error {
+	close(p.events)
+	_ = p.conn.Notify(protocol.MethodShutdown, struct{}{})
+	_ = p.stdin.Close()
+
+	select {
+	case <-p.exited:
+		return nil
+	case <-time.After(shutdownTimeout):
+		p.kill()
+		return fmt.Errorf("plugin %s did not exit", p.Name())
+	}
+}
+
+func (p *Plugin) kill() {
+	if p.cmd.Process != nil {
+		_ = p.cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,150 @@
+package rpcplugin
+
+import (
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fzdwx/ge/internal/script"
+	"github.com/fzdwx/ge/plugin/protocol"
+)
+
+// buildStub builds the stub plugin of testdata/stub.
+func buildStub(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "stub")
+	out, err := exec.Command("go", "build", "-o", path, "./testdata/stub").CombinedOutput()
+	if err != nil {
+		t.Fatalf("build stub: %v\n%s", err, out)
+	}
+	return path
+}
+
+// serve answers the requests of the plugin like the editor does, with a
+// single document.
+func serve(t *testing.T, requests chan *Request, text string) <-chan string {
+	messages := make(chan string, 16)
+	version := 1
+	go func() {
+		for r := range requests {
+			switch r.Method {
+			case protocol.MethodGetDocument:
+				r.Reply(protocol.GetDocumentResult{Filename: "a.txt", Version: version, Text: text}, nil)
+			case protocol.MethodApplyEdits:
+				var params protocol.ApplyEditsParams
+				if err := json.Unmarshal(r.Params, &params); err != nil {
+					t.Error(err)
+				}
+				applied := params.Version == version && len(params.Edits) == 1
+				if applied {
+					text = params.Edits[0].Text
+					version++
+				}
+				r.Reply(protocol.ApplyEditsResult{Applied: applied, Version: version}, nil)
+				messages <- "text " + text
+			case protocol.MethodShowMessage:
+				var params protocol.ShowMessageParams
+				_ = json.Unmarshal(r.Params, &params)
+				r.Reply(nil, nil)
+				messages <- params.Text
+			default:
+				r.Reply(nil, nil)
+			}
+		}
+	}()
+	return messages
+}
+
+func expect(t *testing.T, messages <-chan string, want string) {
+	t.Helper()
+
+	select {
+	case got := <-messages:
+		if got != want {
+			t.Fatalf("got message %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no message, want %q", want)
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	stub := buildStub(t)
+
+	requests := make(chan *Request)
+	messages := serve(t, requests, "hello\nworld")
+
+	p, err := Start(stub, requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Name() != "stub" || len(p.Info.Commands) != 2 || !p.Wants(protocol.EventChange) || !p.Wants(protocol.EventSave) {
+		t.Fatalf("unexpected %+v", p.Info)
+	}
+
+	if err := p.RunCommand(protocol.RunCommandParams{Name: "upcase", Filename: "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, messages, "text HELLO\nWORLD")
+	expect(t, messages, "applied true")
+
+	err = p.RunCommand(protocol.RunCommandParams{Name: "fail", Arg: "x"})
+	if err == nil || !strings.Contains(err.Error(), `failed with "x"`) {
+		t.Fatalf("unexpected %v", err)
+	}
+
+	p.DidChange(protocol.DidChangeParams{Filename: "a.txt", Version: 3, Edit: protocol.Edit{Text: "!"}})
+	expect(t, messages, `changed 3 "!"`)
+	p.DidSave(protocol.DidSaveParams{Filename: "a.txt"})
+	expect(t, messages, "saved a.txt")
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("second Close() = %v", err)
+	}
+}
+
+func TestPlugin_Version(t *testing.T) {
+	stub := buildStub(t)
+	t.Setenv("STUB_VERSION", "99")
+
+	_, err := Start(stub, make(chan *Request))
+	if err == nil || !strings.Contains(err.Error(), "protocol version 99") {
+		t.Fatalf("unexpected %v", err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	stub := buildStub(t)
+
+	paths, err := Discover(filepath.Dir(stub))
+	if err != nil || len(paths) != 1 || paths[0] != stub {
+		t.Fatalf("unexpected %v %v", paths, err)
+	}
+
+	if paths, err := Discover(filepath.Join(t.TempDir(), "missing")); err != nil || paths != nil {
+		t.Fatalf("unexpected %v %v", paths, err)
+	}
+}
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir, err := Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scripts, err := script.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir == scripts {
+		t.Errorf("Dir() = %s, the directory of the scripts", dir)
+	}
+}
@@ -0,0 +1,54 @@
+// Command stub is a plugin used by the tests of package rpcplugin.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fzdwx/ge/plugin/jsonrpc"
+	"github.com/fzdwx/ge/plugin/protocol"
+	"github.com/fzdwx/ge/plugin/sdk"
+)
+
+func main() {
+	if os.Getenv("STUB_VERSION") != "" {
+		// answer initialize with an unknown protocol version.
+		conn := jsonrpc.NewConn(os.Stdin, os.Stdout, func(method string, params json.RawMessage) (interface{}, error) {
+			return protocol.InitializeResult{Name: "stub", ProtocolVersion: 99}, nil
+		})
+		_ = conn.Run()
+		return
+	}
+
+	p := sdk.New("stub")
+	p.Command("upcase", "upcase the document", func(e *sdk.Editor, params protocol.RunCommandParams) error {
+		doc, err := e.Document(params.Filename)
+		if err != nil {
+			return err
+		}
+
+		lines := strings.Split(doc.Text, "\n")
+		end := protocol.Position{Row: len(lines) - 1, Col: len([]rune(lines[len(lines)-1]))}
+		result, err := e.ApplyEdits(doc.Filename, doc.Version, protocol.Edit{To: end, Text: strings.ToUpper(doc.Text)})
+		if err != nil {
+			return err
+		}
+		return e.Message(fmt.Sprintf("applied %v", result.Applied))
+	})
+	p.Command("fail", "always fails", func(e *sdk.Editor, params protocol.RunCommandParams) error {
+		return fmt.Errorf("failed with %q", params.Arg)
+	})
+	p.OnChange(func(e *sdk.Editor, params protocol.DidChangeParams) {
+		_ = e.Message(fmt.Sprintf("changed %d %q", params.Version, params.Edit.Text))
+	})
+	p.OnSave(func(e *sdk.Editor, params protocol.DidSaveParams) {
+		_ = e.Message("saved " + params.Filename)
+	})
+
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
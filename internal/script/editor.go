@@ -0,0 +1,195 @@
+package script
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fzdwx/ge/internal/views"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// module returns the editor module of the plugins.
+func (e *Engine) module() *starlarkstruct.Module {
+	builtins := map[string]func(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error){
+		"filename":   e.filename,
+		"text":       e.text,
+		"line":       e.line,
+		"line_count": e.lineCount,
+		"insert":     e.insert,
+		"replace":    e.replace,
+		"cursor":     e.cursor,
+		"set_cursor": e.setCursor,
+		"selection":  e.selection,
+		"message":    e.message,
+		"command":    e.command,
+		"bind":       e.bind,
+		"execute":    e.execute,
+	}
+
+	members := starlark.StringDict{}
+	for name, f := range builtins {
+		name, f := name, f
+		members[name] = starlark.NewBuiltin(name, func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			v, err := f(args, kwargs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", b.Name(), err)
+			}
+			return v, nil
+		})
+	}
+	return &starlarkstruct.Module{Name: "editor", Members: members}
+}
+
+// filename() returns the file name of the document.
+func (e *Engine) filename(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs("filename", args, kwargs); err != nil {
+		return nil, err
+	}
+	return starlark.String(e.host.Document().Filename()), nil
+}
+
+// text() returns the content of the document.
+func (e *Engine) text(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs("text", args, kwargs); err != nil {
+		return nil, err
+	}
+	return starlark.String(strings.Join(e.host.Document().Lines(), "\n")), nil
+}
+
+// line(row) returns the text of a row.
+func (e *Engine) line(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var row int
+	if err := starlark.UnpackArgs("line", args, kwargs, "row", &row); err != nil {
+		return nil, err
+	}
+
+	document := e.host.Document()
+	if row < 0 || row >= document.Height() {
+		return nil, fmt.Errorf("row %d out of range [0, %d)", row, document.Height())
+	}
+	return starlark.String(document.Row(row).String()), nil
+}
+
+// line_count() returns the number of rows.
+func (e *Engine) lineCount(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs("line_count", args, kwargs); err != nil {
+		return nil, err
+	}
+	return starlark.MakeInt(e.host.Document().Height()), nil
+}
+
+// insert(text) inserts text at the cursor and moves the cursor after it.
+func (e *Engine) insert(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text string
+	if err := starlark.UnpackArgs("insert", args, kwargs, "text", &text); err != nil {
+		return nil, err
+	}
+
+	cursor := e.host.Cursor()
+	e.host.SetCursor(e.host.Replace(cursor, cursor, text))
+	return starlark.None, nil
+}
+
+// replace(from_row, from_col, to_row, to_col, text) replaces the text
+// between the positions, it returns the position after the new text.
+func (e *Engine) replace(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		from, to views.Pos
+		text     string
+	)
+	if err := starlark.UnpackArgs("replace", args, kwargs,
+		"from_row", &from.Row, "from_col", &from.Col, "to_row", &to.Row, "to_col", &to.Col, "text", &text); err != nil {
+		return nil, err
+	}
+
+	return pos(e.host.Replace(from, to, text)), nil
+}
+
+// cursor() returns the row and column of the cursor.
+func (e *Engine) cursor(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs("cursor", args, kwargs); err != nil {
+		return nil, err
+	}
+	return pos(e.host.Cursor()), nil
+}
+
+// set_cursor(row, col) moves the cursor.
+func (e *Engine) setCursor(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p views.Pos
+	if err := starlark.UnpackArgs("set_cursor", args, kwargs, "row", &p.Row, "col", &p.Col); err != nil {
+		return nil, err
+	}
+
+	e.host.SetCursor(e.host.Document().Rows.Clamp(p))
+	return starlark.None, nil
+}
+
+// selection() returns the selected region as (from_row, from_col, to_row,
+// to_col), or None.
+func (e *Engine) selection(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs("selection", args, kwargs); err != nil {
+		return nil, err
+	}
+
+	from, to, ok := e.host.Selection()
+	if !ok {
+		return starlark.None, nil
+	}
+	return starlark.Tuple{
+		starlark.MakeInt(from.Row), starlark.MakeInt(from.Col),
+		starlark.MakeInt(to.Row), starlark.MakeInt(to.Col),
+	}, nil
+}
+
+// message(text) shows text in the status line.
+func (e *Engine) message(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text string
+	if err := starlark.UnpackArgs("message", args, kwargs, "text", &text); err != nil {
+		return nil, err
+	}
+
+	e.host.Message(text)
+	return starlark.None, nil
+}
+
+// command(name, fn, help="") registers fn as an editor command, it is
+// called with the argument of the command line.
+func (e *Engine) command(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		name, help string
+		fn         starlark.Callable
+	)
+	if err := starlark.UnpackArgs("command", args, kwargs, "name", &name, "fn", &fn, "help?", &help); err != nil {
+		return nil, err
+	}
+
+	e.host.RegisterCommand(name, help, func(arg string) error {
+		return e.call(fn, starlark.String(arg))
+	})
+	return starlark.None, nil
+}
+
+// bind(key, command) makes key, e.g. "alt+!", run the command line.
+func (e *Engine) bind(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, command string
+	if err := starlark.UnpackArgs("bind", args, kwargs, "key", &key, "command", &command); err != nil {
+		return nil, err
+	}
+
+	e.host.BindKey(key, command)
+	return starlark.None, nil
+}
+
+// execute(line) runs an editor command line.
+func (e *Engine) execute(args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var line string
+	if err := starlark.UnpackArgs("execute", args, kwargs, "line", &line); err != nil {
+		return nil, err
+	}
+	return starlark.None, e.host.Execute(line)
+}
+
+func pos(p views.Pos) starlark.Tuple {
+	return starlark.Tuple{starlark.MakeInt(p.Row), starlark.MakeInt(p.Col)}
+}
@@ -0,0 +1,186 @@
+// Package script runs Starlark plugins that extend the editor.
+//
+// Plugins are *.star files in the plugins directory of the config directory,
+// they use the predeclared editor module to read and edit the document, move
+// the cursor, register commands and bind keys:
+//
+//	def shout(arg):
+//	    row, col = editor.cursor()
+//	    editor.replace(row, 0, row, len(editor.line(row)), editor.line(row).upper())
+//
+//	editor.command("shout", shout, help = "upcase the current line")
+//	editor.bind("alt+!", "shout")
+//
+// Rows and columns are counted from 0, columns in runes. Plugins are
+// sandboxed, Starlark has no access to the file system, the network or the
+// environment and load only accepts other plugins of the same directory.
+package script
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fzdwx/ge/config"
+	"github.com/fzdwx/ge/internal/views"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// maxSteps bounds the execution of a plugin, so a runaway loop doesn't hang
+// the editor.
+const maxSteps = 50_000_000
+
+// Host is the part of the editor the plugins have access to.
+type Host interface {
+	Document() *views.Document
+	Cursor() views.Pos
+	SetCursor(pos views.Pos)
+	Selection() (from, to views.Pos, ok bool)
+	// Replace replaces the text between the positions of the document and
+	// returns the position after the new text, the cursors move along with
+	// the edit.
+	Replace(from, to views.Pos, text string) views.Pos
+	Message(text string)
+	// RegisterCommand adds a command run by the editor like a builtin one.
+	RegisterCommand(name, help string, run func(arg string) error)
+	// BindKey makes key run the command line.
+	BindKey(key, command string)
+	// Execute runs an editor command line.
+	Execute(line string) error
+}
+
+// Engine loads the plugins and runs their functions.
+type Engine struct {
+	host Host
+	// dir the directory load resolves the plugins in.
+	dir    string
+	loaded map[string]*loadEntry
+	editor *starlarkstruct.Module
+}
+
+type loadEntry struct {
+	globals starlark.StringDict
+	err     error
+}
+
+// New creates an engine running plugins against host.
+func New(host Host) *Engine {
+	e := &Engine{host: host, loaded: map[string]*loadEntry{}}
+	e.editor = e.module()
+	return e
+}
+
+// Dir returns the default plugins directory in the config directory.
+func Dir() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plugins"), nil
+}
+
+// LoadError reports the plugins that failed to load.
+type LoadError []error
+
+func (e LoadError) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// LoadDir runs every *.star file in dir, a missing directory has no plugins.
+func (e *Engine) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	e.dir = dir
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".star" {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var errs LoadError
+	for _, name := range names {
+		if _, err := e.load(nil, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Exec runs the plugin src, filename is used in error messages.
+func (e *Engine) Exec(filename string, src interface{}) error {
+	_, err := starlark.ExecFile(e.thread(filename), filename, src, e.predeclared())
+	return err
+}
+
+// load runs the plugin name of the plugins directory once, it implements
+// the load statement.
+func (e *Engine) load(_ *starlark.Thread, name string) (starlark.StringDict, error) {
+	clean := filepath.Clean(name)
+	if e.dir == "" || filepath.IsAbs(clean) || strings.HasPrefix(clean, "..") {
+		return nil, fmt.Errorf("load %s: only plugins of the plugins directory can be loaded", name)
+	}
+
+	if entry, ok := e.loaded[clean]; ok {
+		if entry == nil {
+			return nil, fmt.Errorf("load %s: cycle in load graph", name)
+		}
+		return entry.globals, entry.err
+	}
+
+	e.loaded[clean] = nil
+	filename := filepath.Join(e.dir, clean)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		e.loaded[clean] = &loadEntry{err: err}
+		return nil, err
+	}
+
+	globals, err := starlark.ExecFile(e.thread(clean), filename, data, e.predeclared())
+	e.loaded[clean] = &loadEntry{globals: globals, err: err}
+	return globals, err
+}
+
+func (e *Engine) thread(name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: name,
+		Load: e.load,
+		Print: func(_ *starlark.Thread, msg string) {
+			e.host.Message(msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+	return thread
+}
+
+func (e *Engine) predeclared() starlark.StringDict {
+	return starlark.StringDict{"editor": e.editor}
+}
+
+// call runs a function of a plugin.
+func (e *Engine) call(fn starlark.Callable, args ...starlark.Value) error {
+	_, err := starlark.Call(e.thread(fn.Name()), fn, args, nil)
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+	return err
+}
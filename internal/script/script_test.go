@@ -0,0 +1,137 @@
+package script
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fzdwx/ge/internal/views"
+)
+
+type fakeHost struct {
+	document *views.Document
+	cursor   views.Pos
+	messages []string
+	commands map[string]func(string) error
+	keys     map[string]string
+}
+
+func newFakeHost(t *testing.T, text string) *fakeHost {
+	rows, err := views.NewRows([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document := views.NewDocument()
+	document.Rows = rows
+	return &fakeHost{document: document, commands: map[string]func(string) error{}, keys: map[string]string{}}
+}
+
+func (h *fakeHost) Document() *views.Document { return h.document }
+func (h *fakeHost) Cursor() views.Pos         { return h.cursor }
+func (h *fakeHost) SetCursor(pos views.Pos)   { h.cursor = pos }
+func (h *fakeHost) Message(text string)       { h.messages = append(h.messages, text) }
+func (h *fakeHost) Replace(from, to views.Pos, text string) views.Pos {
+	return h.document.Replace(from, to, text)
+}
+func (h *fakeHost) BindKey(key, command string) {
+	h.keys[key] = command
+}
+func (h *fakeHost) Selection() (views.Pos, views.Pos, bool) {
+	return views.Pos{}, views.Pos{}, false
+}
+func (h *fakeHost) RegisterCommand(name, help string, run func(arg string) error) {
+	h.commands[name] = run
+}
+func (h *fakeHost) Execute(line string) error {
+	name, arg, _ := strings.Cut(line, " ")
+	return h.commands[name](arg)
+}
+
+func TestEngine_Command(t *testing.T) {
+	host := newFakeHost(t, "hello\nworld")
+	e := New(host)
+
+	err := e.Exec("shout.star", `
+def shout(arg):
+    row, col = editor.cursor()
+    line = editor.line(row)
+    editor.replace(row, 0, row, len(line), line.upper() + arg)
+    print("shouted", editor.line_count())
+
+editor.command("shout", shout, help = "upcase the line")
+editor.bind("alt+!", "shout")
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host.cursor = views.Pos{Row: 1}
+	if err := host.Execute("shout !"); err != nil {
+		t.Fatal(err)
+	}
+	if host.document.String() != "hello\nWORLD!" {
+		t.Fatalf("unexpected %q", host.document.String())
+	}
+	if host.keys["alt+!"] != "shout" || len(host.messages) != 1 || host.messages[0] != "shouted 2" {
+		t.Fatalf("unexpected %v %v", host.keys, host.messages)
+	}
+}
+
+func TestEngine_Insert(t *testing.T) {
+	host := newFakeHost(t, "ab")
+	host.cursor = views.Pos{Col: 1}
+
+	if err := New(host).Exec("insert.star", `editor.insert("x\ny")`); err != nil {
+		t.Fatal(err)
+	}
+	if host.document.String() != "ax\nyb" || host.cursor != (views.Pos{Row: 1, Col: 1}) {
+		t.Fatalf("unexpected %q %v", host.document.String(), host.cursor)
+	}
+
+	if err := New(host).Exec("bad.star", `editor.line(5)`); err == nil {
+		t.Fatal("expected an error for a row out of range")
+	}
+}
+
+func TestEngine_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("lib.star", `greeting = "hi"`)
+	write("a.star", `
+load("lib.star", "greeting")
+editor.message(greeting)
+`)
+	write("escape.star", `load("../secret.star", "x")`)
+
+	host := newFakeHost(t, "")
+	err := New(host).LoadDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "only plugins of the plugins directory") {
+		t.Fatalf("expected the load outside the plugins directory to fail, got %v", err)
+	}
+	if len(host.messages) != 1 || host.messages[0] != "hi" {
+		t.Fatalf("unexpected %v", host.messages)
+	}
+
+	if err := New(host).LoadDir(filepath.Join(dir, "missing")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEngine_MaxSteps(t *testing.T) {
+	host := newFakeHost(t, "")
+	err := New(host).Exec("loop.star", `
+def loop():
+    for i in range(1000000000):
+        pass
+loop()
+`)
+	if err == nil {
+		t.Fatal("expected the loop to be cancelled")
+	}
+}
@@ -0,0 +1,156 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// maxFileSize files bigger than this are skipped.
+	maxFileSize = 8 << 20
+	// sniffLen is how many bytes are inspected to decide whether a file is binary.
+	sniffLen = 8000
+)
+
+type (
+	// Match is a single matched line.
+	Match struct {
+		// Row is the 0-based line index.
+		Row int
+		// Col is the rune offset of the match in the line, so it can be used as a
+		// cursor column directly.
+		Col int
+		// Text is the whole matched line.
+		Text string
+	}
+
+	// Result holds all matches of one file.
+	Result struct {
+		Filename string
+		Matches  []Match
+	}
+)
+
+// Search walks root and greps every regular file with re concurrently.
+//
+// Results are delivered per file on the returned channel, which is closed once
+// the whole tree has been searched or ctx is cancelled.
+func Search(ctx context.Context, root string, re *regexp.Regexp) <-chan Result {
+	var (
+		out   = make(chan Result)
+		paths = make(chan string)
+		wg    sync.WaitGroup
+	)
+
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				matches := grepFile(ctx, path, re)
+				if len(matches) <= 0 {
+					continue
+				}
+
+				select {
+				case out <- Result{Filename: path, Matches: matches}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if d.IsDir() {
+				if path != root && skipDir(d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			select {
+			case paths <- path:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+		close(paths)
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// skipDir reports whether the directory should not be searched, e.g. `.git` or `node_modules`.
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor"
+}
+
+func grepFile(ctx context.Context, path string, re *regexp.Regexp) []Match {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxFileSize {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || isBinary(data) {
+		return nil
+	}
+
+	var (
+		matches []Match
+		scanner = bufio.NewScanner(bytes.NewReader(data))
+		row     int
+	)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFileSize)
+	for ; scanner.Scan(); row++ {
+		if row%1024 == 0 && ctx.Err() != nil {
+			return nil
+		}
+
+		line := scanner.Bytes()
+		loc := re.FindIndex(line)
+		if loc == nil {
+			continue
+		}
+
+		matches = append(matches, Match{
+			Row:  row,
+			Col:  utf8.RuneCount(line[:loc[0]]),
+			Text: string(line),
+		})
+	}
+
+	return matches
+}
+
+func isBinary(data []byte) bool {
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
@@ -0,0 +1,49 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("a.txt", "hello\n我是 hello\nbye")
+	write("sub/b.txt", "nothing here")
+	write(".git/c.txt", "hello")
+	write("bin", "hello\x00")
+
+	var results []Result
+	for result := range Search(context.Background(), root, regexp.MustCompile("hello")) {
+		results = append(results, result)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("expected 1 file, got %v", results)
+	}
+
+	matches := results[0].Matches
+	if len(matches) != 2 || matches[1].Row != 1 || matches[1].Col != 3 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+}
+
+func TestSearch_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for range Search(ctx, ".", regexp.MustCompile(".")) {
+	}
+}
@@ -0,0 +1,109 @@
+package swap
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Swap is the unsaved state of a document, written periodically so that it
+// can be recovered after a crash.
+type Swap struct {
+	Filename string    `json:"filename"`
+	Content  string    `json:"content"`
+	Row      int       `json:"row"`
+	Col      int       `json:"col"`
+	Pid      int       `json:"pid"`
+	Time     time.Time `json:"time"`
+}
+
+// Dir returns the directory the swap files are stored in.
+func Dir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "ge", "swap"), nil
+}
+
+// Path returns the swap file of filename, the absolute path of the file is
+// escaped into the name like vim does, so files never share a swap file.
+func Path(filename string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.ReplaceAll(abs, "%", "%%")
+	name = strings.ReplaceAll(name, string(filepath.Separator), "%")
+	return filepath.Join(dir, name+".swp"), nil
+}
+
+// Write writes the swap file of s.Filename, the file is replaced atomically
+// so a crash while writing never leaves a broken swap file.
+func Write(s Swap) error {
+	path, err := Path(s.Filename)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	s.Pid = os.Getpid()
+	s.Time = time.Now()
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Read reads the swap file of filename, it returns nil if there is none.
+func Read(filename string) (*Swap, error) {
+	path, err := Path(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s Swap
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Remove deletes the swap file of filename if there is one.
+func Remove(filename string) error {
+	path, err := Path(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package swap
+
+import (
+	"testing"
+)
+
+func TestSwap(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	if s, err := Read("a/b.txt"); s != nil || err != nil {
+		t.Fatalf("unexpected swap %v %v", s, err)
+	}
+
+	if err := Write(Swap{Filename: "a/b.txt", Content: "hello", Row: 1, Col: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Read("a/b.txt")
+	if err != nil || s == nil || s.Content != "hello" || s.Row != 1 || s.Col != 2 {
+		t.Fatalf("unexpected swap %v %v", s, err)
+	}
+
+	if err := Remove("a/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := Read("a/b.txt"); s != nil || err != nil {
+		t.Fatalf("swap not removed %v %v", s, err)
+	}
+}
@@ -0,0 +1,182 @@
+// Package symbols finds the declarations of Go source files, e.g. to list
+// them in an outline.
+package symbols
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"unicode/utf8"
+)
+
+// Kind the kind of a declaration.
+type Kind string
+
+const (
+	Func   Kind = "func"
+	Method Kind = "method"
+	Type   Kind = "type"
+	Const  Kind = "const"
+	Var    Kind = "var"
+)
+
+// Symbol is a top-level declaration of a Go file.
+type Symbol struct {
+	Name string
+	Kind Kind
+	// Receiver the type of the receiver of a method, e.g. *Document.
+	Receiver string
+	// Row and Col are 0-based, Col counts runes. End is the last row of the
+	// declaration.
+	Row int
+	Col int
+	End int
+}
+
+// String returns the qualified name of the symbol, e.g. (*Document).Save.
+func (s Symbol) String() string {
+	if s.Receiver == "" {
+		return s.Name
+	}
+	return "(" + s.Receiver + ")." + s.Name
+}
+
+// Parse returns the symbols of the Go source ordered by row. A file with
+// syntax errors returns the symbols of the declarations that could be
+// parsed along with the error.
+func Parse(src []byte) ([]Symbol, error) {
+	symbols, err := parse(src, 0)
+	if err == nil {
+		return symbols, nil
+	}
+
+	// a broken declaration hides the ones after it, e.g. with an unclosed
+	// brace, so the declarations are parsed one by one.
+	symbols = nil
+	for _, d := range split(src) {
+		s, _ := parse(append([]byte("package p\n"), d.src...), d.row-1)
+		symbols = append(symbols, s...)
+	}
+	return symbols, err
+}
+
+// declaration the source of the top-level declaration starting at row.
+type declaration struct {
+	row int
+	src []byte
+}
+
+// split splits src at the rows starting a top-level declaration.
+func split(src []byte) []declaration {
+	var decls []declaration
+	for row, line := range bytes.SplitAfter(src, []byte("\n")) {
+		for _, keyword := range []string{"func", "type", "var", "const", "import"} {
+			if bytes.HasPrefix(line, []byte(keyword)) && len(line) > len(keyword) && !isIdentByte(line[len(keyword)]) {
+				decls = append(decls, declaration{row: row})
+				break
+			}
+		}
+		if len(decls) > 0 {
+			last := &decls[len(decls)-1]
+			last.src = append(last.src, line...)
+		}
+	}
+	return decls
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b >= utf8.RuneSelf
+}
+
+// parse returns the symbols of src, their rows are moved by offset.
+func parse(src []byte, offset int) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if file == nil {
+		return nil, err
+	}
+
+	lines := bytes.Split(src, []byte("\n"))
+	symbol := func(name *ast.Ident, kind Kind, end token.Pos) Symbol {
+		pos := fset.Position(name.Pos())
+		row, col := pos.Line-1, pos.Column-1
+		if row < len(lines) && col <= len(lines[row]) {
+			col = utf8.RuneCount(lines[row][:col])
+		}
+		return Symbol{Name: name.Name, Kind: kind, Row: row + offset, Col: col, End: fset.Position(end).Line - 1 + offset}
+	}
+
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Name == nil || decl.Name.Name == "_" {
+				continue
+			}
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				symbols = append(symbols, symbol(decl.Name, Func, decl.End()))
+				continue
+			}
+			s := symbol(decl.Name, Method, decl.End())
+			s.Receiver = typeName(decl.Recv.List[0].Type)
+			symbols = append(symbols, s)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					symbols = append(symbols, symbol(spec.Name, Type, spec.End()))
+				case *ast.ValueSpec:
+					kind := Var
+					if decl.Tok == token.CONST {
+						kind = Const
+					}
+					for _, name := range spec.Names {
+						if name.Name != "_" {
+							symbols = append(symbols, symbol(name, kind, spec.End()))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].Row < symbols[j].Row
+	})
+	return symbols, err
+}
+
+// typeName returns the name of the type of a receiver, without its type
+// parameters.
+func typeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.StarExpr:
+		return "*" + typeName(expr.X)
+	case *ast.IndexExpr:
+		return typeName(expr.X)
+	case *ast.IndexListExpr:
+		return typeName(expr.X)
+	case *ast.ParenExpr:
+		return typeName(expr.X)
+	}
+	return "?"
+}
+
+// At returns the index of the innermost symbol whose declaration spans row,
+// -1 if there is none.
+func At(symbols []Symbol, row int) int {
+	at := -1
+	for i, s := range symbols {
+		if s.Row > row {
+			break
+		}
+		if s.End >= row {
+			at = i
+		}
+	}
+	return at
+}
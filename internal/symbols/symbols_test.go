@@ -0,0 +1,70 @@
+package symbols
+
+import (
+	"reflect"
+	"testing"
+)
+
+const src = `package p
+
+const (
+	A, _ = 1, 2
+	B    = 3
+)
+
+var x int
+
+type T[K comparable] struct {
+	m map[K]int
+}
+
+func (t *T[K]) Get(k K) int {
+	return t.m[k]
+}
+
+// 名前 has a name with runes.
+func 名前() {}
+`
+
+func TestParse(t *testing.T) {
+	got, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Symbol{
+		{Name: "A", Kind: Const, Row: 3, Col: 1, End: 3},
+		{Name: "B", Kind: Const, Row: 4, Col: 1, End: 4},
+		{Name: "x", Kind: Var, Row: 7, Col: 4, End: 7},
+		{Name: "T", Kind: Type, Row: 9, Col: 5, End: 11},
+		{Name: "Get", Kind: Method, Receiver: "*T", Row: 13, Col: 15, End: 15},
+		{Name: "名前", Kind: Func, Row: 18, Col: 5, End: 18},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() =\n%v\nwant\n%v", got, want)
+	}
+	if s := got[4].String(); s != "(*T).Get" {
+		t.Errorf("String() = %q", s)
+	}
+	if i := At(got, 14); i != 4 {
+		t.Errorf("At(14) = %d, want 4", i)
+	}
+	if i := At(got, 12); i != -1 {
+		t.Errorf("At(12) = %d, want -1", i)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	got, err := Parse([]byte("package p\n\nfunc a() {\n\tif {\n}\n\nfunc b() {}\n\ntype C int\n"))
+	if err == nil {
+		t.Fatal("Parse() err = nil, want a syntax error")
+	}
+
+	var names []string
+	for _, s := range got {
+		names = append(names, s.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "b", "C"}) {
+		t.Errorf("Parse() = %v, want the declarations around the error", names)
+	}
+}
@@ -0,0 +1,63 @@
+package syntax
+
+// Brackets the closing bracket of an opening one.
+var Brackets = map[rune]rune{'(': ')', '[': ']', '{': '}'}
+
+// Opening returns the opening bracket of the closing bracket r.
+func Opening(r rune) (rune, bool) {
+	for opening, closing := range Brackets {
+		if r == closing {
+			return opening, true
+		}
+	}
+	return 0, false
+}
+
+// Match returns the position of the bracket matching the one at row and
+// col, ok is false when there is none. Only the brackets of the code count,
+// see Classify.
+func Match[R ~[]rune](rows []R, classes [][]Class, row, col int) (int, int, bool) {
+	if row < 0 || row >= len(rows) || col < 0 || col >= len(rows[row]) || classes[row][col] != Code {
+		return 0, 0, false
+	}
+
+	r := rows[row][col]
+	other, forward := Brackets[r]
+	if !forward {
+		var ok bool
+		if other, ok = Opening(r); !ok {
+			return 0, 0, false
+		}
+	}
+
+	step := 1
+	if !forward {
+		step = -1
+	}
+	depth := 0
+	for y, x := row, col; y >= 0 && y < len(rows); {
+		if x >= 0 && x < len(rows[y]) && classes[y][x] == Code {
+			switch rows[y][x] {
+			case r:
+				depth++
+			case other:
+				depth--
+				if depth == 0 {
+					return y, x, true
+				}
+			}
+		}
+
+		x += step
+		if x < 0 {
+			y--
+			if y >= 0 {
+				x = len(rows[y]) - 1
+			}
+		} else if x >= len(rows[y]) {
+			y++
+			x = 0
+		}
+	}
+	return 0, 0, false
+}
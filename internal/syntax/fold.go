@@ -0,0 +1,154 @@
+package syntax
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Fold a region of rows that can be folded, the row Start stays visible and
+// the rows after it up to End are hidden.
+type Fold struct {
+	Start int
+	End   int
+}
+
+// Folds returns the fold regions of rows ordered by Start, at most one
+// starts at a row. Markdown is folded by its headings, the languages with
+// brackets by them and the others by the indentation of the rows.
+func Folds[R ~[]rune](typ string, rows []R, tabWidth int) []Fold {
+	var folds []Fold
+	switch typ {
+	case "md":
+		folds = headingFolds(rows)
+	case "go", "javascript", "typescript", "json", "css", "scss":
+		folds = bracketFolds(rows, Classify(LexerOf(typ), rows))
+	default:
+		folds = indentFolds(rows, tabWidth)
+	}
+
+	sort.Slice(folds, func(i, j int) bool {
+		return folds[i].Start < folds[j].Start
+	})
+	return folds
+}
+
+// bracketFolds folds the rows between the brackets of the code which are on
+// different rows, the row of the closing bracket stays visible when the
+// bracket starts it.
+func bracketFolds[R ~[]rune](rows []R, classes [][]Class) []Fold {
+	var (
+		// open the rows of the unclosed brackets.
+		open []int
+		ends = map[int]int{}
+	)
+	for y, row := range rows {
+		for x, r := range row {
+			if classes[y][x] != Code {
+				continue
+			}
+			if _, ok := Brackets[r]; ok {
+				open = append(open, y)
+				continue
+			}
+			if _, ok := Opening(r); !ok || len(open) == 0 {
+				continue
+			}
+
+			start := open[len(open)-1]
+			open = open[:len(open)-1]
+			end := y
+			if strings.TrimSpace(string(row[:x])) == "" {
+				end--
+			}
+			// the outermost region of a row wins.
+			if end > start && end > ends[start] {
+				ends[start] = end
+			}
+		}
+	}
+
+	var folds []Fold
+	for start, end := range ends {
+		folds = append(folds, Fold{Start: start, End: end})
+	}
+	return folds
+}
+
+// indentFolds folds the rows indented deeper than the row before them, the
+// blank rows at the end of a region stay visible.
+func indentFolds[R ~[]rune](rows []R, tabWidth int) []Fold {
+	var folds []Fold
+	for i, row := range rows {
+		if isBlank(row) {
+			continue
+		}
+
+		width, end := indentWidth(row, tabWidth), i
+		for j := i + 1; j < len(rows); j++ {
+			if isBlank(rows[j]) {
+				continue
+			}
+			if indentWidth(rows[j], tabWidth) <= width {
+				break
+			}
+			end = j
+		}
+		if end > i {
+			folds = append(folds, Fold{Start: i, End: end})
+		}
+	}
+	return folds
+}
+
+// headingFolds folds the section of every Markdown heading up to the next
+// heading of the same or a higher level.
+func headingFolds[R ~[]rune](rows []R) []Fold {
+	var (
+		headings = Headings(rows)
+		folds    []Fold
+	)
+	for i, h := range headings {
+		next := len(rows)
+		for _, other := range headings[i+1:] {
+			if other.Level <= h.Level {
+				next = other.Row
+				break
+			}
+		}
+
+		end := next - 1
+		for end > h.Row && isBlank(rows[end]) {
+			end--
+		}
+		if end > h.Row {
+			folds = append(folds, Fold{Start: h.Row, End: end})
+		}
+	}
+	return folds
+}
+
+// indentWidth returns the display width of the indentation of row.
+func indentWidth[R ~[]rune](row R, tabWidth int) int {
+	width := 0
+	for _, r := range row {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += tabWidth - width%tabWidth
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+func isBlank[R ~[]rune](row R) bool {
+	for _, r := range row {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
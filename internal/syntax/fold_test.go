@@ -0,0 +1,34 @@
+package syntax
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFolds(t *testing.T) {
+	tests := []struct {
+		typ  string
+		text string
+		want []Fold
+	}{
+		{"go", "func f() {\n\tx := \"{\"\n\tif x {\n\t\ty()\n\t}\n}", []Fold{{0, 4}, {2, 3}}},
+		{"go", "import (\n\t\"a\"\n)\nf(a,\n\tb)", []Fold{{0, 1}, {3, 4}}},
+		{"go", "// {\nf() {}\n", nil},
+		{"md", "# a\nx\n## b\ny\n\n# c\n```\n# d\n```", []Fold{{0, 3}, {2, 3}, {5, 8}}},
+		{"md", "#a\n#b", nil},
+		{"yaml", "a:\n  b:\n    c: 1\n\n  d: 2\ne: 3", []Fold{{0, 4}, {1, 2}}},
+		{"", "a\n\tb\n    c\nd", []Fold{{0, 2}}},
+	}
+	for _, tt := range tests {
+		rows := strings.Split(tt.text, "\n")
+		runes := make([][]rune, len(rows))
+		for i, row := range rows {
+			runes[i] = []rune(row)
+		}
+
+		if got := Folds(tt.typ, runes, 4); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Folds(%q, %q) = %v, want %v", tt.typ, tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package syntax
+
+type Golang string
+
+func (g Golang) FileName() string          { return string(g) }
+func (g Golang) Type() string              { return "go" }
+func (g Golang) Highlight(s string) string { return s }
@@ -0,0 +1,73 @@
+package syntax
+
+// Grammar describes the tokens of a language for the parse tree, see Tree.
+type Grammar struct {
+	Lexer
+	Keywords map[string]bool
+	// Continued whether a row ending with an operator continues the
+	// statement on the next row, e.g. a + at the end of a row in Go.
+	Continued bool
+}
+
+var grammars = map[string]Grammar{
+	"go": {
+		Lexer: lexers["go"],
+		Keywords: words(
+			"break", "case", "chan", "const", "continue", "default", "defer", "else",
+			"fallthrough", "for", "func", "go", "goto", "if", "import", "interface",
+			"map", "package", "range", "return", "select", "struct", "switch", "type",
+			"var", "true", "false", "nil", "iota",
+		),
+		Continued: true,
+	},
+	"javascript": {Lexer: lexers["javascript"], Keywords: jsKeywords, Continued: true},
+	"typescript": {
+		Lexer:     lexers["typescript"],
+		Keywords:  union(jsKeywords, words("abstract", "any", "as", "declare", "enum", "implements", "interface", "keyof", "namespace", "private", "protected", "public", "readonly", "type")),
+		Continued: true,
+	},
+	"json": {Lexer: lexers["json"], Keywords: words("true", "false", "null")},
+	"css":  {Lexer: lexers["css"]},
+	"scss": {Lexer: lexers["scss"]},
+	"html": {Lexer: lexers["html"]},
+	"yaml": {Lexer: lexers["yaml"], Keywords: words("true", "false", "null")},
+}
+
+var jsKeywords = words(
+	"async", "await", "break", "case", "catch", "class", "const", "continue",
+	"debugger", "default", "delete", "do", "else", "export", "extends", "finally",
+	"for", "from", "function", "if", "import", "in", "instanceof", "let", "new",
+	"of", "return", "static", "super", "switch", "this", "throw", "try", "typeof",
+	"var", "void", "while", "yield", "true", "false", "null", "undefined",
+)
+
+// GrammarOf returns the grammar of the syntax type typ, the types without
+// one only have words, brackets and punctuation.
+func GrammarOf(typ string) Grammar {
+	return grammars[typ]
+}
+
+// plain reports whether the grammar tells nothing but words and punctuation
+// apart, its tokens aren't highlighted.
+func (g Grammar) plain() bool {
+	return len(g.Keywords) == 0 && len(g.LineComments) == 0 && len(g.BlockComments) == 0 && len(g.Quotes) == 0
+}
+
+func words(s ...string) map[string]bool {
+	m := make(map[string]bool, len(s))
+	for _, w := range s {
+		m[w] = true
+	}
+	return m
+}
+
+func union(a, b map[string]bool) map[string]bool {
+	m := make(map[string]bool, len(a)+len(b))
+	for w := range a {
+		m[w] = true
+	}
+	for w := range b {
+		m[w] = true
+	}
+	return m
+}
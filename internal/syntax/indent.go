@@ -0,0 +1,49 @@
+package syntax
+
+import "strings"
+
+// Indent how the rows of a language are indented.
+type Indent struct {
+	// Tabs whether a level of indentation is a tab, otherwise Size spaces.
+	Tabs bool
+	// Size the number of columns of a level.
+	Size int
+	// TabWidth the number of columns a tab extends to, the tab stops.
+	TabWidth int
+}
+
+var (
+	// DefaultIndent the indentation of the languages without settings.
+	DefaultIndent = Indent{Tabs: true, Size: 4, TabWidth: 4}
+
+	twoSpaces = Indent{Size: 2, TabWidth: 4}
+
+	// indents the indentation of a syntax type.
+	indents = map[string]Indent{
+		"go":         {Tabs: true, Size: 4, TabWidth: 4},
+		"md":         twoSpaces,
+		"javascript": twoSpaces,
+		"typescript": twoSpaces,
+		"json":       twoSpaces,
+		"css":        twoSpaces,
+		"scss":       twoSpaces,
+		"html":       twoSpaces,
+		"yaml":       twoSpaces,
+	}
+)
+
+// IndentOf returns the indentation of the syntax type typ.
+func IndentOf(typ string) Indent {
+	if indent, ok := indents[typ]; ok {
+		return indent
+	}
+	return DefaultIndent
+}
+
+// Unit returns the text of a level of indentation.
+func (i Indent) Unit() string {
+	if i.Tabs {
+		return "\t"
+	}
+	return strings.Repeat(" ", i.Size)
+}
@@ -0,0 +1,188 @@
+package syntax
+
+import "strings"
+
+// Class the kind of text a rune belongs to.
+type Class uint8
+
+const (
+	Code Class = iota
+	String
+	Comment
+)
+
+// Lexer tells the strings and the comments of a language apart from the
+// code, e.g. to ignore the brackets inside of them.
+type Lexer struct {
+	LineComments  []string
+	BlockComments [][2]string
+	// Quotes start and end the strings, a backslash escapes the next rune.
+	Quotes []rune
+	// RawQuotes start and end the strings without escapes, which may span
+	// rows.
+	RawQuotes []rune
+}
+
+var (
+	cLike = Lexer{
+		LineComments:  []string{"//"},
+		BlockComments: [][2]string{{"/*", "*/"}},
+		Quotes:        []rune{'"', '\''},
+	}
+
+	// lexers the lexer of a syntax type.
+	lexers = map[string]Lexer{
+		"go":         {LineComments: cLike.LineComments, BlockComments: cLike.BlockComments, Quotes: cLike.Quotes, RawQuotes: []rune{'`'}},
+		"javascript": {LineComments: cLike.LineComments, BlockComments: cLike.BlockComments, Quotes: []rune{'"', '\'', '`'}},
+		"typescript": {LineComments: cLike.LineComments, BlockComments: cLike.BlockComments, Quotes: []rune{'"', '\'', '`'}},
+		"json":       {Quotes: []rune{'"'}},
+		"css":        {BlockComments: cLike.BlockComments, Quotes: cLike.Quotes},
+		"scss":       cLike,
+		"html":       {BlockComments: [][2]string{{"<!--", "-->"}}, Quotes: cLike.Quotes},
+		"yaml":       {LineComments: []string{"#"}},
+	}
+)
+
+// LexerOf returns the lexer of the syntax type typ, the text of the types
+// without one is all code.
+func LexerOf(typ string) Lexer {
+	return lexers[typ]
+}
+
+// IsQuote reports whether r starts and ends the strings of the language.
+func (l Lexer) IsQuote(r rune) bool {
+	return containsRune(l.Quotes, r) || containsRune(l.RawQuotes, r)
+}
+
+// Classify returns the class of every rune of rows.
+func Classify[R ~[]rune](l Lexer, rows []R) [][]Class {
+	classes := make([][]Class, len(rows))
+
+	var (
+		// the state at the end of a row, only block comments and raw strings
+		// span rows.
+		blockEnd string
+		rawQuote rune
+	)
+	for i, row := range rows {
+		class := make([]Class, len(row))
+		classes[i] = class
+
+		for col := 0; col < len(row); {
+			switch {
+			case blockEnd != "":
+				end := indexAt(row, col, blockEnd)
+				if end < 0 {
+					fill(class, col, len(row), Comment)
+					col = len(row)
+					continue
+				}
+				end += len([]rune(blockEnd))
+				fill(class, col, end, Comment)
+				col, blockEnd = end, ""
+			case rawQuote != 0:
+				end := indexAt(row, col, string(rawQuote))
+				if end < 0 {
+					fill(class, col, len(row), String)
+					col = len(row)
+					continue
+				}
+				fill(class, col, end+1, String)
+				col, rawQuote = end+1, 0
+			case l.lineComment(row, col):
+				fill(class, col, len(row), Comment)
+				col = len(row)
+			case l.blockComment(row, col) != "":
+				start := l.blockComment(row, col)
+				blockEnd = l.blockCommentEnd(start)
+				n := len([]rune(start))
+				fill(class, col, col+n, Comment)
+				col += n
+			case containsRune(l.RawQuotes, row[col]):
+				rawQuote = row[col]
+				class[col] = String
+				col++
+			case containsRune(l.Quotes, row[col]):
+				// a quoted string ends at the end of the row at the latest.
+				end := col + 1
+				for end < len(row) && row[end] != row[col] {
+					if row[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				end = min(end+1, len(row))
+				fill(class, col, end, String)
+				col = end
+			default:
+				col++
+			}
+		}
+	}
+	return classes
+}
+
+func (l Lexer) lineComment(row []rune, col int) bool {
+	for _, prefix := range l.LineComments {
+		if hasPrefixAt(row, col, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockComment returns the start of the block comment starting at col.
+func (l Lexer) blockComment(row []rune, col int) string {
+	for _, block := range l.BlockComments {
+		if hasPrefixAt(row, col, block[0]) {
+			return block[0]
+		}
+	}
+	return ""
+}
+
+func (l Lexer) blockCommentEnd(start string) string {
+	for _, block := range l.BlockComments {
+		if block[0] == start {
+			return block[1]
+		}
+	}
+	return ""
+}
+
+func hasPrefixAt(row []rune, col int, prefix string) bool {
+	return strings.HasPrefix(string(row[col:min(len(row), col+len(prefix))]), prefix)
+}
+
+// indexAt returns the column of the first s at or after col, -1 if there is
+// none.
+func indexAt(row []rune, col int, s string) int {
+	for i := col; i < len(row); i++ {
+		if hasPrefixAt(row, i, s) {
+			return i
+		}
+	}
+	return -1
+}
+
+func fill(class []Class, from, to int, c Class) {
+	for i := from; i < to && i < len(class); i++ {
+		class[i] = c
+	}
+}
+
+func containsRune(runes []rune, r rune) bool {
+	for _, c := range runes {
+		if c == r {
+			return true
+		}
+	}
+	return false
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,81 @@
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+// classes renders the classes of the runes, c for code, s for strings and #
+// for comments.
+func classes(l Lexer, text string) string {
+	var rows [][]rune
+	for _, line := range strings.Split(text, "\n") {
+		rows = append(rows, []rune(line))
+	}
+
+	var lines []string
+	for _, row := range Classify(l, rows) {
+		var sb strings.Builder
+		for _, c := range row {
+			sb.WriteByte("cs#"[c])
+		}
+		lines = append(lines, sb.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		typ  string
+		text string
+		want string
+	}{
+		{"go", `f("a)\"", ')') // x(`, "ccssssssccssscc#####"},
+		{"go", "a /* b\nc */ d", "cc####\n####cc"},
+		{"go", "x := `a\n(b` + y", "cccccss\nssscccc"},
+		{"javascript", "f(`${a}`, 'b')", "ccssssssccsssc"},
+		{"yaml", "a: b # c", "ccccc###"},
+		{"unknown", `it's "x"`, "cccccccc"},
+	}
+	for _, tt := range tests {
+		if got := classes(LexerOf(tt.typ), tt.text); got != tt.want {
+			t.Errorf("%s %q:\n got %q\nwant %q", tt.typ, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rows := [][]rune{
+		[]rune(`func f() {`),
+		[]rune(`	g("}", '{') // }`),
+		[]rune(`	h([]int{1})`),
+		[]rune(`}`),
+	}
+	classes := Classify(LexerOf("go"), rows)
+
+	tests := []struct {
+		row, col       int
+		wantRow, wantX int
+		ok             bool
+	}{
+		{0, 9, 3, 0, true},
+		{3, 0, 0, 9, true},
+		{0, 6, 0, 7, true},
+		{2, 2, 2, 11, true},
+		{2, 3, 2, 4, true},
+		{2, 8, 2, 10, true},
+		{1, 4, 0, 0, false},
+		{0, 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		row, col, ok := Match(rows, classes, tt.row, tt.col)
+		if ok != tt.ok || row != tt.wantRow || col != tt.wantX {
+			t.Errorf("Match(%d, %d) = %d, %d, %v", tt.row, tt.col, row, col, ok)
+		}
+	}
+
+	unbalanced := [][]rune{[]rune("(()")}
+	if _, _, ok := Match(unbalanced, Classify(Lexer{}, unbalanced), 0, 0); ok {
+		t.Error("expected no match")
+	}
+}
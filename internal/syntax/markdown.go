@@ -0,0 +1,68 @@
+package syntax
+
+import "strings"
+
+// Heading an ATX heading of a Markdown document, e.g. `## Usage`.
+type Heading struct {
+	Row   int
+	Level int
+	// Text the text of the heading without the markers.
+	Text string
+}
+
+// Headings returns the headings of the Markdown rows, the ones in fenced
+// code blocks don't count.
+func Headings[R ~[]rune](rows []R) []Heading {
+	var (
+		headings []Heading
+		fence    string
+	)
+	for i, row := range rows {
+		line := strings.TrimSpace(string(row))
+		if marker := fenceMarker(line); marker != "" {
+			switch {
+			case fence == "":
+				fence = marker
+			case strings.HasPrefix(line, fence):
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		if level := headingLevel(string(row)); level > 0 {
+			text := strings.TrimSpace(string(row)[level:])
+			// the closing sequence is optional.
+			if trimmed := strings.TrimRight(text, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+				text = strings.TrimSpace(trimmed)
+			}
+			headings = append(headings, Heading{Row: i, Level: level, Text: text})
+		}
+	}
+	return headings
+}
+
+// headingLevel returns the level of the ATX heading line, 0 if it is none.
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ' && line[level] != '\t') {
+		return 0
+	}
+	return level
+}
+
+// fenceMarker returns the fence starting line, e.g. ```, or "" if it
+// doesn't start a fenced code block.
+func fenceMarker(line string) string {
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, marker) {
+			return marker
+		}
+	}
+	return ""
+}
@@ -0,0 +1,22 @@
+package syntax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHeadings(t *testing.T) {
+	rows := [][]rune{
+		[]rune("# Title #"),
+		[]rune("```"),
+		[]rune("# not a heading"),
+		[]rune("```"),
+		[]rune("## C# ##"),
+		[]rune("###"),
+		[]rune("#hashtag"),
+	}
+	want := []Heading{{0, 1, "Title"}, {4, 2, "C#"}, {5, 3, ""}}
+	if got := Headings(rows); !reflect.DeepEqual(got, want) {
+		t.Errorf("Headings() = %v, want %v", got, want)
+	}
+}
@@ -26,9 +26,36 @@ var (
 		".md": func(filename string) Syntax {
 			return MarkerDown(filename)
 		},
+		".go": func(filename string) Syntax {
+			return Golang(filename)
+		},
+	}
+
+	// plain the languages that are only told apart by their type, e.g. to pick
+	// a formatter.
+	plain = map[string]string{
+		".js":   "javascript",
+		".jsx":  "javascript",
+		".ts":   "typescript",
+		".tsx":  "typescript",
+		".json": "json",
+		".css":  "css",
+		".scss": "scss",
+		".html": "html",
+		".yaml": "yaml",
+		".yml":  "yaml",
 	}
 )
 
+func init() {
+	for ext, typ := range plain {
+		typ := typ
+		m[ext] = func(filename string) Syntax {
+			return Plain{Name: filename, Lang: typ}
+		}
+	}
+}
+
 func From(filename string) Syntax {
 	ext := strings.ToLower(path.Ext(filename))
 
@@ -44,3 +71,13 @@ type Default string
 func (d Default) FileName() string            { return string(d) }
 func (d Default) Type() string                { return "unknown" }
 func (d Default) Highlight(str string) string { return str }
+
+// Plain a file of a language without highlighting.
+type Plain struct {
+	Name string
+	Lang string
+}
+
+func (p Plain) FileName() string            { return p.Name }
+func (p Plain) Type() string                { return p.Lang }
+func (p Plain) Highlight(str string) string { return str }
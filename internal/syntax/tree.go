@@ -0,0 +1,524 @@
+package syntax
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Kind the kind of a node of the parse tree.
+type Kind uint8
+
+const (
+	// RootNode spans the whole text.
+	RootNode Kind = iota
+	// BlockNode is a pair of brackets and the nodes between them.
+	BlockNode
+	// StatementNode groups the nodes up to the end of a row, a semicolon or
+	// a comma in parentheses, e.g. an argument or an assignment.
+	StatementNode
+
+	IdentNode
+	KeywordNode
+	NumberNode
+	StringNode
+	CommentNode
+	PunctNode
+)
+
+// Point a position in the text, Col counts runes.
+type Point struct {
+	Row int
+	Col int
+}
+
+// Before reports whether p is before o.
+func (p Point) Before(o Point) bool {
+	return p.Row < o.Row || p.Row == o.Row && p.Col < o.Col
+}
+
+// Node is a node of the parse tree, it spans the text from Start up to, but
+// not including, End. The tokens are the leaves of the tree.
+type Node struct {
+	Kind     Kind
+	Start    Point
+	End      Point
+	Parent   *Node
+	Children []*Node
+
+	// closed whether a block ends with its closing bracket.
+	closed bool
+}
+
+// IsToken reports whether the node is a leaf of the tree.
+func (n *Node) IsToken() bool {
+	return n.Kind >= IdentNode
+}
+
+// Inner returns the range of the nodes between the brackets of a closed
+// block, ok is false for the other nodes.
+func (n *Node) Inner() (Point, Point, bool) {
+	if n.Kind != BlockNode || !n.closed {
+		return Point{}, Point{}, false
+	}
+	if len(n.Children) == 2 {
+		return n.Children[0].End, n.Children[0].End, true
+	}
+	return n.Children[1].Start, n.Children[len(n.Children)-2].End, true
+}
+
+// child returns the child spanning from..to. The child starting at an empty
+// range wins over the one ending at it.
+func (n *Node) child(from, to Point) *Node {
+	i := sort.Search(len(n.Children), func(i int) bool {
+		return !n.Children[i].End.Before(from)
+	})
+
+	var found *Node
+	for ; i < len(n.Children) && !from.Before(n.Children[i].Start); i++ {
+		c := n.Children[i]
+		if c.End.Before(to) {
+			continue
+		}
+		found = c
+		if from != to || from.Before(c.End) {
+			break
+		}
+	}
+	return found
+}
+
+// shift moves the node and its children by delta rows.
+func (n *Node) shift(delta int) {
+	n.Start.Row += delta
+	n.End.Row += delta
+	for _, c := range n.Children {
+		c.shift(delta)
+	}
+}
+
+// Tree is the parse tree of a text, it is parsed again incrementally after
+// edits: the rows are parsed from the last row before the edit starting
+// with a clean state, i.e. outside of any bracket, string or statement,
+// until a row after the edit that started with a clean state before, the
+// nodes after it are kept.
+type Tree struct {
+	grammar Grammar
+	root    *Node
+	// rows the number of rows of the parsed text.
+	rows int
+	// syncs the rows starting with a clean state, in order.
+	syncs []int
+
+	// edited whether the text was edited since it was parsed, the rows in
+	// [start, end] of the parsed text were replaced by delta more rows.
+	edited bool
+	start  int
+	end    int
+	delta  int
+
+	// reparsed the number of rows parsed by the last Parse.
+	reparsed int
+}
+
+func NewTree(g Grammar) *Tree {
+	return &Tree{grammar: g}
+}
+
+// Highlighted reports whether the tokens of the grammar are worth
+// highlighting, they are only words and punctuation otherwise.
+func (t *Tree) Highlighted() bool {
+	return !t.grammar.plain()
+}
+
+// Edit records that the rows from to to of the text were replaced by the
+// rows from to end, the rows are those of the text before the edit.
+func (t *Tree) Edit(from, to, end int) {
+	if t.root == nil {
+		return
+	}
+	if !t.edited {
+		t.edited, t.start, t.end, t.delta = true, from, to, end-to
+		return
+	}
+
+	// the rows after the edited ones are moved by delta since the parse.
+	if to > t.end+t.delta {
+		t.end = to - t.delta
+	}
+	t.start = min(t.start, from)
+	t.delta += end - to
+}
+
+// Parse parses the n rows of the text returned by row, only the edited
+// rows are parsed again. It returns the root of the tree.
+func (t *Tree) Parse(n int, row func(int) []rune) *Node {
+	switch {
+	case t.root == nil || len(t.syncs) == 0 || t.rows+t.delta != n:
+		t.parse(n, row, false)
+	case t.edited:
+		t.parse(n, row, true)
+	}
+	t.edited, t.start, t.end, t.delta = false, 0, 0, 0
+	t.rows = n
+	return t.root
+}
+
+// Root returns the root of the last parsed tree.
+func (t *Tree) Root() *Node {
+	return t.root
+}
+
+// parse parses the rows, from the edited ones if incremental is true.
+func (t *Tree) parse(n int, row func(int) []rune, incremental bool) {
+	var (
+		old, oldSyncs = []*Node(nil), t.syncs
+		restart       int
+	)
+	if incremental {
+		old = t.root.Children
+		restart = oldSyncs[max(0, sort.SearchInts(oldSyncs, t.start+1)-1)]
+	} else {
+		t.root = &Node{Kind: RootNode}
+	}
+
+	kept := sort.Search(len(old), func(i int) bool { return old[i].Start.Row >= restart })
+	t.root.Children = append([]*Node(nil), old[:kept]...)
+	t.syncs = append([]int(nil), oldSyncs[:sort.SearchInts(oldSyncs, restart)]...)
+
+	p := &parser{grammar: t.grammar, stack: []*frame{{node: t.root}}}
+	t.reparsed = 0
+	for r := restart; r < n; r++ {
+		if p.clean() {
+			if incremental && r > t.end+t.delta && t.reuse(old, oldSyncs, r) {
+				break
+			}
+			t.syncs = append(t.syncs, r)
+		}
+		p.row(r, row(r))
+		t.reparsed++
+	}
+	p.finish()
+
+	t.root.Start, t.root.End = Point{}, Point{}
+	if n > 0 {
+		t.root.End = Point{Row: n - 1, Col: len(row(n - 1))}
+	}
+}
+
+// reuse appends the nodes of the old tree from row r on, moved by the rows
+// added by the edit, when the row r started with a clean state before the
+// edit too.
+func (t *Tree) reuse(old []*Node, oldSyncs []int, r int) bool {
+	r -= t.delta
+	i := sort.SearchInts(oldSyncs, r)
+	if i >= len(oldSyncs) || oldSyncs[i] != r {
+		return false
+	}
+
+	for _, sync := range oldSyncs[i:] {
+		t.syncs = append(t.syncs, sync+t.delta)
+	}
+	for _, n := range old[sort.Search(len(old), func(i int) bool { return old[i].Start.Row >= r }):] {
+		if t.delta != 0 {
+			n.shift(t.delta)
+		}
+		t.root.Children = append(t.root.Children, n)
+	}
+	return true
+}
+
+// Cover returns the innermost node spanning from..to. An empty range is
+// covered by the token at it, or the one ending at it.
+func (t *Tree) Cover(from, to Point) *Node {
+	n := t.root
+	for c := n.child(from, to); c != nil; c = c.child(from, to) {
+		n = c
+	}
+	return n
+}
+
+// Expand returns the smallest range larger than from..to that spans a node,
+// or the inside of a block, around it. ok is false when from..to spans the
+// whole text already.
+func (t *Tree) Expand(from, to Point) (Point, Point, bool) {
+	grows := func(start, end Point) bool {
+		return !from.Before(start) && !end.Before(to) && (start != from || end != to)
+	}
+	for n := t.Cover(from, to); n != nil; n = n.Parent {
+		if start, end, ok := n.Inner(); ok && grows(start, end) {
+			return start, end, true
+		}
+		if grows(n.Start, n.End) {
+			return n.Start, n.End, true
+		}
+	}
+	return from, to, false
+}
+
+// Tokens returns the tokens spanning the rows in [start, end) in order.
+func (t *Tree) Tokens(start, end int) []*Node {
+	var (
+		tokens []*Node
+		walk   func(n *Node)
+	)
+	walk = func(n *Node) {
+		i := sort.Search(len(n.Children), func(i int) bool { return n.Children[i].End.Row >= start })
+		for _, c := range n.Children[i:] {
+			if c.Start.Row >= end {
+				break
+			}
+			if c.IsToken() {
+				tokens = append(tokens, c)
+			} else {
+				walk(c)
+			}
+		}
+	}
+	if t.root != nil {
+		walk(t.root)
+	}
+	return tokens
+}
+
+// frame a block being parsed and the nodes of its current statement.
+type frame struct {
+	node  *Node
+	close rune
+	stmt  []*Node
+}
+
+type parser struct {
+	grammar Grammar
+	stack   []*frame
+	// open the token spanning rows being parsed, e.g. a block comment, and
+	// the text ending it.
+	open    *Node
+	openEnd string
+	// continued whether the last token continues the statement on the next
+	// row.
+	continued bool
+}
+
+// clean reports whether the parser is outside of any block, token or
+// statement.
+func (p *parser) clean() bool {
+	return len(p.stack) == 1 && p.open == nil && len(p.stack[0].stmt) == 0
+}
+
+func (p *parser) top() *frame {
+	return p.stack[len(p.stack)-1]
+}
+
+// row parses the row r, a newline ends the current statement unless it is
+// continued.
+func (p *parser) row(r int, line []rune) {
+	col := 0
+	if p.open != nil {
+		end := indexAt(line, 0, p.openEnd)
+		if end < 0 {
+			p.open.End = Point{Row: r, Col: len(line)}
+			return
+		}
+		col = end + len([]rune(p.openEnd))
+		p.open.End = Point{Row: r, Col: col}
+		p.open = nil
+	}
+
+	g := p.grammar
+	for col < len(line) {
+		c, start := line[col], Point{Row: r, Col: col}
+		token := func(kind Kind, end int) *Node {
+			n := &Node{Kind: kind, Start: start, End: Point{Row: r, Col: end}}
+			col = end
+			return n
+		}
+
+		switch {
+		case unicode.IsSpace(c):
+			col++
+		case g.lineComment(line, col):
+			p.add(token(CommentNode, len(line)))
+		case g.blockComment(line, col) != "":
+			s := g.blockComment(line, col)
+			col = p.spanRows(CommentNode, line, start, len([]rune(s)), g.blockCommentEnd(s))
+		case containsRune(g.RawQuotes, c):
+			col = p.spanRows(StringNode, line, start, 1, string(c))
+		case containsRune(g.Quotes, c):
+			// a quoted string ends at the end of the row at the latest.
+			end := col + 1
+			for end < len(line) && line[end] != c {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			p.add(token(StringNode, min(end+1, len(line))))
+		case Brackets[c] != 0:
+			p.openBlock(token(PunctNode, col+1), Brackets[c])
+		case isClosing(c):
+			p.closeBlock(token(PunctNode, col+1), c)
+		case c == ';', c == ',' && p.top().close != '}' && len(p.stack) > 1:
+			// the commas in braces belong to the statements, e.g. a, b := f().
+			p.separate(token(PunctNode, col+1))
+		case unicode.IsDigit(c):
+			end := col + 1
+			for end < len(line) && (isWord(line[end]) || line[end] == '.') {
+				end++
+			}
+			p.add(token(NumberNode, end))
+		case isWord(c):
+			end := col + 1
+			for end < len(line) && isWord(line[end]) {
+				end++
+			}
+			kind := IdentNode
+			if g.Keywords[string(line[col:end])] {
+				kind = KeywordNode
+			}
+			p.add(token(kind, end))
+		default:
+			end := col + 1
+			for end < len(line) && p.isOperator(line, end) {
+				end++
+			}
+			op := string(line[col:end])
+			p.add(token(PunctNode, end))
+			p.continued = g.Continued && op != "++" && op != "--" && op != ":"
+		}
+	}
+
+	if p.open == nil && !p.continued {
+		p.flush(p.top())
+	}
+}
+
+// isOperator reports whether the rune at col continues an operator.
+func (p *parser) isOperator(line []rune, col int) bool {
+	c, g := line[col], p.grammar
+	_, bracket := Brackets[c]
+	_, closing := Opening(c)
+	return !unicode.IsSpace(c) && !isWord(c) && !bracket && !closing && c != ',' && c != ';' &&
+		!g.IsQuote(c) && !g.lineComment(line, col) && g.blockComment(line, col) == ""
+}
+
+// spanRows adds the token starting at start which may span rows, e.g. a
+// block comment, n is the length of the text starting it. It returns the
+// column after the token.
+func (p *parser) spanRows(kind Kind, line []rune, start Point, n int, end string) int {
+	token := &Node{Kind: kind, Start: start}
+	p.add(token)
+
+	col := indexAt(line, start.Col+n, end)
+	if col < 0 {
+		token.End = Point{Row: start.Row, Col: len(line)}
+		p.open, p.openEnd = token, end
+		return len(line)
+	}
+	token.End = Point{Row: start.Row, Col: col + len([]rune(end))}
+	return token.End.Col
+}
+
+// add adds n to the current statement.
+func (p *parser) add(n *Node) {
+	f := p.top()
+	f.stmt = append(f.stmt, n)
+	if n.Kind != CommentNode {
+		p.continued = false
+	}
+}
+
+// flush ends the statement of f, a statement of a single node is the node
+// itself.
+func (p *parser) flush(f *frame) {
+	switch len(f.stmt) {
+	case 0:
+		return
+	case 1:
+		attach(f.node, f.stmt[0])
+	default:
+		s := &Node{Kind: StatementNode, Start: f.stmt[0].Start, End: f.stmt[len(f.stmt)-1].End}
+		for _, n := range f.stmt {
+			attach(s, n)
+		}
+		attach(f.node, s)
+	}
+	f.stmt = nil
+}
+
+// separate ends the current statement with the separator token.
+func (p *parser) separate(token *Node) {
+	f := p.top()
+	p.flush(f)
+	attach(f.node, token)
+	p.continued = false
+}
+
+// openBlock starts a block with the opening bracket token.
+func (p *parser) openBlock(token *Node, close rune) {
+	block := &Node{Kind: BlockNode, Start: token.Start, End: token.End}
+	p.add(block)
+	attach(block, token)
+	p.stack = append(p.stack, &frame{node: block, close: close})
+}
+
+// closeBlock ends the innermost block closed by c with the closing bracket
+// token, the unclosed blocks inside of it end as well. The token is
+// punctuation when no block is closed by c.
+func (p *parser) closeBlock(token *Node, c rune) {
+	i := len(p.stack) - 1
+	for i > 0 && p.stack[i].close != c {
+		i--
+	}
+	if i == 0 {
+		p.add(token)
+		return
+	}
+
+	for len(p.stack) > i+1 {
+		p.pop()
+	}
+	f := p.top()
+	p.flush(f)
+	attach(f.node, token)
+	f.node.End, f.node.closed = token.End, true
+	p.stack = p.stack[:i]
+	p.continued = false
+}
+
+// pop ends the innermost block without its closing bracket.
+func (p *parser) pop() {
+	f := p.top()
+	p.flush(f)
+	f.node.End = f.node.Children[len(f.node.Children)-1].End
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
+// finish ends the unclosed blocks and the last statement.
+func (p *parser) finish() {
+	for len(p.stack) > 1 {
+		p.pop()
+	}
+	p.flush(p.top())
+}
+
+// attach appends the child n to parent.
+func attach(parent, n *Node) {
+	n.Parent = parent
+	parent.Children = append(parent.Children, n)
+}
+
+func isClosing(r rune) bool {
+	_, ok := Opening(r)
+	return ok
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,170 @@
+package syntax
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const goSrc = `package p
+
+// Sum adds the numbers.
+func Sum(xs ...int) int {
+	total := 0
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+
+var s = ` + "`raw\nstring`" + `
+
+func f() { g(1, "a)") }
+`
+
+func parseText(t *Tree, text string) *Node {
+	rows := textRows(text)
+	return t.Parse(len(rows), func(i int) []rune { return rows[i] })
+}
+
+func textRows(text string) [][]rune {
+	var rows [][]rune
+	for _, line := range strings.Split(text, "\n") {
+		rows = append(rows, []rune(line))
+	}
+	return rows
+}
+
+// dump renders the tree in a compact form, e.g. B(P S(I P) P).
+func dump(n *Node) string {
+	names := map[Kind]string{
+		RootNode: "R", BlockNode: "B", StatementNode: "S", IdentNode: "I", KeywordNode: "K",
+		NumberNode: "N", StringNode: "Q", CommentNode: "C", PunctNode: "P",
+	}
+	s := fmt.Sprintf("%s%d.%d-%d.%d", names[n.Kind], n.Start.Row, n.Start.Col, n.End.Row, n.End.Col)
+	if len(n.Children) == 0 {
+		return s
+	}
+
+	var children []string
+	for _, c := range n.Children {
+		if c.Parent != n {
+			children = append(children, "orphan")
+		}
+		children = append(children, dump(c))
+	}
+	return s + "(" + strings.Join(children, " ") + ")"
+}
+
+func TestTree_Tokens(t *testing.T) {
+	tree := NewTree(GrammarOf("go"))
+	parseText(tree, goSrc)
+
+	var got []string
+	for _, token := range tree.Tokens(11, 13) {
+		got = append(got, fmt.Sprintf("%d:%d-%d:%d", token.Kind, token.Start.Row, token.End.Row, token.End.Col))
+	}
+	want := []string{
+		fmt.Sprintf("%d:11-11:3", KeywordNode),
+		fmt.Sprintf("%d:11-11:5", IdentNode),
+		fmt.Sprintf("%d:11-11:7", PunctNode),
+		fmt.Sprintf("%d:11-12:7", StringNode),
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Tokens() = %v, want %v", got, want)
+	}
+	if c := tree.Cover(Point{Row: 2, Col: 5}, Point{Row: 2, Col: 5}); c.Kind != CommentNode {
+		t.Errorf("Cover() = %v, want the comment", c.Kind)
+	}
+}
+
+func TestTree_Expand(t *testing.T) {
+	tree := NewTree(GrammarOf("go"))
+	parseText(tree, goSrc)
+
+	// from the x of total += x.
+	from, to := Point{Row: 6, Col: 11}, Point{Row: 6, Col: 11}
+	want := []string{
+		"6.11-6.12", // x
+		"6.2-6.12",  // total += x
+		"5.22-7.2",  // the braces, their inside is the statement
+		"5.1-7.2",   // the for statement
+		"4.1-8.13",  // the inside of the function
+		"3.24-9.1",  // the braces of the function
+		"3.0-9.1",   // the function
+		"0.0-15.0",
+	}
+	for _, w := range want {
+		var ok bool
+		from, to, ok = tree.Expand(from, to)
+		if got := fmt.Sprintf("%d.%d-%d.%d", from.Row, from.Col, to.Row, to.Col); !ok || got != w {
+			t.Fatalf("Expand() = %s %v, want %s", got, ok, w)
+		}
+	}
+	if _, _, ok := tree.Expand(from, to); ok {
+		t.Errorf("Expand() of the whole text is ok")
+	}
+
+	// the string hides the bracket.
+	from, to, _ = tree.Expand(Point{Row: 14, Col: 17}, Point{Row: 14, Col: 17})
+	if from != (Point{Row: 14, Col: 16}) || to != (Point{Row: 14, Col: 20}) {
+		t.Errorf("Expand() in a string = %v %v", from, to)
+	}
+}
+
+func TestTree_Parse_Incremental(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	snippets := []string{"{", "}", "(", ")", "\n", "`", "/*", "*/", "\"", "x", " + ", "+\n", ";", "// c\n", "func a() {\n}\n"}
+
+	tree := NewTree(GrammarOf("go"))
+	rows := textRows(goSrc)
+	parse := func() { tree.Parse(len(rows), func(i int) []rune { return rows[i] }) }
+	parse()
+
+	for i := 0; i < 500; i++ {
+		// replace a random range of the text with a snippet.
+		from := Point{Row: r.Intn(len(rows))}
+		from.Col = r.Intn(len(rows[from.Row]) + 1)
+		to := Point{Row: min(len(rows)-1, from.Row+r.Intn(2))}
+		to.Col = r.Intn(len(rows[to.Row]) + 1)
+		if to.Before(from) {
+			to = from
+		}
+
+		text := string(rows[from.Row][:from.Col]) + snippets[r.Intn(len(snippets))] + string(rows[to.Row][to.Col:])
+		inserted := textRows(text)
+		rows = append(rows[:from.Row], append(inserted, rows[to.Row+1:]...)...)
+		tree.Edit(from.Row, to.Row, from.Row+len(inserted)-1)
+
+		if r.Intn(3) > 0 {
+			parse()
+			full := NewTree(GrammarOf("go"))
+			want := full.Parse(len(rows), func(i int) []rune { return rows[i] })
+			if got := dump(tree.Root()); got != dump(want) {
+				t.Fatalf("edit %d: Parse() =\n%s\nwant\n%s", i, got, dump(want))
+			}
+		}
+	}
+}
+
+func TestTree_Parse_Reparsed(t *testing.T) {
+	text := strings.Repeat("func f() {\n\tx := 1\n}\n\n", 100)
+	tree := NewTree(GrammarOf("go"))
+	parseText(tree, text)
+	if tree.reparsed != 401 {
+		t.Fatalf("reparsed = %d, want all the rows", tree.reparsed)
+	}
+
+	rows := textRows(text)
+	rows[201] = []rune("\tx := (1 +")
+	rows = append(rows[:202], append([][]rune{[]rune("\t\t2)")}, rows[202:]...)...)
+	tree.Edit(201, 201, 202)
+	tree.Parse(len(rows), func(i int) []rune { return rows[i] })
+	if tree.reparsed != 4 {
+		t.Errorf("reparsed = %d, want the rows of the edited function", tree.reparsed)
+	}
+	if n := tree.Cover(Point{Row: 205, Col: 6}, Point{Row: 205, Col: 6}); n.Start.Row != 205 {
+		t.Errorf("Cover() after the edit = %v, want the moved function", n.Start)
+	}
+}
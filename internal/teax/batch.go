@@ -1,6 +1,10 @@
 package teax
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	"reflect"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
 
 type batch struct {
 	cmds []tea.Cmd
@@ -23,3 +27,20 @@ func (b *batch) Check(err error) *batch {
 	b.Append(Check(err))
 	return b
 }
+
+// Cmds returns the commands of a message of tea.Batch, the program runs them
+// instead of passing the message to the model.
+func Cmds(msg tea.Msg) ([]tea.Cmd, bool) {
+	v := reflect.ValueOf(msg)
+	if !v.IsValid() || v.Kind() != reflect.Slice {
+		return nil, false
+	}
+
+	cmds := make([]tea.Cmd, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if cmd, ok := v.Index(i).Interface().(tea.Cmd); ok {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds, true
+}
@@ -0,0 +1,835 @@
+// Package term emulates a terminal, the output of a program is parsed into
+// a grid of cells, see Screen, and the program runs under a pseudo-terminal,
+// see Terminal.
+package term
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	rw "github.com/mattn/go-runewidth"
+)
+
+const (
+	// maxScrollback the number of lines kept after they scrolled off the
+	// screen.
+	maxScrollback = 1000
+	// tabWidth the distance between the tab stops.
+	tabWidth = 8
+	// maxParams the number of parameters of a control sequence that are kept.
+	maxParams = 16
+)
+
+// Color is the color of a cell, DefaultColor, an index of the 256 colors
+// palette or an RGB color.
+type Color int32
+
+// DefaultColor is the color configured by the user.
+const DefaultColor Color = -1
+
+// rgbFlag marks the RGB colors.
+const rgbFlag = 1 << 24
+
+// RGB returns the color of the red, green and blue components.
+func RGB(r, g, b uint8) Color {
+	return Color(rgbFlag | int32(r)<<16 | int32(g)<<8 | int32(b))
+}
+
+// IsRGB reports whether c is an RGB color.
+func (c Color) IsRGB() bool {
+	return c >= rgbFlag
+}
+
+// String formats c like lipgloss colors, "" is the default color.
+func (c Color) String() string {
+	switch {
+	case c == DefaultColor:
+		return ""
+	case c.IsRGB():
+		return fmt.Sprintf("#%06x", int32(c)&0xffffff)
+	default:
+		return fmt.Sprintf("%d", c)
+	}
+}
+
+// Attr are the attributes of a cell.
+type Attr struct {
+	Fg, Bg    Color
+	Bold      bool
+	Faint     bool
+	Italic    bool
+	Underline bool
+	Reverse   bool
+}
+
+// defaultAttr the attributes after a reset.
+var defaultAttr = Attr{Fg: DefaultColor, Bg: DefaultColor}
+
+// Cell is a character of the screen.
+type Cell struct {
+	// Rune is 0 for an empty cell, and continuation for the right half of a
+	// wide character.
+	Rune rune
+	Attr Attr
+}
+
+// continuation is the Rune of the cell after a wide character.
+const continuation rune = -1
+
+// Continuation reports whether c is the right half of a wide character, it
+// isn't rendered.
+func (c Cell) Continuation() bool {
+	return c.Rune == continuation
+}
+
+type parserState int
+
+const (
+	ground parserState = iota
+	escape
+	// escapeIntermediate skips the character designating a charset, e.g.
+	// `ESC ( B`.
+	escapeIntermediate
+	csi
+	osc
+	// oscEscape is an ESC in an OSC, the start of the string terminator.
+	oscEscape
+)
+
+type cursor struct {
+	x, y int
+	attr Attr
+}
+
+// Screen is the grid of cells a program writes to with escape sequences,
+// it understands the common VT100 and xterm sequences.
+type Screen struct {
+	width, height int
+
+	lines [][]Cell
+	// scrollback the lines that scrolled off the top of the main screen.
+	scrollback [][]Cell
+	// main the lines of the main screen while the alternate one is shown.
+	main [][]Cell
+
+	cursor cursor
+	saved  cursor
+	// wrapNext whether the next character goes to the next line, the cursor
+	// stays on the last column after it is written.
+	wrapNext bool
+	// top and bottom the rows of the scrolling region, inclusive.
+	top, bottom int
+
+	autowrap      bool
+	cursorVisible bool
+	appCursorKeys bool
+
+	// Title is the window title set by the program.
+	Title string
+	// Reply answers the queries of the program, e.g. the cursor position,
+	// it may be nil.
+	Reply func(p []byte)
+
+	state        parserState
+	params       []int
+	private      rune
+	intermediate rune
+	oscData      strings.Builder
+	// partial the bytes of a rune split between two writes.
+	partial []byte
+}
+
+// NewScreen creates an empty screen of the size.
+func NewScreen(width, height int) *Screen {
+	s := &Screen{}
+	s.reset(max(width, 1), max(height, 1))
+	return s
+}
+
+func (s *Screen) reset(width, height int) {
+	s.width, s.height = width, height
+	s.cursor = cursor{attr: defaultAttr}
+	s.saved = s.cursor
+	s.lines = make([][]Cell, height)
+	for i := range s.lines {
+		s.lines[i] = s.blankLine()
+	}
+	s.main = nil
+	s.wrapNext = false
+	s.top, s.bottom = 0, height-1
+	s.autowrap, s.cursorVisible, s.appCursorKeys = true, true, false
+	s.state = ground
+}
+
+// Size returns the width and height of the screen.
+func (s *Screen) Size() (width, height int) {
+	return s.width, s.height
+}
+
+// Cell returns the cell of column x and row y.
+func (s *Screen) Cell(x, y int) Cell {
+	return s.lines[y][x]
+}
+
+// Cursor returns the position of the cursor and whether it is shown.
+func (s *Screen) Cursor() (x, y int, visible bool) {
+	return s.cursor.x, s.cursor.y, s.cursorVisible
+}
+
+// AppCursorKeys reports whether the program asked for the application mode
+// of the cursor keys, they are sent as `ESC O A` instead of `ESC [ A`.
+func (s *Screen) AppCursorKeys() bool {
+	return s.appCursorKeys
+}
+
+// AltScreen reports whether the alternate screen is shown, as full screen
+// programs do.
+func (s *Screen) AltScreen() bool {
+	return s.main != nil
+}
+
+// Text returns the scrollback and the screen as text, without the trailing
+// blanks of the lines and the empty lines at the end.
+func (s *Screen) Text() string {
+	var lines []string
+	for _, line := range s.scrollback {
+		lines = append(lines, lineText(line))
+	}
+	for _, line := range s.lines {
+		lines = append(lines, lineText(line))
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func lineText(line []Cell) string {
+	var sb strings.Builder
+	for _, c := range line {
+		switch c.Rune {
+		case continuation:
+		case 0:
+			sb.WriteByte(' ')
+		default:
+			sb.WriteRune(c.Rune)
+		}
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// Resize changes the size of the screen, the lines above the cursor are
+// moved to the scrollback if the screen gets shorter.
+func (s *Screen) Resize(width, height int) {
+	width, height = max(width, 1), max(height, 1)
+	if width == s.width && height == s.height {
+		return
+	}
+
+	resize := func(lines [][]Cell) [][]Cell {
+		for i, line := range lines {
+			if len(line) > width {
+				lines[i] = line[:width]
+				continue
+			}
+			for len(lines[i]) < width {
+				lines[i] = append(lines[i], s.blank())
+			}
+		}
+		return lines
+	}
+
+	s.width = width
+	s.lines = resize(s.lines)
+	if s.main != nil {
+		s.main = resize(s.main)
+	}
+
+	for len(s.lines) > height {
+		if s.cursor.y > 0 {
+			if s.main == nil {
+				s.pushScrollback(s.lines[0])
+			}
+			s.lines = s.lines[1:]
+			s.cursor.y--
+		} else {
+			s.lines = s.lines[:len(s.lines)-1]
+		}
+	}
+	for len(s.lines) < height {
+		s.lines = append(s.lines, s.blankLine())
+	}
+	if s.main != nil {
+		for len(s.main) > height {
+			s.main = s.main[1:]
+		}
+		for len(s.main) < height {
+			s.main = append(s.main, s.blankLine())
+		}
+	}
+
+	s.height = height
+	s.top, s.bottom = 0, height-1
+	s.cursor.x = min(s.cursor.x, width-1)
+	s.cursor.y = min(s.cursor.y, height-1)
+	s.wrapNext = false
+}
+
+// Write parses the output of a program.
+func (s *Screen) Write(p []byte) (int, error) {
+	data := p
+	if len(s.partial) > 0 {
+		data = append(s.partial, p...)
+		s.partial = nil
+	}
+
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 && !utf8.FullRune(data) {
+			s.partial = append([]byte(nil), data...)
+			break
+		}
+		data = data[size:]
+		s.handle(r)
+	}
+	return len(p), nil
+}
+
+func (s *Screen) handle(r rune) {
+	switch s.state {
+	case ground:
+		s.handleGround(r)
+	case escape:
+		s.handleEscape(r)
+	case escapeIntermediate:
+		s.state = ground
+	case csi:
+		s.handleCSI(r)
+	case osc:
+		switch r {
+		case 0x07:
+			s.dispatchOSC()
+		case 0x1b:
+			s.state = oscEscape
+		default:
+			s.oscData.WriteRune(r)
+		}
+	case oscEscape:
+		// ESC \ terminates the string, anything else aborts it.
+		if r == '\\' {
+			s.dispatchOSC()
+			return
+		}
+		s.state = escape
+		s.handleEscape(r)
+	}
+}
+
+func (s *Screen) handleGround(r rune) {
+	switch r {
+	case 0x1b:
+		s.state = escape
+	case '\r':
+		s.cursor.x, s.wrapNext = 0, false
+	case '\n', '\v', '\f':
+		s.lineFeed()
+	case '\b':
+		if s.cursor.x > 0 {
+			s.cursor.x--
+		}
+		s.wrapNext = false
+	case '\t':
+		s.cursor.x = min((s.cursor.x/tabWidth+1)*tabWidth, s.width-1)
+	default:
+		if r < 0x20 || r == 0x7f {
+			return
+		}
+		s.put(r)
+	}
+}
+
+// put writes r at the cursor and moves the cursor after it.
+func (s *Screen) put(r rune) {
+	width := rw.RuneWidth(r)
+	if width == 0 {
+		return
+	}
+
+	if s.wrapNext || (width == 2 && s.cursor.x == s.width-1) {
+		if s.autowrap {
+			s.cursor.x = 0
+			s.lineFeed()
+		}
+		s.wrapNext = false
+	}
+	if width > s.width {
+		return
+	}
+
+	line := s.lines[s.cursor.y]
+	s.clearWide(line, s.cursor.x)
+	line[s.cursor.x] = Cell{Rune: r, Attr: s.cursor.attr}
+	if width == 2 {
+		s.clearWide(line, s.cursor.x+1)
+		line[s.cursor.x+1] = Cell{Rune: continuation, Attr: s.cursor.attr}
+	}
+
+	if s.cursor.x+width >= s.width {
+		s.cursor.x = s.width - 1
+		s.wrapNext = true
+	} else {
+		s.cursor.x += width
+	}
+}
+
+// clearWide blanks the other half of the wide character at x, if any.
+func (s *Screen) clearWide(line []Cell, x int) {
+	if line[x].Rune == continuation && x > 0 {
+		line[x-1] = s.blank()
+	}
+	if x+1 < len(line) && line[x+1].Rune == continuation {
+		line[x+1] = s.blank()
+	}
+}
+
+func (s *Screen) handleEscape(r rune) {
+	s.state = ground
+	switch r {
+	case '[':
+		s.state = csi
+		s.params, s.private, s.intermediate = s.params[:0], 0, 0
+	case ']':
+		s.state = osc
+		s.oscData.Reset()
+	case '(', ')', '*', '+', '#', '%':
+		s.state = escapeIntermediate
+	case '7':
+		s.saved = s.cursor
+	case '8':
+		s.cursor = s.saved
+		s.wrapNext = false
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.cursor.x = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		title, reply := s.Title, s.Reply
+		s.reset(s.width, s.height)
+		s.scrollback = nil
+		s.Title, s.Reply = title, reply
+	}
+}
+
+func (s *Screen) handleCSI(r rune) {
+	switch {
+	case r >= '0' && r <= '9':
+		if len(s.params) == 0 {
+			s.params = append(s.params, 0)
+		}
+		if last := &s.params[len(s.params)-1]; *last < 1<<16 {
+			*last = *last*10 + int(r-'0')
+		}
+	case r == ';' || r == ':':
+		if len(s.params) == 0 {
+			s.params = append(s.params, 0)
+		}
+		if len(s.params) < maxParams {
+			s.params = append(s.params, 0)
+		}
+	case r >= '<' && r <= '?':
+		s.private = r
+	case r >= 0x20 && r <= 0x2f:
+		s.intermediate = r
+	case r >= 0x40 && r <= 0x7e:
+		s.state = ground
+		s.dispatchCSI(r)
+	case r == 0x1b:
+		s.state = escape
+	case r < 0x20:
+		// the controls are executed in the middle of a sequence.
+		s.handleGround(r)
+	}
+}
+
+// param returns the parameter i, or def if it is missing or 0.
+func (s *Screen) param(i, def int) int {
+	if i < len(s.params) && s.params[i] != 0 {
+		return s.params[i]
+	}
+	return def
+}
+
+func (s *Screen) dispatchCSI(r rune) {
+	if s.private == '?' {
+		switch r {
+		case 'h':
+			s.setModes(true)
+		case 'l':
+			s.setModes(false)
+		}
+		return
+	}
+	if s.private != 0 || s.intermediate != 0 {
+		return
+	}
+
+	n := s.param(0, 1)
+	switch r {
+	case '@':
+		s.insertBlanks(n)
+	case 'A':
+		s.moveTo(s.cursor.x, max(s.cursor.y-n, s.top))
+	case 'B', 'e':
+		s.moveTo(s.cursor.x, min(s.cursor.y+n, s.bottom))
+	case 'C', 'a':
+		s.moveTo(s.cursor.x+n, s.cursor.y)
+	case 'D':
+		s.moveTo(s.cursor.x-n, s.cursor.y)
+	case 'E':
+		s.moveTo(0, min(s.cursor.y+n, s.bottom))
+	case 'F':
+		s.moveTo(0, max(s.cursor.y-n, s.top))
+	case 'G', '`':
+		s.moveTo(n-1, s.cursor.y)
+	case 'H', 'f':
+		s.moveTo(s.param(1, 1)-1, n-1)
+	case 'd':
+		s.moveTo(s.cursor.x, n-1)
+	case 'J':
+		s.eraseDisplay(s.param(0, 0))
+	case 'K':
+		s.eraseLine(s.param(0, 0))
+	case 'L':
+		s.insertLines(n)
+	case 'M':
+		s.deleteLines(n)
+	case 'P':
+		s.deleteChars(n)
+	case 'X':
+		s.eraseChars(n)
+	case 'S':
+		s.scrollUp(s.top, s.bottom, n)
+	case 'T':
+		s.scrollDown(s.top, s.bottom, n)
+	case 'm':
+		s.setAttributes()
+	case 'r':
+		top, bottom := s.param(0, 1)-1, s.param(1, s.height)-1
+		if top < bottom && bottom < s.height {
+			s.top, s.bottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.saved = s.cursor
+	case 'u':
+		s.cursor = s.saved
+		s.wrapNext = false
+	case 'n':
+		switch s.param(0, 0) {
+		case 5:
+			s.reply("\x1b[0n")
+		case 6:
+			s.reply(fmt.Sprintf("\x1b[%d;%dR", s.cursor.y+1, s.cursor.x+1))
+		}
+	case 'c':
+		s.reply("\x1b[?6c")
+	}
+}
+
+func (s *Screen) setModes(on bool) {
+	for _, mode := range s.params {
+		switch mode {
+		case 1:
+			s.appCursorKeys = on
+		case 7:
+			s.autowrap = on
+		case 25:
+			s.cursorVisible = on
+		case 47, 1047, 1049:
+			if mode == 1049 && on {
+				s.saved = s.cursor
+			}
+			s.setAltScreen(on)
+			if mode == 1049 && !on {
+				s.cursor = s.saved
+			}
+		}
+	}
+}
+
+func (s *Screen) setAltScreen(on bool) {
+	if on == (s.main != nil) {
+		return
+	}
+
+	if on {
+		s.main = s.lines
+		s.lines = make([][]Cell, s.height)
+		for i := range s.lines {
+			s.lines[i] = s.blankLine()
+		}
+	} else {
+		s.lines, s.main = s.main, nil
+	}
+	s.wrapNext = false
+}
+
+func (s *Screen) setAttributes() {
+	if len(s.params) == 0 {
+		s.cursor.attr = defaultAttr
+		return
+	}
+
+	a := &s.cursor.attr
+	for i := 0; i < len(s.params); i++ {
+		switch p := s.params[i]; {
+		case p == 0:
+			*a = defaultAttr
+		case p == 1:
+			a.Bold = true
+		case p == 2:
+			a.Faint = true
+		case p == 3:
+			a.Italic = true
+		case p == 4:
+			a.Underline = true
+		case p == 7:
+			a.Reverse = true
+		case p == 22:
+			a.Bold, a.Faint = false, false
+		case p == 23:
+			a.Italic = false
+		case p == 24:
+			a.Underline = false
+		case p == 27:
+			a.Reverse = false
+		case p >= 30 && p <= 37:
+			a.Fg = Color(p - 30)
+		case p == 38:
+			a.Fg, i = s.extendedColor(i)
+		case p == 39:
+			a.Fg = DefaultColor
+		case p >= 40 && p <= 47:
+			a.Bg = Color(p - 40)
+		case p == 48:
+			a.Bg, i = s.extendedColor(i)
+		case p == 49:
+			a.Bg = DefaultColor
+		case p >= 90 && p <= 97:
+			a.Fg = Color(p - 90 + 8)
+		case p >= 100 && p <= 107:
+			a.Bg = Color(p - 100 + 8)
+		}
+	}
+}
+
+// extendedColor parses `5;n` or `2;r;g;b` after the parameter i, it returns
+// the color and the index of its last parameter.
+func (s *Screen) extendedColor(i int) (Color, int) {
+	if i+2 < len(s.params) && s.params[i+1] == 5 {
+		return Color(s.params[i+2] & 0xff), i + 2
+	}
+	if i+4 < len(s.params) && s.params[i+1] == 2 {
+		return RGB(uint8(s.params[i+2]), uint8(s.params[i+3]), uint8(s.params[i+4])), i + 4
+	}
+	return DefaultColor, len(s.params)
+}
+
+func (s *Screen) dispatchOSC() {
+	s.state = ground
+	// 0 sets the icon name and the title, 2 the title.
+	code, text, ok := strings.Cut(s.oscData.String(), ";")
+	if ok && (code == "0" || code == "2") {
+		s.Title = text
+	}
+}
+
+func (s *Screen) reply(answer string) {
+	if s.Reply != nil {
+		s.Reply([]byte(answer))
+	}
+}
+
+func (s *Screen) moveTo(x, y int) {
+	s.cursor.x = clamp(x, 0, s.width-1)
+	s.cursor.y = clamp(y, 0, s.height-1)
+	s.wrapNext = false
+}
+
+// lineFeed moves the cursor down, the scrolling region scrolls up when the
+// cursor is on its last line.
+func (s *Screen) lineFeed() {
+	s.wrapNext = false
+	if s.cursor.y == s.bottom {
+		s.scrollUp(s.top, s.bottom, 1)
+	} else if s.cursor.y < s.height-1 {
+		s.cursor.y++
+	}
+}
+
+// reverseIndex moves the cursor up, the scrolling region scrolls down when
+// the cursor is on its first line.
+func (s *Screen) reverseIndex() {
+	s.wrapNext = false
+	if s.cursor.y == s.top {
+		s.scrollDown(s.top, s.bottom, 1)
+	} else if s.cursor.y > 0 {
+		s.cursor.y--
+	}
+}
+
+// scrollUp moves the lines top..bottom up by n, the lines leaving the top
+// of the main screen go to the scrollback.
+func (s *Screen) scrollUp(top, bottom, n int) {
+	n = min(n, bottom-top+1)
+	for i := 0; i < n; i++ {
+		if top == 0 && s.main == nil {
+			s.pushScrollback(s.lines[top])
+		}
+		copy(s.lines[top:bottom], s.lines[top+1:bottom+1])
+		s.lines[bottom] = s.blankLine()
+	}
+}
+
+// scrollDown moves the lines top..bottom down by n.
+func (s *Screen) scrollDown(top, bottom, n int) {
+	n = min(n, bottom-top+1)
+	for i := 0; i < n; i++ {
+		copy(s.lines[top+1:bottom+1], s.lines[top:bottom])
+		s.lines[top] = s.blankLine()
+	}
+}
+
+func (s *Screen) pushScrollback(line []Cell) {
+	s.scrollback = append(s.scrollback, line)
+	if len(s.scrollback) > maxScrollback {
+		s.scrollback = s.scrollback[len(s.scrollback)-maxScrollback:]
+	}
+}
+
+func (s *Screen) insertLines(n int) {
+	if s.cursor.y < s.top || s.cursor.y > s.bottom {
+		return
+	}
+	s.scrollDown(s.cursor.y, s.bottom, n)
+	s.cursor.x, s.wrapNext = 0, false
+}
+
+func (s *Screen) deleteLines(n int) {
+	if s.cursor.y < s.top || s.cursor.y > s.bottom {
+		return
+	}
+	// the deleted lines don't go to the scrollback.
+	n = min(n, s.bottom-s.cursor.y+1)
+	for i := 0; i < n; i++ {
+		copy(s.lines[s.cursor.y:s.bottom], s.lines[s.cursor.y+1:s.bottom+1])
+		s.lines[s.bottom] = s.blankLine()
+	}
+	s.cursor.x, s.wrapNext = 0, false
+}
+
+func (s *Screen) insertBlanks(n int) {
+	line, x := s.lines[s.cursor.y], s.cursor.x
+	n = min(n, s.width-x)
+	copy(line[x+n:], line[x:])
+	for i := x; i < x+n; i++ {
+		line[i] = s.blank()
+	}
+	s.wrapNext = false
+}
+
+func (s *Screen) deleteChars(n int) {
+	line, x := s.lines[s.cursor.y], s.cursor.x
+	n = min(n, s.width-x)
+	copy(line[x:], line[x+n:])
+	for i := s.width - n; i < s.width; i++ {
+		line[i] = s.blank()
+	}
+	s.wrapNext = false
+}
+
+func (s *Screen) eraseChars(n int) {
+	s.erase(s.cursor.y, s.cursor.x, min(s.cursor.x+n, s.width))
+}
+
+// eraseDisplay erases from the cursor to the end of the screen (0), from
+// the start to the cursor (1), the screen (2) or the scrollback (3).
+func (s *Screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.erase(s.cursor.y, s.cursor.x, s.width)
+		for y := s.cursor.y + 1; y < s.height; y++ {
+			s.erase(y, 0, s.width)
+		}
+	case 1:
+		for y := 0; y < s.cursor.y; y++ {
+			s.erase(y, 0, s.width)
+		}
+		s.erase(s.cursor.y, 0, s.cursor.x+1)
+	case 2:
+		for y := 0; y < s.height; y++ {
+			s.erase(y, 0, s.width)
+		}
+	case 3:
+		s.scrollback = nil
+	}
+}
+
+// eraseLine erases from the cursor to the end of the line (0), from the
+// start to the cursor (1) or the line (2).
+func (s *Screen) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		s.erase(s.cursor.y, s.cursor.x, s.width)
+	case 1:
+		s.erase(s.cursor.y, 0, s.cursor.x+1)
+	case 2:
+		s.erase(s.cursor.y, 0, s.width)
+	}
+}
+
+// erase blanks the cells from..to of the row y.
+func (s *Screen) erase(y, from, to int) {
+	line := s.lines[y]
+	for x := from; x < to; x++ {
+		s.clearWide(line, x)
+		line[x] = s.blank()
+	}
+	s.wrapNext = false
+}
+
+// blank returns an empty cell, it keeps the background color.
+func (s *Screen) blank() Cell {
+	return Cell{Attr: Attr{Fg: DefaultColor, Bg: s.cursor.attr.Bg}}
+}
+
+func (s *Screen) blankLine() []Cell {
+	line := make([]Cell, s.width)
+	for i := range line {
+		line[i] = s.blank()
+	}
+	return line
+}
+
+func clamp(v, low, high int) int {
+	return max(low, min(v, high))
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,131 @@
+package term
+
+import (
+	"strings"
+	"testing"
+)
+
+func lines(s *Screen) []string {
+	_, height := s.Size()
+	out := make([]string, height)
+	for y := range out {
+		out[y] = lineText(s.lines[y])
+	}
+	return out
+}
+
+func checkLines(t *testing.T, s *Screen, want ...string) {
+	t.Helper()
+	if got := lines(s); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func checkCursor(t *testing.T, s *Screen, x, y int) {
+	t.Helper()
+	if cx, cy, _ := s.Cursor(); cx != x || cy != y {
+		t.Errorf("cursor at %d,%d, want %d,%d", cx, cy, x, y)
+	}
+}
+
+func TestScreen_Write(t *testing.T) {
+	s := NewScreen(10, 3)
+	s.Write([]byte("hello\r\nwor"))
+	s.Write([]byte("ld\x1b[1;3H"))
+	s.Write([]byte("LL\x1b[2;1H\x1b[K42"))
+	checkLines(t, s, "heLLo", "42", "")
+	checkCursor(t, s, 2, 1)
+
+	s.Write([]byte("\x1b[2J\x1b[HABCDEFGHIJK"))
+	checkLines(t, s, "ABCDEFGHIJ", "K", "")
+	checkCursor(t, s, 1, 1)
+
+	s.Write([]byte("\x1b[1;1H\x1b[3P\x1b[2@"))
+	checkLines(t, s, "  DEFGHIJ", "K", "")
+}
+
+func TestScreen_Scroll(t *testing.T) {
+	s := NewScreen(5, 3)
+	s.Write([]byte("1\r\n2\r\n3\r\n4\r\n5"))
+	checkLines(t, s, "3", "4", "5")
+	if got := s.Text(); got != "1\n2\n3\n4\n5" {
+		t.Errorf("unexpected text %q", got)
+	}
+
+	// scrolling region of the rows 2 and 3.
+	s.Write([]byte("\x1b[2;3r\x1b[3;1H\nx"))
+	checkLines(t, s, "3", "5", "x")
+
+	s.Write([]byte("\x1b[2;1H\x1bMy"))
+	checkLines(t, s, "3", "y", "5")
+}
+
+func TestScreen_Attributes(t *testing.T) {
+	s := NewScreen(10, 1)
+	s.Write([]byte("\x1b[1;31ma\x1b[38;5;200;48;2;1;2;3mb\x1b[0mc"))
+
+	if a := s.Cell(0, 0).Attr; !a.Bold || a.Fg != 1 || a.Bg != DefaultColor {
+		t.Errorf("unexpected attributes of a: %+v", a)
+	}
+	if a := s.Cell(1, 0).Attr; !a.Bold || a.Fg != 200 || a.Bg != RGB(1, 2, 3) || a.Bg.String() != "#010203" {
+		t.Errorf("unexpected attributes of b: %+v", a)
+	}
+	if a := s.Cell(2, 0).Attr; a != defaultAttr {
+		t.Errorf("unexpected attributes of c: %+v", a)
+	}
+}
+
+func TestScreen_AltScreen(t *testing.T) {
+	s := NewScreen(5, 2)
+	s.Write([]byte("sh$ "))
+	s.Write([]byte("\x1b[?1049h\x1b[Hvim"))
+	checkLines(t, s, "vim", "")
+	if !s.AltScreen() {
+		t.Error("expected the alternate screen")
+	}
+
+	s.Write([]byte("\x1b[?1049l"))
+	checkLines(t, s, "sh$", "")
+	checkCursor(t, s, 4, 0)
+}
+
+func TestScreen_WideAndSplitRunes(t *testing.T) {
+	s := NewScreen(5, 2)
+	data := []byte("a你好")
+	// the rune is split between two writes.
+	s.Write(data[:3])
+	s.Write(data[3:])
+	checkLines(t, s, "a你好", "")
+	checkCursor(t, s, 4, 0)
+
+	s.Write([]byte("世"))
+	checkLines(t, s, "a你好", "世")
+}
+
+func TestScreen_Reply(t *testing.T) {
+	s := NewScreen(10, 5)
+	var replies []string
+	s.Reply = func(p []byte) { replies = append(replies, string(p)) }
+
+	s.Write([]byte("\x1b[3;4H\x1b[6n\x1b]0;title\x07"))
+	if len(replies) != 1 || replies[0] != "\x1b[3;4R" {
+		t.Errorf("unexpected replies %q", replies)
+	}
+	if s.Title != "title" {
+		t.Errorf("unexpected title %q", s.Title)
+	}
+}
+
+func TestScreen_Resize(t *testing.T) {
+	s := NewScreen(5, 3)
+	s.Write([]byte("1\r\n2\r\n3"))
+	s.Resize(3, 2)
+	checkLines(t, s, "2", "3")
+	checkCursor(t, s, 1, 1)
+
+	s.Resize(4, 3)
+	checkLines(t, s, "2", "3", "")
+	if got := s.Text(); got != "1\n2\n3" {
+		t.Errorf("unexpected text %q", got)
+	}
+}
@@ -0,0 +1,133 @@
+package term
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/creack/pty"
+)
+
+// Terminal runs a program under a pseudo-terminal, its output is written to
+// a Screen.
+type Terminal struct {
+	mu     sync.Mutex
+	screen *Screen
+
+	cmd *exec.Cmd
+	pty *os.File
+
+	// updates is signaled when the screen changed, the signals are merged
+	// until they are received.
+	updates chan struct{}
+	// done is closed when the program has exited.
+	done chan struct{}
+	err  error
+}
+
+// Start runs the program name with args in a terminal of the size.
+func Start(width, height int, name string, args ...string) (*Terminal, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+
+	f, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(width), Rows: uint16(height)})
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Terminal{
+		screen:  NewScreen(width, height),
+		cmd:     cmd,
+		pty:     f,
+		updates: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	t.screen.Reply = func(p []byte) {
+		_, _ = f.Write(p)
+	}
+	go t.read()
+	return t, nil
+}
+
+func (t *Terminal) read() {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := t.pty.Read(buf)
+		if n > 0 {
+			t.mu.Lock()
+			_, _ = t.screen.Write(buf[:n])
+			t.mu.Unlock()
+
+			select {
+			case t.updates <- struct{}{}:
+			default:
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	err := t.cmd.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.err = err
+	}
+	close(t.done)
+}
+
+// Updates is signaled when the screen changed.
+func (t *Terminal) Updates() <-chan struct{} {
+	return t.updates
+}
+
+// Done is closed when the program has exited.
+func (t *Terminal) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err returns the error of running the program once it has exited, a non-zero
+// exit status isn't an error.
+func (t *Terminal) Err() error {
+	return t.err
+}
+
+// Screen calls f with the screen, the output of the program is held until f
+// returns.
+func (t *Terminal) Screen(f func(s *Screen)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f(t.screen)
+}
+
+// Write sends p, e.g. keys, to the program.
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.pty.Write(p)
+}
+
+// Resize changes the size of the terminal, the program is told about it.
+func (t *Terminal) Resize(width, height int) error {
+	t.mu.Lock()
+	t.screen.Resize(width, height)
+	t.mu.Unlock()
+	return pty.Setsize(t.pty, &pty.Winsize{Cols: uint16(width), Rows: uint16(height)})
+}
+
+// Close kills the program if it is still running.
+func (t *Terminal) Close() error {
+	select {
+	case <-t.done:
+		return t.pty.Close()
+	default:
+	}
+
+	if t.cmd.Process != nil {
+		_ = t.cmd.Process.Kill()
+	}
+	// closing the pty stops read, even if a child of the program still
+	// holds the terminal.
+	err := t.pty.Close()
+	<-t.done
+	return err
+}
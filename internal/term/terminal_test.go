@@ -0,0 +1,75 @@
+package term
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitText waits until the text of the terminal contains want.
+func waitText(t *testing.T, term *Terminal, want string) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		var text string
+		term.Screen(func(s *Screen) { text = s.Text() })
+		if strings.Contains(text, want) {
+			return
+		}
+
+		select {
+		case <-term.Updates():
+		case <-term.Done():
+		case <-deadline:
+			t.Fatalf("%q not found in %q", want, text)
+		}
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no pty on windows")
+	}
+
+	term, err := Start(20, 5, "sh", "-c", `printf 'name? '; read name; echo "hello $name"; stty size`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer term.Close()
+
+	waitText(t, term, "name?")
+	if _, err := term.Write([]byte("ge\r")); err != nil {
+		t.Fatal(err)
+	}
+	waitText(t, term, "hello ge")
+	waitText(t, term, "5 20")
+
+	select {
+	case <-term.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the program didn't exit")
+	}
+	if err := term.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTerminal_Close(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no pty on windows")
+	}
+
+	term, err := Start(20, 5, "sh", "-c", "sleep 60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := term.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-term.Done():
+	default:
+		t.Fatal("the program is still running")
+	}
+}
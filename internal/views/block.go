@@ -0,0 +1,78 @@
+package views
+
+import "strings"
+
+// Block is a rectangular region of the rows in [Top, Bottom] and the display
+// columns in [Left, Right), so that wide runes line up as they are shown.
+type Block struct {
+	Top    int
+	Bottom int
+	Left   int
+	Right  int
+}
+
+// NewBlock returns the block with the corners at the rows and display
+// columns of a and b.
+func NewBlock(aRow, aWidth, bRow, bWidth int) Block {
+	if bRow < aRow {
+		aRow, bRow = bRow, aRow
+	}
+	if bWidth < aWidth {
+		aWidth, bWidth = bWidth, aWidth
+	}
+	return Block{Top: aRow, Bottom: bRow, Left: aWidth, Right: bWidth}
+}
+
+// Cols returns the columns of the runes of r inside the block, a wide rune
+// belongs to the block if it starts inside it.
+func (b Block) Cols(r Row, tabWidth int) (int, int) {
+	return r.ColAt(b.Left, tabWidth), r.ColAt(b.Right, tabWidth)
+}
+
+// BlockText returns the text of every row of the block.
+func (rs Rows) BlockText(b Block, tabWidth int) []string {
+	var lines []string
+	for row := b.Top; row <= b.Bottom && row < rs.Len(); row++ {
+		from, to := b.Cols(rs[row], tabWidth)
+		lines = append(lines, string(rs[row][from:to]))
+	}
+	return lines
+}
+
+// DeleteBlock deletes the text of every row of the block.
+func (d *Document) DeleteBlock(b Block) {
+	d.BeginGroup()
+	defer d.EndGroup()
+
+	for row := b.Top; row <= b.Bottom && row < d.Height(); row++ {
+		from, to := b.Cols(d.Rows[row], d.indent.TabWidth)
+		if from < to {
+			d.Replace(Pos{Row: row, Col: from}, Pos{Row: row, Col: to}, "")
+		}
+	}
+}
+
+// InsertBlock inserts the lines at the display column width of the rows
+// starting at row. Short rows are padded with spaces and rows are added at
+// the end of the document as needed.
+func (d *Document) InsertBlock(row, width int, lines []string) {
+	d.BeginGroup()
+	defer d.EndGroup()
+
+	for i, line := range lines {
+		r := row + i
+		if r >= d.Height() {
+			d.Replace(d.Rows.end(), d.Rows.end(), "\n")
+		}
+
+		pad := width - d.Rows[r].TotalRuneWidth(d.indent.TabWidth)
+		if pad > 0 {
+			end := Pos{Row: r, Col: len(d.Rows[r])}
+			d.Replace(end, end, strings.Repeat(" ", pad)+line)
+			continue
+		}
+
+		at := Pos{Row: r, Col: d.Rows[r].ColAt(width, d.indent.TabWidth)}
+		d.Replace(at, at, line)
+	}
+}
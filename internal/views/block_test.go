@@ -0,0 +1,38 @@
+package views
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRows_BlockText(t *testing.T) {
+	d := newTestDocument(t, "我是你好\nabcdefgh\nab")
+
+	b := NewBlock(2, 6, 0, 2)
+	if lines := d.Rows.BlockText(b, 4); !reflect.DeepEqual(lines, []string{"是你", "cdef", ""}) {
+		t.Fatalf("unexpected %q", lines)
+	}
+
+	d.DeleteBlock(b)
+	if d.String() != "我好\nabgh\nab" {
+		t.Fatalf("unexpected %q", d.String())
+	}
+
+	d.Undo()
+	if d.String() != "我是你好\nabcdefgh\nab" {
+		t.Fatalf("unexpected %q", d.String())
+	}
+}
+
+func TestDocument_InsertBlock(t *testing.T) {
+	d := newTestDocument(t, "我是你好\nabcdefgh")
+
+	d.InsertBlock(0, 4, []string{"|", "|", "|"})
+	if d.String() != "我是|你好\nabcd|efgh\n    |" {
+		t.Fatalf("unexpected %q", d.String())
+	}
+
+	if _, ok := d.Undo(); !ok || d.String() != "我是你好\nabcdefgh" {
+		t.Fatalf("unexpected %q", d.String())
+	}
+}
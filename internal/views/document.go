@@ -1,13 +1,46 @@
 package views
 
 import (
+	"bytes"
+	"github.com/fzdwx/ge/internal/diff"
 	"github.com/fzdwx/ge/internal/syntax"
+	"hash/fnv"
+	"io"
 	"os"
+	"strings"
 )
 
+// Stdin the file name that loads a document from the standard input.
+const Stdin = "-"
+
 type Document struct {
 	Rows   Rows
 	syntax syntax.Syntax
+	// indent the indentation of the rows, of the syntax unless it is set.
+	indent syntax.Indent
+	// tree the parse tree of the rows, parsed again when it is asked for
+	// after a change.
+	tree *syntax.Tree
+
+	// revision is incremented by every change of the Rows.
+	revision int
+	// modified whether the Rows have changed since the document was loaded.
+	modified bool
+
+	// finalNewline whether the file ends with a newline.
+	finalNewline bool
+	// newline the line ending and charset the encoding of the file.
+	newline string
+	charset string
+	// settings of the file from its .editorconfig files, applied on save.
+	settings settings
+	// checksum of the file content when it was loaded or saved, used to tell
+	// our own writes from changes made by other processes.
+	checksum uint64
+
+	history      history
+	listeners    map[int]func(Change)
+	nextListener int
 }
 
 func (d *Document) String() string {
@@ -15,7 +48,48 @@ func (d *Document) String() string {
 }
 
 func NewDocument() *Document {
-	return &Document{Rows: Rows{}, syntax: syntax.From("")}
+	d := &Document{Rows: Rows{}, finalNewline: true, newline: "\n", charset: utf8Charset}
+	d.setSyntax("")
+	return d
+}
+
+// setSyntax sets the syntax, the settings and the indentation of filename.
+func (d *Document) setSyntax(filename string) {
+	d.syntax = syntax.From(filename)
+	d.settings = readSettings(filename)
+	d.indent = syntax.IndentOf(d.syntax.Type())
+	d.tree = syntax.NewTree(syntax.GrammarOf(d.syntax.Type()))
+	if d.settings.indent != nil {
+		d.indent = *d.settings.indent
+	}
+}
+
+// Filename the name of the file the document was loaded from.
+func (d *Document) Filename() string {
+	return d.syntax.FileName()
+}
+
+// Type the syntax type of the document, e.g. go.
+func (d *Document) Type() string {
+	return d.syntax.Type()
+}
+
+// Indent returns the indentation of the rows.
+func (d *Document) Indent() syntax.Indent {
+	return d.indent
+}
+
+// SetIndent changes the indentation of the rows, e.g. from the settings of
+// the project.
+func (d *Document) SetIndent(indent syntax.Indent) {
+	d.indent = indent
+}
+
+// Tree returns the parse tree of the rows, only the rows changed since it
+// was last asked for are parsed again.
+func (d *Document) Tree() *syntax.Tree {
+	d.tree.Parse(d.Rows.Len(), func(i int) []rune { return d.Rows[i] })
+	return d.tree
 }
 
 func (d *Document) Render() string {
@@ -23,26 +97,147 @@ func (d *Document) Render() string {
 }
 
 func (d *Document) Load(filename string) error {
+	// keep the filename even if the file does not exist, so it can be saved.
+	d.setSyntax(filename)
+
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return err
 	}
+	return d.load(data)
+}
 
-	d.syntax = syntax.From(filename)
+// Read loads the content of r, e.g. the standard input, the document has no
+// file until it is saved.
+func (d *Document) Read(r io.Reader) error {
+	d.setSyntax("")
 
-	if len(data) <= 0 {
+	data, err := io.ReadAll(r)
+	if err != nil {
 		return err
 	}
+	return d.load(data)
+}
 
-	rows, err := NewRows(data)
+func (d *Document) load(data []byte) error {
+	text, charset, err := decode(data, d.settings.charset)
+	if err != nil {
+		return err
+	}
+	rows, err := NewRows(text)
 	if err != nil {
 		return err
 	}
 
+	change := Change{To: d.Rows.end()}
 	d.Rows = rows
+	change.End = d.Rows.end()
+
+	d.finalNewline = len(text) <= 0 || bytes.HasSuffix(text, []byte{'\n'})
+	if d.settings.finalNewline != nil {
+		d.finalNewline = *d.settings.finalNewline
+	}
+	d.newline = detectNewline(text)
+	d.charset = charset
+	d.checksum = checksum(data)
+	d.history = history{}
+	d.changed(change)
+	d.modified = false
 	return nil
 }
 
+// Reload loads the file again, dropping the changes of the document.
+func (d *Document) Reload() error {
+	return d.Load(d.Filename())
+}
+
+// Bytes returns the content of the document as it is written to the file.
+func (d *Document) Bytes() []byte {
+	data := []byte(d.String())
+	if d.finalNewline && d.Rows.Len() > 0 {
+		data = append(data, '\n')
+	}
+	return data
+}
+
+// Save writes the document to its file.
+func (d *Document) Save() error {
+	return d.SaveAs(d.Filename())
+}
+
+// SaveAs writes the document to filename, which becomes the file of the
+// document. The settings of the file, e.g. its line ending, are applied.
+func (d *Document) SaveAs(filename string) error {
+	s := d.settings
+	if filename != d.Filename() {
+		s = readSettings(filename)
+	}
+
+	if s.trimTrailingWhitespace {
+		d.TrimTrailingWhitespace()
+	}
+	if s.finalNewline != nil {
+		d.finalNewline = *s.finalNewline
+	}
+	data, err := d.encode(s)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode()
+	}
+
+	if err := os.WriteFile(filename, data, mode); err != nil {
+		return err
+	}
+
+	if filename != d.Filename() {
+		d.setSyntax(filename)
+	}
+	d.checksum = checksum(data)
+	d.history.commit()
+	d.history.clean = len(d.history.undo)
+	d.modified = false
+	return nil
+}
+
+// ChangedOnDisk reports whether the file has been changed by another process
+// since it was loaded or saved.
+func (d *Document) ChangedOnDisk() (bool, error) {
+	data, err := os.ReadFile(d.Filename())
+	if err != nil {
+		return false, err
+	}
+	return checksum(data) != d.checksum, nil
+}
+
+func checksum(data []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return h.Sum64()
+}
+
+// Revision returns a number that changes every time the document is edited.
+func (d *Document) Revision() int {
+	return d.revision
+}
+
+// Modified reports whether the document has changes that are not saved.
+func (d *Document) Modified() bool {
+	return d.modified
+}
+
+func (d *Document) changed(change Change) {
+	d.revision++
+	d.modified = true
+	d.tree.Edit(change.From.Row, change.To.Row, change.End.Row)
+	for _, listener := range d.listeners {
+		listener(change)
+	}
+}
+
 // Height get document Rows len.
 func (d *Document) Height() int {
 	return d.Rows.Len()
@@ -55,16 +250,157 @@ func (d *Document) Row(i int) Row {
 
 // InsertRune insert rune at specified row and column
 func (d *Document) InsertRune(r rune, row int, col int) {
-	if r == '\n' {
-		d.SplitLine(row, col)
+	d.Replace(Pos{Row: row, Col: col}, Pos{Row: row, Col: col}, string(r))
+}
+
+func (d *Document) SplitLine(row int, col int) {
+	d.Replace(Pos{Row: row, Col: col}, Pos{Row: row, Col: col}, "\n")
+}
+
+// Text returns the text between from and to.
+func (d *Document) Text(from, to Pos) string {
+	return d.Rows.Text(from, to)
+}
+
+// Replace replaces the text between from and to with text, and returns the
+// position after the inserted text.
+func (d *Document) Replace(from, to Pos, text string) Pos {
+	from, to = d.Rows.Clamp(from), d.Rows.Clamp(to)
+	if to.Before(from) {
+		from, to = to, from
+	}
+
+	removed, empty := d.Rows.Text(from, to), d.Rows.Len() == 0
+	end := d.apply(from, to, text)
+	d.history.record(edit{Change: Change{From: from, To: to, End: end}, removed: removed, inserted: text, empty: empty})
+	return end
+}
+
+// apply replaces the text without recording it in the history.
+func (d *Document) apply(from, to Pos, text string) Pos {
+	end := d.Rows.Replace(from, to, text)
+	d.changed(Change{From: from, To: to, End: end})
+	return end
+}
+
+// ReplaceLines replaces the rows in [start, end) with lines.
+func (d *Document) ReplaceLines(start, end int, lines []string) {
+	text := strings.Join(lines, "\n")
+	switch {
+	case end < d.Height():
+		if len(lines) > 0 {
+			text += "\n"
+		}
+		d.Replace(Pos{Row: start}, Pos{Row: end}, text)
+	case start > 0:
+		// there is no row after the replaced ones, so the newline before them
+		// is replaced instead.
+		if len(lines) > 0 {
+			text = "\n" + text
+		}
+		d.Replace(Pos{Row: start - 1, Col: len(d.Row(start - 1))}, d.Rows.end(), text)
+	default:
+		d.Replace(Pos{}, d.Rows.end(), text)
+	}
+}
+
+// Rewrite changes the content of the document to lines in one undo step,
+// only the changed runes are replaced so that the positions around them, e.g.
+// the cursors, stay where they are.
+func (d *Document) Rewrite(lines []string) {
+	d.BeginGroup()
+	defer d.EndGroup()
+
+	old := d.Lines()
+	if len(old) == 0 {
+		d.Replace(Pos{}, Pos{}, strings.Join(lines, "\n"))
 		return
 	}
 
-	d.Rows.InsertRune(r, row, col)
+	hunks := diff.Lines(old, lines)
+	// from the last hunk, so that the rows of the others don't move.
+	for i := len(hunks) - 1; i >= 0; i-- {
+		h := hunks[i]
+		text := strings.Join(lines[h.B:h.BEnd], "\n")
+
+		var from, to Pos
+		switch {
+		case h.A == len(old):
+			// appended rows.
+			from, to = d.Rows.end(), d.Rows.end()
+			text = "\n" + text
+		case h.AEnd < len(old):
+			from, to = Pos{Row: h.A}, Pos{Row: h.AEnd}
+			if h.B < h.BEnd {
+				text += "\n"
+			}
+		case h.B == h.BEnd && h.A > 0:
+			// the last rows are removed with the newline before them.
+			from, to = Pos{Row: h.A - 1, Col: len(d.Row(h.A - 1))}, d.Rows.end()
+		default:
+			from, to = Pos{Row: h.A}, d.Rows.end()
+		}
+		d.replaceChanged(from, to, text)
+	}
 }
 
-func (d *Document) SplitLine(row int, col int) {
-	d.Rows.SplitLine(row, col)
+// replaceChanged replaces the text between from and to with text, only the
+// runes that differ are replaced.
+func (d *Document) replaceChanged(from, to Pos, text string) {
+	a, b := []rune(d.Text(from, to)), []rune(text)
+
+	hunks := diff.Runes(a, b)
+	for i := len(hunks) - 1; i >= 0; i-- {
+		h := hunks[i]
+		start := advance(from, a[:h.A])
+		end := advance(start, a[h.A:h.AEnd])
+		d.Replace(start, end, string(b[h.B:h.BEnd]))
+	}
+}
+
+// advance returns the position after text inserted at p.
+func advance(p Pos, text []rune) Pos {
+	for _, r := range text {
+		if r == '\n' {
+			p = Pos{Row: p.Row + 1}
+		} else {
+			p.Col++
+		}
+	}
+	return p
+}
+
+// IndentLines inserts unit at the start of the non-empty rows in [start, end].
+func (d *Document) IndentLines(start, end int, unit string) {
+	for row := start; row <= end && row < d.Height(); row++ {
+		if len(d.Rows[row]) > 0 {
+			d.Replace(Pos{Row: row}, Pos{Row: row}, unit)
+		}
+	}
+}
+
+// OutdentLines removes one level of indentation, a tab or up to width
+// spaces, from the start of the rows in [start, end].
+func (d *Document) OutdentLines(start, end int, width int) {
+	for row := start; row <= end && row < d.Height(); row++ {
+		line, n := d.Rows[row], 0
+		if len(line) > 0 && line[0] == '\t' {
+			n = 1
+		} else {
+			for n < len(line) && n < width && line[n] == ' ' {
+				n++
+			}
+		}
+
+		if n > 0 {
+			d.Replace(Pos{Row: row}, Pos{Row: row, Col: n}, "")
+		}
+	}
+}
+
+// Lines returns every row as a string.
+func (d *Document) Lines() []string {
+	return d.Rows.Lines()
 }
 
 // Length  Value returns the value of the text input.
@@ -81,9 +417,39 @@ func LoadDocument(filenames ...string) (*Document, error) {
 		return document, nil
 	}
 
+	if filenames[0] == Stdin {
+		return document, document.Read(os.Stdin)
+	}
 	if err := document.Load(filenames[0]); err != nil {
 		return document, err
 	}
 
 	return document, nil
 }
+
+// Find returns the position of the next occurrence of text at or after pos,
+// the search wraps around at the end of the document.
+func (d *Document) Find(text string, after Pos) (Pos, bool) {
+	if text == "" || d.Height() <= 0 {
+		return Pos{}, false
+	}
+
+	var lines [][]rune
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, []rune(line))
+	}
+
+	after = d.Rows.Clamp(after)
+	for i := 0; i <= d.Height(); i++ {
+		row, start := (after.Row+i)%d.Height(), 0
+		if i == 0 {
+			start = after.Col
+		}
+		for col := start; col <= len(d.Row(row)); col++ {
+			if d.Rows.matchAt(row, col, lines) {
+				return Pos{Row: row, Col: col}, true
+			}
+		}
+	}
+	return Pos{}, false
+}
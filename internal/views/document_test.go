@@ -2,6 +2,8 @@ package views
 
 import (
 	"fmt"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -9,6 +11,9 @@ func Test_Load(t *testing.T) {
 
 	//filenames := "document.go"
 	filenames := "C:\\Users\\98065\\IdeaProjects\\ge\\README.md"
+	if _, err := os.Stat(filenames); err != nil {
+		t.Skip(err)
+	}
 	document, err := LoadDocument(filenames)
 	if err != nil {
 		panic(err)
@@ -20,3 +25,17 @@ func Test_Load(t *testing.T) {
 	//fmt.Println("row height", document.Height(), "val:", document.Row(document.Height()))
 	//fmt.Print(document.Row(4))
 }
+
+func TestDocument_Read(t *testing.T) {
+	d := NewDocument()
+	if err := d.Read(strings.NewReader("commit 1\nAuthor: a\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Filename() != "" || d.Modified() {
+		t.Errorf("unexpected file %q, modified %v", d.Filename(), d.Modified())
+	}
+	if got := string(d.Bytes()); got != "commit 1\nAuthor: a\n" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
@@ -0,0 +1,148 @@
+package views
+
+type (
+	// Change describes an edit of a document, the text between From and To
+	// was replaced by a text that ends at End.
+	Change struct {
+		From Pos
+		To   Pos
+		End  Pos
+	}
+
+	// edit is a recorded Replace, From and To are the range before the edit.
+	edit struct {
+		Change
+		removed  string
+		inserted string
+		// empty whether the document had no rows before the edit, which
+		// added the first one.
+		empty bool
+	}
+
+	// history records the edits of a document, grouped into undo steps.
+	history struct {
+		undo [][]edit
+		redo [][]edit
+		// group the edits of the step being recorded.
+		group []edit
+		// depth the number of open groups, see Document.BeginGroup.
+		depth int
+		// clean is the number of undo steps when the document was saved, or
+		// -1 when that state can't be reached anymore.
+		clean int
+	}
+)
+
+func (h *history) record(e edit) {
+	h.group = append(h.group, e)
+	h.redo = nil
+	if h.clean > len(h.undo) {
+		h.clean = -1
+	}
+
+	if h.depth <= 0 {
+		h.commit()
+	}
+}
+
+// commit ends the current undo step.
+func (h *history) commit() {
+	if len(h.group) > 0 {
+		h.undo = append(h.undo, h.group)
+		h.group = nil
+	}
+}
+
+// BeginGroup starts an undo step, all edits until the matching EndGroup are
+// undone together. Groups can be nested, only the outermost one counts.
+func (d *Document) BeginGroup() {
+	d.history.depth++
+}
+
+// EndGroup ends the undo step started by BeginGroup.
+func (d *Document) EndGroup() {
+	d.history.depth--
+	if d.history.depth <= 0 {
+		d.history.depth = 0
+		d.history.commit()
+	}
+}
+
+// Undo reverts the last undo step, it returns the position of the first
+// edit of the step and false if there is nothing to undo.
+func (d *Document) Undo() (Pos, bool) {
+	h := &d.history
+	h.commit()
+	if len(h.undo) <= 0 {
+		return Pos{}, false
+	}
+
+	step := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+
+	var pos Pos
+	for i := len(step) - 1; i >= 0; i-- {
+		e := step[i]
+		d.apply(e.From, e.End, e.removed)
+		if e.empty {
+			d.Rows = Rows{}
+			d.changed(Change{})
+		}
+		pos = e.From
+	}
+
+	h.redo = append(h.redo, step)
+	d.modified = len(h.undo) != h.clean
+	return pos, true
+}
+
+// Redo applies the last undone step again, it returns the position after
+// the last edit of the step and false if there is nothing to redo.
+func (d *Document) Redo() (Pos, bool) {
+	h := &d.history
+	if len(h.redo) <= 0 {
+		return Pos{}, false
+	}
+
+	step := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+
+	var pos Pos
+	for _, e := range step {
+		pos = d.apply(e.From, e.To, e.inserted)
+	}
+
+	h.undo = append(h.undo, step)
+	d.modified = len(h.undo) != h.clean
+	return pos, true
+}
+
+// OnChange registers f to be called after every change of the document, the
+// returned function unregisters it.
+func (d *Document) OnChange(f func(Change)) func() {
+	if d.listeners == nil {
+		d.listeners = map[int]func(Change){}
+	}
+
+	d.nextListener++
+	id := d.nextListener
+	d.listeners[id] = f
+	return func() {
+		delete(d.listeners, id)
+	}
+}
+
+// Adjust returns where p is after change, positions inside the replaced
+// text move to its end.
+func (c Change) Adjust(p Pos) Pos {
+	switch {
+	case p.Before(c.From):
+		return p
+	case p.Before(c.To):
+		return c.End
+	case p.Row == c.To.Row:
+		return Pos{Row: c.End.Row, Col: c.End.Col + p.Col - c.To.Col}
+	default:
+		return Pos{Row: p.Row + c.End.Row - c.To.Row, Col: p.Col}
+	}
+}
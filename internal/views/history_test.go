@@ -0,0 +1,159 @@
+package views
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestDocument(t *testing.T, s string) *Document {
+	rows, err := NewRows([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDocument()
+	d.Rows = rows
+	return d
+}
+
+func TestDocument_Undo(t *testing.T) {
+	d := newTestDocument(t, "hello\nworld")
+
+	d.BeginGroup()
+	d.InsertRune('!', 0, 5)
+	d.SplitLine(1, 2)
+	d.EndGroup()
+	d.Replace(Pos{Row: 0}, Pos{Row: 0, Col: 1}, "j")
+	if d.String() != "jello!\nwo\nrld" {
+		t.Fatalf("unexpected %q", d.String())
+	}
+
+	if pos, ok := d.Undo(); !ok || pos != (Pos{}) || d.String() != "hello!\nwo\nrld" {
+		t.Fatalf("unexpected %q %v", d.String(), pos)
+	}
+	if pos, ok := d.Undo(); !ok || pos != (Pos{Row: 0, Col: 5}) || d.String() != "hello\nworld" {
+		t.Fatalf("unexpected %q %v", d.String(), pos)
+	}
+	if _, ok := d.Undo(); ok {
+		t.Fatal("expected nothing to undo")
+	}
+
+	if pos, ok := d.Redo(); !ok || pos != (Pos{Row: 2}) || d.String() != "hello!\nwo\nrld" {
+		t.Fatalf("unexpected %q %v", d.String(), pos)
+	}
+
+	d.ReplaceLines(1, 3, []string{"x"})
+	if _, ok := d.Redo(); ok {
+		t.Fatal("expected an edit to clear the redo steps")
+	}
+	d.Undo()
+	if d.String() != "hello!\nwo\nrld" {
+		t.Fatalf("unexpected %q", d.String())
+	}
+}
+
+func TestDocument_Undo_Empty(t *testing.T) {
+	d := NewDocument()
+	d.Replace(Pos{}, Pos{}, "a\nb")
+	if _, ok := d.Undo(); !ok || d.Height() != 0 || d.Modified() {
+		t.Fatalf("unexpected %d rows, modified %v", d.Height(), d.Modified())
+	}
+	if _, ok := d.Redo(); !ok || d.String() != "a\nb" {
+		t.Fatalf("unexpected %q", d.String())
+	}
+}
+
+func TestDocument_OnChange(t *testing.T) {
+	d := newTestDocument(t, "ab\ncd")
+
+	cursor := Pos{Row: 1, Col: 1}
+	unsubscribe := d.OnChange(func(c Change) {
+		cursor = c.Adjust(cursor)
+	})
+
+	d.Replace(Pos{Row: 0, Col: 1}, Pos{Row: 1}, "x\ny\nz")
+	if cursor != (Pos{Row: 2, Col: 2}) {
+		t.Fatalf("unexpected %v", cursor)
+	}
+
+	d.Replace(Pos{Row: 0}, Pos{Row: 2, Col: 2}, "")
+	if cursor != (Pos{}) {
+		t.Fatalf("unexpected %v", cursor)
+	}
+
+	unsubscribe()
+	d.InsertRune('a', 0, 0)
+	if cursor != (Pos{}) {
+		t.Fatalf("unexpected %v", cursor)
+	}
+}
+
+func TestDocument_Find(t *testing.T) {
+	d := newTestDocument(t, "foo bar\nbar foo\nfoo")
+
+	for _, tc := range []struct {
+		text  string
+		after Pos
+		want  Pos
+	}{
+		{"foo", Pos{}, Pos{}},
+		{"foo", Pos{Col: 1}, Pos{Row: 1, Col: 4}},
+		{"foo", Pos{Row: 2, Col: 1}, Pos{}},
+		{"bar\nbar", Pos{}, Pos{Col: 4}},
+		{"foo\nfoo", Pos{Row: 1}, Pos{Row: 1, Col: 4}},
+	} {
+		if got, ok := d.Find(tc.text, tc.after); !ok || got != tc.want {
+			t.Errorf("Find(%q, %v) = %v, want %v", tc.text, tc.after, got, tc.want)
+		}
+	}
+
+	if _, ok := d.Find("baz", Pos{}); ok {
+		t.Error("unexpected match of baz")
+	}
+}
+
+func TestDocument_Rewrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{name: "same", before: "a\nb", after: "a\nb"},
+		{name: "change", before: "func f(){\nx:=1\n}", after: "func f() {\n\tx := 1\n}"},
+		{name: "insert", before: "a\nc", after: "a\nb\nc"},
+		{name: "append", before: "a", after: "a\nb\nc"},
+		{name: "remove", before: "a\nb\nc", after: "a\nc"},
+		{name: "remove last", before: "a\nb\nc", after: "a"},
+		{name: "remove first", before: "a\nb\nc", after: "c"},
+		{name: "empty", before: "", after: "a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDocument(t, tt.before)
+			d.Rewrite(strings.Split(tt.after, "\n"))
+			if d.String() != tt.after {
+				t.Fatalf("unexpected %q", d.String())
+			}
+
+			d.Undo()
+			if d.String() != tt.before {
+				t.Fatalf("unexpected %q after undo", d.String())
+			}
+		})
+	}
+}
+
+func TestDocument_Rewrite_Cursor(t *testing.T) {
+	d := newTestDocument(t, "package a\nfunc f(){\nx:=1\nreturn  x\n}")
+
+	cursors := []Pos{{Row: 2, Col: 3}, {Row: 3, Col: 8}}
+	d.OnChange(func(c Change) {
+		for i := range cursors {
+			cursors[i] = c.Adjust(cursors[i])
+		}
+	})
+	d.Rewrite([]string{"package a", "", "func f() {", "\tx := 1", "\treturn x", "}"})
+	if cursors[0] != (Pos{Row: 3, Col: 6}) || cursors[1] != (Pos{Row: 4, Col: 8}) {
+		t.Fatalf("unexpected %v", cursors)
+	}
+}
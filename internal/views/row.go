@@ -6,6 +6,7 @@ import (
 	"errors"
 	"github.com/fzdwx/x/str"
 	rw "github.com/mattn/go-runewidth"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -13,6 +14,12 @@ type (
 	Row []rune
 
 	Rows []Row
+
+	// Pos is a position in Rows, Col is the rune index in the row.
+	Pos struct {
+		Row int
+		Col int
+	}
 )
 
 func (rs Rows) String() string {
@@ -72,15 +79,53 @@ func (r Row) Col(col int) rune {
 	return r[col]
 }
 
-func (r Row) RuneWidth(col int) int {
-	if len(r) <= col {
-		return 0
-	} // todo
-	return rw.RuneWidth(r[col])
+// RuneWidth returns the display width of the rune c starting at the display
+// column width, a tab extends to the next tab stop.
+func RuneWidth(c rune, width, tabWidth int) int {
+	if c == '\t' && tabWidth > 0 {
+		return tabWidth - width%tabWidth
+	}
+	return rw.RuneWidth(c)
+}
+
+// TotalRuneWidth returns the display width of the row.
+func (r Row) TotalRuneWidth(tabWidth int) int {
+	return r.Width(len(r), tabWidth)
+}
+
+// Width returns the display width of the runes before col.
+func (r Row) Width(col, tabWidth int) int {
+	if col > len(r) {
+		col = len(r)
+	}
+
+	w := 0
+	for _, c := range r[:col] {
+		w += RuneWidth(c, w, tabWidth)
+	}
+	return w
+}
+
+// ColAt returns the column of the first rune starting at or after the display
+// column width, or the length of the row.
+func (r Row) ColAt(width, tabWidth int) int {
+	w := 0
+	for col, c := range r {
+		if w >= width {
+			return col
+		}
+		w += RuneWidth(c, w, tabWidth)
+	}
+	return len(r)
 }
 
-func (r Row) TotalRuneWidth() int {
-	return rw.StringWidth(r.String())
+// Indentation returns the number of spaces and tabs at the start of the row.
+func (r Row) Indentation() int {
+	n := 0
+	for n < len(r) && (r[n] == ' ' || r[n] == '\t') {
+		n++
+	}
+	return n
 }
 
 // Len get row len
@@ -98,15 +143,15 @@ func (rs Rows) Row(idx int) Row {
 	return rs[idx]
 }
 
-func (rs Rows) SplitLine(row int, col int) {
+func (rs *Rows) SplitLine(row int, col int) {
 	rowLine := rs.Row(row)
 	head, tailSrc := rowLine[:col], rowLine[col:]
 	tail := make([]rune, len(tailSrc))
 	copy(tail, tailSrc)
 
-	_ = append(rs[:row+1], rs[row:]...)
-	rs[row] = head
-	rs[row+1] = tail
+	*rs = append((*rs)[:row+1], (*rs)[row:]...)
+	(*rs)[row] = head
+	(*rs)[row+1] = tail
 }
 
 func (rs Rows) InsertRune(r rune, row int, col int) {
@@ -129,3 +174,129 @@ func (rs Rows) NewLine() Rows {
 func (rs Rows) Has(row int) bool {
 	return row < len(rs)
 }
+
+// Lines returns every row as a string.
+func (rs Rows) Lines() []string {
+	lines := make([]string, rs.Len())
+	for i, row := range rs {
+		lines[i] = row.String()
+	}
+	return lines
+}
+
+// Clamp returns the nearest valid position of pos.
+func (rs Rows) Clamp(pos Pos) Pos {
+	if rs.Len() <= 0 {
+		return Pos{}
+	}
+
+	if pos.Row < 0 {
+		return Pos{}
+	}
+	if pos.Row >= rs.Len() {
+		return Pos{Row: rs.Len() - 1, Col: len(rs[rs.Len()-1])}
+	}
+
+	if pos.Col < 0 {
+		pos.Col = 0
+	}
+	if pos.Col > len(rs[pos.Row]) {
+		pos.Col = len(rs[pos.Row])
+	}
+	return pos
+}
+
+// Text returns the runes between from and to, rows are joined by a newline.
+func (rs Rows) Text(from, to Pos) string {
+	from, to = rs.Clamp(from), rs.Clamp(to)
+	if rs.Len() <= 0 || !from.Before(to) {
+		return str.Empty
+	}
+
+	if from.Row == to.Row {
+		return string(rs[from.Row][from.Col:to.Col])
+	}
+
+	fluent := str.NewFluent()
+	fluent.Str(string(rs[from.Row][from.Col:]))
+	for row := from.Row + 1; row < to.Row; row++ {
+		fluent.NewLine().Str(rs[row].String())
+	}
+	fluent.NewLine().Str(string(rs[to.Row][:to.Col]))
+	return fluent.String()
+}
+
+// Replace replaces the runes between from and to with text, and returns the
+// position after the inserted text.
+func (rs *Rows) Replace(from, to Pos, text string) Pos {
+	if rs.Len() <= 0 {
+		*rs = Rows{Row{}}
+	}
+
+	from, to = rs.Clamp(from), rs.Clamp(to)
+	if to.Before(from) {
+		from, to = to, from
+	}
+
+	lines := strings.Split(text, "\n")
+	head := (*rs)[from.Row][:from.Col]
+	tail := (*rs)[to.Row][to.Col:]
+
+	replaced := make(Rows, len(lines))
+	for i, line := range lines {
+		replaced[i] = Row(line)
+	}
+	end := Pos{Row: from.Row + len(lines) - 1, Col: len(replaced[len(lines)-1])}
+	if len(lines) == 1 {
+		end.Col += len(head)
+	}
+
+	replaced[0] = append(append(Row{}, head...), replaced[0]...)
+	replaced[len(lines)-1] = append(replaced[len(lines)-1], tail...)
+
+	rs.ReplaceLines(from.Row, to.Row+1, replaced)
+	return end
+}
+
+// ReplaceLines replaces the rows in [start, end) with lines.
+func (rs *Rows) ReplaceLines(start, end int, lines Rows) {
+	rest := append(Rows{}, (*rs)[end:]...)
+	*rs = append(append((*rs)[:start], lines...), rest...)
+}
+
+// end returns the position after the last rune.
+func (rs Rows) end() Pos {
+	if rs.Len() <= 0 {
+		return Pos{}
+	}
+	return Pos{Row: rs.Len() - 1, Col: len(rs[rs.Len()-1])}
+}
+
+// Before reports whether p is before other.
+func (p Pos) Before(other Pos) bool {
+	return p.Row < other.Row || (p.Row == other.Row && p.Col < other.Col)
+}
+
+// matchAt reports whether the text split into lines starts at row and col.
+func (rs Rows) matchAt(row, col int, lines [][]rune) bool {
+	for i, line := range lines {
+		if !rs.Has(row + i) {
+			return false
+		}
+
+		r := rs[row+i]
+		if i == 0 {
+			r = r[col:]
+		}
+
+		switch {
+		case i == len(lines)-1:
+			if len(r) < len(line) || string(r[:len(line)]) != string(line) {
+				return false
+			}
+		case string(r) != string(line):
+			return false
+		}
+	}
+	return true
+}
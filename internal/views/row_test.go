@@ -38,3 +38,51 @@ func Test_Rune(t *testing.T) {
 	fmt.Println(rw.RuneWidth(utf8.RuneError))
 
 }
+
+func TestRows_Replace(t *testing.T) {
+	rows, err := NewRows([]byte("hello\nworld\n我是你好"))
+	if err != nil {
+		panic(err)
+	}
+
+	end := rows.Replace(Pos{Row: 0, Col: 2}, Pos{Row: 1, Col: 3}, "y\nfoo\nbar")
+	if rows.String() != "hey\nfoo\nbarld\n我是你好" || end != (Pos{Row: 2, Col: 3}) {
+		t.Fatalf("unexpected %q %v", rows.String(), end)
+	}
+
+	if text := rows.Text(Pos{Row: 2, Col: 3}, Pos{Row: 3, Col: 2}); text != "ld\n我是" {
+		t.Fatalf("unexpected text %q", text)
+	}
+
+	end = rows.Replace(Pos{Row: 3, Col: 1}, Pos{Row: 3, Col: 1}, "x")
+	if rows.Row(3).String() != "我x是你好" || end != (Pos{Row: 3, Col: 2}) {
+		t.Fatalf("unexpected %q %v", rows.Row(3), end)
+	}
+}
+
+func TestRow_Width(t *testing.T) {
+	row := Row("\ta\tb我")
+
+	tests := []struct {
+		col   int
+		width int
+	}{{0, 0}, {1, 4}, {2, 5}, {3, 8}, {4, 9}, {5, 11}, {9, 11}}
+	for _, tt := range tests {
+		if w := row.Width(tt.col, 4); w != tt.width {
+			t.Errorf("Width(%d) = %d, want %d", tt.col, w, tt.width)
+		}
+	}
+
+	if w := row.Width(1, 8); w != 8 {
+		t.Errorf("Width(1) = %d with tabs of 8", w)
+	}
+	if col := row.ColAt(2, 4); col != 1 {
+		t.Errorf("ColAt(2) = %d, a tab belongs to the column it starts at", col)
+	}
+	if col := row.ColAt(6, 4); col != 3 {
+		t.Errorf("ColAt(6) = %d", col)
+	}
+	if n := Row("\t  x ").Indentation(); n != 3 {
+		t.Errorf("Indentation() = %d", n)
+	}
+}
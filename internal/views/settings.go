@@ -0,0 +1,200 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/fzdwx/ge/internal/editorconfig"
+	"github.com/fzdwx/ge/internal/logx"
+	"github.com/fzdwx/ge/internal/syntax"
+)
+
+// The charsets of editorconfig.
+const (
+	utf8Charset    = "utf-8"
+	utf8BOMCharset = "utf-8-bom"
+	latin1Charset  = "latin1"
+	utf16BECharset = "utf-16be"
+	utf16LECharset = "utf-16le"
+)
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16BEBOM = []byte{0xfe, 0xff}
+	utf16LEBOM = []byte{0xff, 0xfe}
+)
+
+// settings how the file of a document is written, from its .editorconfig
+// files. The empty values keep what the file had when it was loaded.
+type settings struct {
+	newline                string
+	charset                string
+	trimTrailingWhitespace bool
+	finalNewline           *bool
+	indent                 *syntax.Indent
+}
+
+// readSettings returns the settings of filename from its .editorconfig files.
+func readSettings(filename string) settings {
+	var s settings
+	if filename == "" || filename == Stdin {
+		return s
+	}
+
+	properties, err := editorconfig.Lookup(filename)
+	if err != nil {
+		logx.Warn().Err(err).Str("filename", filename).Msg("could not read the editorconfig")
+		return s
+	}
+
+	switch properties["end_of_line"] {
+	case "lf":
+		s.newline = "\n"
+	case "crlf":
+		s.newline = "\r\n"
+	case "cr":
+		s.newline = "\r"
+	}
+	switch charset := properties["charset"]; charset {
+	case utf8Charset, utf8BOMCharset, latin1Charset, utf16BECharset, utf16LECharset:
+		s.charset = charset
+	}
+	s.trimTrailingWhitespace = properties["trim_trailing_whitespace"] == "true"
+	switch properties["insert_final_newline"] {
+	case "true", "false":
+		finalNewline := properties["insert_final_newline"] == "true"
+		s.finalNewline = &finalNewline
+	}
+
+	indent := syntax.IndentOf(syntax.From(filename).Type())
+	configured := false
+	switch properties["indent_style"] {
+	case "tab":
+		indent.Tabs, configured = true, true
+	case "space":
+		indent.Tabs, configured = false, true
+	}
+	if size, err := strconv.Atoi(properties["indent_size"]); err == nil && size > 0 {
+		indent.Size, configured = size, true
+	}
+	if width, err := strconv.Atoi(properties["tab_width"]); err == nil && width > 0 {
+		indent.TabWidth, configured = width, true
+	}
+	if configured {
+		s.indent = &indent
+	}
+	return s
+}
+
+// decode returns the UTF-8 text of data in the charset, or in the one of its
+// byte order mark when charset is empty, and the charset of the data.
+func decode(data []byte, charset string) ([]byte, string, error) {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return data[len(utf8BOM):], utf8BOMCharset, nil
+	case bytes.HasPrefix(data, utf16BEBOM) && (charset == "" || charset == utf16BECharset):
+		return decodeUTF16(data[len(utf16BEBOM):], true), utf16BECharset, nil
+	case bytes.HasPrefix(data, utf16LEBOM) && (charset == "" || charset == utf16LECharset):
+		return decodeUTF16(data[len(utf16LEBOM):], false), utf16LECharset, nil
+	case charset == utf16BECharset || charset == utf16LECharset:
+		return decodeUTF16(data, charset == utf16BECharset), charset, nil
+	case charset == latin1Charset:
+		text := make([]rune, len(data))
+		for i, b := range data {
+			text[i] = rune(b)
+		}
+		return []byte(string(text)), charset, nil
+	}
+	return data, utf8Charset, nil
+}
+
+func decodeUTF16(data []byte, bigEndian bool) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// encode returns text in the charset, the UTF-16 ones start with a byte
+// order mark.
+func encode(text string, charset string) ([]byte, error) {
+	switch charset {
+	case utf8BOMCharset:
+		return append(append([]byte{}, utf8BOM...), text...), nil
+	case latin1Charset:
+		data := make([]byte, 0, len(text))
+		for _, r := range text {
+			if r > 0xff {
+				return nil, fmt.Errorf("%q can't be written in %s", r, charset)
+			}
+			data = append(data, byte(r))
+		}
+		return data, nil
+	case utf16BECharset, utf16LECharset:
+		bigEndian := charset == utf16BECharset
+		data := utf16LEBOM
+		if bigEndian {
+			data = utf16BEBOM
+		}
+		data = append([]byte{}, data...)
+		for _, unit := range utf16.Encode([]rune(text)) {
+			if bigEndian {
+				data = append(data, byte(unit>>8), byte(unit))
+			} else {
+				data = append(data, byte(unit), byte(unit>>8))
+			}
+		}
+		return data, nil
+	}
+	return []byte(text), nil
+}
+
+// detectNewline returns the line ending of the first row of data.
+func detectNewline(data []byte) string {
+	if i := bytes.IndexByte(data, '\n'); i > 0 && data[i-1] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// TrimTrailingWhitespace removes the spaces and tabs at the end of the rows.
+func (d *Document) TrimTrailingWhitespace() {
+	d.BeginGroup()
+	defer d.EndGroup()
+
+	for row, line := range d.Rows {
+		end := len(line)
+		for end > 0 && (line[end-1] == ' ' || line[end-1] == '\t') {
+			end--
+		}
+		if end < len(line) {
+			d.Replace(Pos{Row: row, Col: end}, Pos{Row: row, Col: len(line)}, "")
+		}
+	}
+}
+
+// encode returns the content of the file of the document with the settings.
+func (d *Document) encode(s settings) ([]byte, error) {
+	text := string(d.Bytes())
+	newline := d.newline
+	if s.newline != "" {
+		newline = s.newline
+	}
+	if newline != "\n" {
+		text = strings.ReplaceAll(text, "\n", newline)
+	}
+
+	charset := d.charset
+	if s.charset != "" {
+		charset = s.charset
+	}
+	return encode(text, charset)
+}
@@ -0,0 +1,76 @@
+package views
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fzdwx/ge/internal/editorconfig"
+	"github.com/fzdwx/ge/internal/syntax"
+)
+
+func TestDocument_EditorConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := "root = true\n[*.txt]\nindent_style = space\nindent_size = 3\nend_of_line = crlf\ncharset = utf-8-bom\ntrim_trailing_whitespace = true\ninsert_final_newline = false\n"
+	if err := os.WriteFile(filepath.Join(dir, editorconfig.Filename), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(filename, []byte("a  \nb\t\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDocument()
+	if err := d.Load(filename); err != nil {
+		t.Fatal(err)
+	}
+	if indent := d.Indent(); indent != (syntax.Indent{Size: 3, TabWidth: 3}) {
+		t.Fatalf("unexpected %+v", indent)
+	}
+
+	if err := d.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "\xef\xbb\xbfa\r\nb" {
+		t.Fatalf("unexpected %q", data)
+	}
+	if d.String() != "a\nb" || d.Modified() {
+		t.Fatalf("unexpected %q", d.String())
+	}
+
+	// a file without settings keeps its line ending.
+	other := filepath.Join(dir, "b.md")
+	if err := os.WriteFile(other, []byte("x  \r\ny\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Load(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(other); string(data) != "x  \r\ny\r\n" {
+		t.Fatalf("unexpected %q", data)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, charset := range []string{utf8Charset, utf8BOMCharset, latin1Charset, utf16BECharset, utf16LECharset} {
+		data, err := encode("héllo\n", charset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, got, err := decode(data, charset)
+		if err != nil || string(text) != "héllo\n" || got != charset {
+			t.Errorf("%s: unexpected %q %s %v", charset, text, got, err)
+		}
+	}
+
+	if _, err := encode("我", latin1Charset); err == nil {
+		t.Error("expected an error")
+	}
+	if text, charset, _ := decode([]byte{0xff, 0xfe, 'a', 0}, ""); string(text) != "a" || charset != utf16LECharset {
+		t.Errorf("unexpected %q %s", text, charset)
+	}
+}
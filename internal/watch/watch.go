@@ -0,0 +1,146 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultInterval the interval of the polling Watcher.
+const DefaultInterval = time.Second
+
+type (
+	// Event reports that a watched file has been written, replaced or removed.
+	Event struct {
+		Filename string
+	}
+
+	// Watcher watches files for changes made by other processes.
+	Watcher interface {
+		// Add starts watching filename.
+		Add(filename string) error
+		// Remove stops watching filename.
+		Remove(filename string)
+		// Events returns the channel the changes are delivered on.
+		Events() <-chan Event
+		// Close stops the watcher and closes the Events channel.
+		Close() error
+	}
+)
+
+// New returns the native watcher of the platform (inotify on Linux), or a
+// polling watcher when it is not available.
+func New() Watcher {
+	if w, err := newNative(); err == nil {
+		return w
+	}
+	return NewPoller(DefaultInterval)
+}
+
+// abs returns the cleaned absolute path of filename, used as the key of the
+// watched files.
+func abs(filename string) string {
+	if path, err := filepath.Abs(filename); err == nil {
+		return path
+	}
+	return filepath.Clean(filename)
+}
+
+type (
+	poller struct {
+		mu     sync.Mutex
+		files  map[string]stat
+		events chan Event
+		done   chan struct{}
+		once   sync.Once
+	}
+
+	stat struct {
+		modTime time.Time
+		size    int64
+		exists  bool
+	}
+)
+
+// NewPoller returns a Watcher that checks the modification time and size of
+// the files every interval, it works on every platform and filesystem.
+func NewPoller(interval time.Duration) Watcher {
+	p := &poller{
+		files:  map[string]stat{},
+		events: make(chan Event),
+		done:   make(chan struct{}),
+	}
+	go p.loop(interval)
+	return p
+}
+
+func statOf(filename string) stat {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return stat{}
+	}
+	return stat{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
+
+func (p *poller) Add(filename string) error {
+	filename = abs(filename)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files[filename] = statOf(filename)
+	return nil
+}
+
+func (p *poller) Remove(filename string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.files, abs(filename))
+}
+
+func (p *poller) Events() <-chan Event {
+	return p.events
+}
+
+func (p *poller) Close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+func (p *poller) loop(interval time.Duration) {
+	defer close(p.events)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+
+		for _, filename := range p.poll() {
+			select {
+			case p.events <- Event{Filename: filename}:
+			case <-p.done:
+				return
+			}
+		}
+	}
+}
+
+// poll returns the files whose stat has changed since the last poll.
+func (p *poller) poll() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var changed []string
+	for filename, old := range p.files {
+		if current := statOf(filename); current != old {
+			p.files[filename] = current
+			changed = append(changed, filename)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,174 @@
+//go:build linux
+
+package watch
+
+import (
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// inotifyMask the events of a directory that may change a file in it,
+	// CLOSE_WRITE and MOVED_TO cover both in place writes and the
+	// write-then-rename of formatters and git.
+	inotifyMask = unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_MOVED_FROM | unix.IN_CREATE | unix.IN_DELETE
+	// pollTimeout how long a poll of the inotify fd blocks, in milliseconds,
+	// which bounds the time Close waits for the reading goroutine.
+	pollTimeout = 200
+)
+
+// inotify watches the directories of the files, because files replaced by a
+// rename would not be reported by a watch on the file itself.
+type inotify struct {
+	fd     int
+	mu     sync.Mutex
+	dirs   map[string]int
+	wds    map[int]string
+	files  map[string]bool
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newNative() (Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &inotify{
+		fd:     fd,
+		dirs:   map[string]int{},
+		wds:    map[int]string{},
+		files:  map[string]bool{},
+		events: make(chan Event),
+		done:   make(chan struct{}),
+	}
+	go w.loop()
+	return w, nil
+}
+
+func (w *inotify) Add(filename string) error {
+	filename = abs(filename)
+	dir := filepath.Dir(filename)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.dirs[dir]; !ok {
+		wd, err := unix.InotifyAddWatch(w.fd, dir, inotifyMask)
+		if err != nil {
+			return err
+		}
+		w.dirs[dir] = wd
+		w.wds[wd] = dir
+	}
+	w.files[filename] = true
+	return nil
+}
+
+func (w *inotify) Remove(filename string) {
+	filename = abs(filename)
+	dir := filepath.Dir(filename)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.files, filename)
+
+	// the directory is watched as long as one of its files is.
+	for f := range w.files {
+		if filepath.Dir(f) == dir {
+			return
+		}
+	}
+	if wd, ok := w.dirs[dir]; ok {
+		_, _ = unix.InotifyRmWatch(w.fd, uint32(wd))
+		delete(w.dirs, dir)
+		delete(w.wds, wd)
+	}
+}
+
+func (w *inotify) Events() <-chan Event {
+	return w.events
+}
+
+func (w *inotify) Close() error {
+	w.once.Do(func() { close(w.done) })
+	return nil
+}
+
+func (w *inotify) loop() {
+	defer close(w.events)
+	defer unix.Close(w.fd)
+
+	var (
+		buf = make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+		fds = []unix.PollFd{{Fd: int32(w.fd), Events: unix.POLLIN}}
+	)
+	for {
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+
+		n, err := unix.Poll(fds, pollTimeout)
+		if err != nil && err != unix.EINTR {
+			return
+		}
+		if n <= 0 {
+			continue
+		}
+
+		n, err = unix.Read(w.fd, buf)
+		if err != nil {
+			if err == unix.EAGAIN || err == unix.EINTR {
+				continue
+			}
+			return
+		}
+
+		for _, filename := range w.parse(buf[:n]) {
+			select {
+			case w.events <- Event{Filename: filename}:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// parse returns the watched files that the events in buf are about.
+func (w *inotify) parse(buf []byte) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var (
+		filenames []string
+		seen      = map[string]bool{}
+	)
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(buf); {
+		event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + unix.SizeofInotifyEvent
+		nameEnd := nameStart + int(event.Len)
+		offset = nameEnd
+		if event.Len <= 0 || nameEnd > len(buf) {
+			continue
+		}
+
+		name := string(buf[nameStart:nameEnd])
+		for len(name) > 0 && name[len(name)-1] == 0 {
+			name = name[:len(name)-1]
+		}
+
+		filename := filepath.Join(w.wds[int(event.Wd)], name)
+		if w.files[filename] && !seen[filename] {
+			seen[filename] = true
+			filenames = append(filenames, filename)
+		}
+	}
+	return filenames
+}
@@ -0,0 +1,38 @@
+//go:build linux
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInotify_Remove(t *testing.T) {
+	native, err := newNative()
+	if err != nil {
+		t.Skip(err)
+	}
+	w := native.(*inotify)
+	defer w.Close()
+
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	for _, filename := range []string{a, b} {
+		if err := os.WriteFile(filename, []byte("a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Add(filename); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w.Remove(a)
+	if len(w.dirs) != 1 {
+		t.Fatalf("dirs = %v, want the directory of b", w.dirs)
+	}
+	w.Remove(b)
+	if len(w.dirs) != 0 || len(w.wds) != 0 {
+		t.Errorf("dirs = %v, wds = %v, want no watches", w.dirs, w.wds)
+	}
+}
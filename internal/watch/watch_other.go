@@ -0,0 +1,9 @@
+//go:build !linux
+
+package watch
+
+import "errors"
+
+func newNative() (Watcher, error) {
+	return nil, errors.New("no native file watcher on this platform")
+}
@@ -0,0 +1,47 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testWatcher(t *testing.T, w Watcher) {
+	defer w.Close()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(filename, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	// replace the file by a rename, like formatters do.
+	tmp := filepath.Join(dir, "a.txt.tmp")
+	if err := os.WriteFile(tmp, []byte("bb"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-w.Events():
+		if event.Filename != filename {
+			t.Fatalf("unexpected event %v", event)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no event")
+	}
+}
+
+func TestNew(t *testing.T) {
+	testWatcher(t, New())
+}
+
+func TestNewPoller(t *testing.T) {
+	testWatcher(t, NewPoller(50*time.Millisecond))
+}
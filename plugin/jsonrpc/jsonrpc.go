@@ -0,0 +1,225 @@
+// Package jsonrpc implements a JSON-RPC 2.0 connection over a stream with
+// one message per line, used by ge and its plugins.
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxMessageSize the size of the largest message, a document sent as a
+// whole has to fit.
+const maxMessageSize = 64 << 20
+
+// ErrClosed is returned by calls on a closed connection.
+var ErrClosed = errors.New("jsonrpc: connection closed")
+
+// Message is a request, a notification (no ID) or a response.
+type Message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *int64           `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  *json.RawMessage `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+}
+
+// Error is the error of a response.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Error codes of JSON-RPC.
+const (
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Handler handles the requests and notifications of the other side, the
+// result is ignored for notifications. Each request is handled in its own
+// goroutine, the notifications are handled one after the other in the order
+// they were sent, handlers can make calls themselves.
+type Handler func(method string, params json.RawMessage) (interface{}, error)
+
+// Conn is a connection to the other side.
+type Conn struct {
+	r       io.Reader
+	handler Handler
+
+	wmu sync.Mutex
+	w   io.Writer
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *Message
+	closed  bool
+}
+
+// NewConn creates a connection reading from r and writing to w, Run has to
+// be called to receive messages.
+func NewConn(r io.Reader, w io.Writer, handler Handler) *Conn {
+	return &Conn{r: r, w: w, handler: handler, pending: map[int64]chan *Message{}}
+}
+
+// Run reads the messages until r ends, the pending calls fail afterwards.
+func (c *Conn) Run() error {
+	defer c.close()
+
+	notifications := make(chan *Message, 64)
+	defer close(notifications)
+	go func() {
+		for msg := range notifications {
+			c.handle(msg)
+		}
+	}()
+
+	scanner := bufio.NewScanner(c.r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return fmt.Errorf("jsonrpc: invalid message: %w", err)
+		}
+
+		switch {
+		case msg.Method == "":
+			c.resolve(&msg)
+		case msg.ID == nil:
+			notifications <- &msg
+		default:
+			go c.handle(&msg)
+		}
+	}
+	return scanner.Err()
+}
+
+func (c *Conn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+func (c *Conn) resolve(msg *Message) {
+	if msg.ID == nil {
+		return
+	}
+
+	c.mu.Lock()
+	ch, ok := c.pending[*msg.ID]
+	delete(c.pending, *msg.ID)
+	c.mu.Unlock()
+
+	if ok {
+		ch <- msg
+	}
+}
+
+func (c *Conn) handle(msg *Message) {
+	result, err := c.handler(msg.Method, msg.Params)
+	if msg.ID == nil {
+		return
+	}
+
+	response := &Message{ID: msg.ID}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		response.Error = rpcErr
+	} else {
+		data, err := json.Marshal(result)
+		if err != nil {
+			response.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+		} else {
+			raw := json.RawMessage(data)
+			response.Result = &raw
+		}
+	}
+	_ = c.write(response)
+}
+
+// Call sends a request and waits for its response, which is decoded into
+// result unless it is nil.
+func (c *Conn) Call(method string, params, result interface{}) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *Message, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.send(&id, method, params); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return err
+	}
+
+	response, ok := <-ch
+	if !ok {
+		return ErrClosed
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil || response.Result == nil {
+		return nil
+	}
+	return json.Unmarshal(*response.Result, result)
+}
+
+// Notify sends a notification.
+func (c *Conn) Notify(method string, params interface{}) error {
+	return c.send(nil, method, params)
+}
+
+func (c *Conn) send(id *int64, method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(&Message{ID: id, Method: method, Params: data})
+}
+
+func (c *Conn) write(msg *Message) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err = c.w.Write(append(data, '\n'))
+	return err
+}
+
+// MethodNotFound returns the error for an unknown method.
+func MethodNotFound(method string) error {
+	return &Error{Code: CodeMethodNotFound, Message: "method not found: " + method}
+}
+
+// InvalidParams returns the error for params that can't be decoded.
+func InvalidParams(err error) error {
+	return &Error{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+}
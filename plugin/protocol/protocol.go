@@ -0,0 +1,132 @@
+// Package protocol defines the messages exchanged between ge and the
+// plugins it runs as separate processes.
+//
+// A plugin is an executable in the rpc-plugins directory of the config
+// directory, ge starts it and talks JSON-RPC 2.0 over its stdin and stdout,
+// one JSON object per line. Its stderr is logged.
+//
+// ge first sends the initialize request, the plugin answers with the
+// commands it provides and the events it wants. Afterwards ge sends
+// command/run requests and document/didChange and document/didSave
+// notifications, while the plugin may request document/get and
+// document/applyEdits and notify window/showMessage at any time. ge sends
+// the shutdown notification before it exits.
+package protocol
+
+// Version is the version of the protocol, ge only runs plugins speaking the
+// same version.
+const Version = 1
+
+// Methods sent by ge.
+const (
+	MethodInitialize = "initialize"
+	MethodRunCommand = "command/run"
+	MethodDidChange  = "document/didChange"
+	MethodDidSave    = "document/didSave"
+	MethodShutdown   = "shutdown"
+)
+
+// Methods sent by the plugins.
+const (
+	MethodGetDocument = "document/get"
+	MethodApplyEdits  = "document/applyEdits"
+	MethodShowMessage = "window/showMessage"
+)
+
+// Events a plugin can subscribe to.
+const (
+	EventChange = "change"
+	EventSave   = "save"
+)
+
+// Position is a position in a document, rows and columns are counted from 0
+// and columns in runes.
+type Position struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// Edit replaces the text between From and To.
+type Edit struct {
+	From Position `json:"from"`
+	To   Position `json:"to"`
+	Text string   `json:"text"`
+}
+
+// InitializeParams are the params of the initialize request.
+type InitializeParams struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	Editor          string `json:"editor"`
+}
+
+// InitializeResult is the answer of a plugin to the initialize request.
+type InitializeResult struct {
+	Name            string        `json:"name"`
+	ProtocolVersion int           `json:"protocolVersion"`
+	Commands        []CommandInfo `json:"commands,omitempty"`
+	Events          []string      `json:"events,omitempty"`
+}
+
+// CommandInfo describes a command provided by a plugin.
+type CommandInfo struct {
+	Name string `json:"name"`
+	Help string `json:"help,omitempty"`
+}
+
+// RunCommandParams are the params of the command/run request, it is answered
+// once the command is done.
+type RunCommandParams struct {
+	Name     string   `json:"name"`
+	Arg      string   `json:"arg,omitempty"`
+	Filename string   `json:"filename"`
+	Version  int      `json:"version"`
+	Cursor   Position `json:"cursor"`
+}
+
+// DidChangeParams are the params of the document/didChange notification,
+// the text between From and To of the previous version was replaced by Text.
+type DidChangeParams struct {
+	Filename string `json:"filename"`
+	Version  int    `json:"version"`
+	Edit     Edit   `json:"edit"`
+}
+
+// DidSaveParams are the params of the document/didSave notification.
+type DidSaveParams struct {
+	Filename string `json:"filename"`
+}
+
+// GetDocumentParams are the params of the document/get request, an empty
+// Filename is the current document.
+type GetDocumentParams struct {
+	Filename string `json:"filename,omitempty"`
+}
+
+// GetDocumentResult is the content of a document.
+type GetDocumentResult struct {
+	Filename string `json:"filename"`
+	Version  int    `json:"version"`
+	Text     string `json:"text"`
+}
+
+// ApplyEditsParams are the params of the document/applyEdits request. The
+// edits refer to the positions before any of them is applied and must not
+// overlap. When Version is not 0 and the document changed since then, the
+// edits are rejected.
+type ApplyEditsParams struct {
+	Filename string `json:"filename,omitempty"`
+	Version  int    `json:"version,omitempty"`
+	Edits    []Edit `json:"edits"`
+}
+
+// ApplyEditsResult tells whether the edits were applied.
+type ApplyEditsResult struct {
+	Applied bool   `json:"applied"`
+	Version int    `json:"version"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ShowMessageParams are the params of the window/showMessage notification.
+type ShowMessageParams struct {
+	Text string `json:"text"`
+}
@@ -0,0 +1,172 @@
+// Package sdk helps writing plugins for ge in Go, see package protocol for
+// the protocol they speak.
+//
+//	func main() {
+//		p := sdk.New("sort")
+//		p.Command("sort-lines", "sort the lines of the document", func(e *sdk.Editor, params protocol.RunCommandParams) error {
+//			doc, err := e.Document(params.Filename)
+//			...
+//			_, err = e.ApplyEdits(doc.Filename, doc.Version, edits...)
+//			return err
+//		})
+//		if err := p.Run(); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/fzdwx/ge/plugin/jsonrpc"
+	"github.com/fzdwx/ge/plugin/protocol"
+)
+
+// CommandFunc runs a command of the plugin.
+type CommandFunc func(e *Editor, params protocol.RunCommandParams) error
+
+// Plugin is a plugin with its commands and event handlers.
+type Plugin struct {
+	name     string
+	commands map[string]CommandFunc
+	infos    []protocol.CommandInfo
+	onChange func(e *Editor, params protocol.DidChangeParams)
+	onSave   func(e *Editor, params protocol.DidSaveParams)
+}
+
+// New creates a plugin named name.
+func New(name string) *Plugin {
+	return &Plugin{name: name, commands: map[string]CommandFunc{}}
+}
+
+// Command adds a command to the editor, it is run by the editor like its
+// builtin commands.
+func (p *Plugin) Command(name, help string, run CommandFunc) {
+	p.commands[name] = run
+	p.infos = append(p.infos, protocol.CommandInfo{Name: name, Help: help})
+}
+
+// OnChange subscribes to the changes of the documents.
+func (p *Plugin) OnChange(f func(e *Editor, params protocol.DidChangeParams)) {
+	p.onChange = f
+}
+
+// OnSave subscribes to the saves of the documents.
+func (p *Plugin) OnSave(f func(e *Editor, params protocol.DidSaveParams)) {
+	p.onSave = f
+}
+
+// Run serves the editor over stdin and stdout until it shuts the plugin
+// down.
+func (p *Plugin) Run() error {
+	return p.Serve(os.Stdin, os.Stdout)
+}
+
+// Serve serves the editor over r and w until it shuts the plugin down or r
+// ends.
+func (p *Plugin) Serve(r io.Reader, w io.Writer) error {
+	var (
+		editor   = &Editor{}
+		shutdown = make(chan struct{})
+		once     sync.Once
+	)
+
+	editor.conn = jsonrpc.NewConn(r, w, func(method string, params json.RawMessage) (interface{}, error) {
+		switch method {
+		case protocol.MethodInitialize:
+			return p.initialize(params)
+		case protocol.MethodRunCommand:
+			var run protocol.RunCommandParams
+			if err := json.Unmarshal(params, &run); err != nil {
+				return nil, jsonrpc.InvalidParams(err)
+			}
+			command, ok := p.commands[run.Name]
+			if !ok {
+				return nil, fmt.Errorf("unknown command: %s", run.Name)
+			}
+			return nil, command(editor, run)
+		case protocol.MethodDidChange:
+			var change protocol.DidChangeParams
+			if err := json.Unmarshal(params, &change); err == nil && p.onChange != nil {
+				p.onChange(editor, change)
+			}
+			return nil, nil
+		case protocol.MethodDidSave:
+			var save protocol.DidSaveParams
+			if err := json.Unmarshal(params, &save); err == nil && p.onSave != nil {
+				p.onSave(editor, save)
+			}
+			return nil, nil
+		case protocol.MethodShutdown:
+			once.Do(func() { close(shutdown) })
+			return nil, nil
+		}
+		return nil, jsonrpc.MethodNotFound(method)
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- editor.conn.Run()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-shutdown:
+		return nil
+	}
+}
+
+func (p *Plugin) initialize(params json.RawMessage) (interface{}, error) {
+	var init protocol.InitializeParams
+	if err := json.Unmarshal(params, &init); err != nil {
+		return nil, jsonrpc.InvalidParams(err)
+	}
+
+	result := protocol.InitializeResult{
+		Name:            p.name,
+		ProtocolVersion: protocol.Version,
+		Commands:        p.infos,
+	}
+	if p.onChange != nil {
+		result.Events = append(result.Events, protocol.EventChange)
+	}
+	if p.onSave != nil {
+		result.Events = append(result.Events, protocol.EventSave)
+	}
+	return result, nil
+}
+
+// Editor is the editor running the plugin.
+type Editor struct {
+	conn *jsonrpc.Conn
+}
+
+// Document returns the content of the document of filename, or of the
+// current document if filename is empty.
+func (e *Editor) Document(filename string) (protocol.GetDocumentResult, error) {
+	var result protocol.GetDocumentResult
+	err := e.conn.Call(protocol.MethodGetDocument, protocol.GetDocumentParams{Filename: filename}, &result)
+	return result, err
+}
+
+// ApplyEdits applies the edits to the document of filename, they are
+// rejected if version is not 0 and the document changed since then.
+func (e *Editor) ApplyEdits(filename string, version int, edits ...protocol.Edit) (protocol.ApplyEditsResult, error) {
+	var result protocol.ApplyEditsResult
+	err := e.conn.Call(protocol.MethodApplyEdits, protocol.ApplyEditsParams{
+		Filename: filename,
+		Version:  version,
+		Edits:    edits,
+	}, &result)
+	return result, err
+}
+
+// Message shows text in the status line of the editor.
+func (e *Editor) Message(text string) error {
+	return e.conn.Notify(protocol.MethodShowMessage, protocol.ShowMessageParams{Text: text})
+}
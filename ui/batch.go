@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/config"
+	"github.com/fzdwx/ge/internal/clipboard"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+const (
+	// batchWidth and batchHeight the size of the window the commands of a
+	// Batch see, it matters to the keys moving by screen lines.
+	batchWidth  = 80
+	batchHeight = 24
+
+	// batchTimeout how long a command may run, e.g. the process of
+	// filter-region, the commands waiting for events need the editor.
+	batchTimeout = time.Minute
+)
+
+// Batch runs command lines on files without a terminal, see `ge exec`. The
+// commands and the keys of the macros go through the same Ui as in the
+// editor.
+type Batch struct {
+	u *Ui
+}
+
+// NewBatch creates a Batch, the saved macros are loaded so that they can be
+// played.
+func NewBatch() (*Batch, error) {
+	u := New(config.New(nil))
+	// the clipboard of a batch must not write to the terminal, see
+	// clipboard.OSC52.
+	u.textarea.Registers = NewRegisters(&clipboard.Memory{})
+	// nothing is shown, the commands settle without blinks.
+	u.stillCursors()
+	// the files are saved by the caller once all commands succeeded.
+	u.RegisterCommand(Command{
+		Name: "save",
+		Help: "not available in batch mode",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.fail(errors.New("save is not available in batch mode, the files are saved once all commands succeeded"))
+			return nil
+		},
+	})
+
+	if err := u.loadMacros(""); err != nil {
+		return nil, err
+	}
+	return &Batch{u: u}, nil
+}
+
+// Run loads filename and runs the command lines on it, see RunDocument. The
+// edited document is returned, it isn't saved.
+func (b *Batch) Run(filename string, lines []string) (*views.Document, error) {
+	document, err := views.LoadDocument(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.RunDocument(document, lines); err != nil {
+		return document, fmt.Errorf("%s: %w", filename, err)
+	}
+	return document, nil
+}
+
+// RunDocument runs the command lines on document, it stops at the first
+// command that fails. The commands of a line run to their end before the next
+// line.
+func (b *Batch) RunDocument(document *views.Document, lines []string) error {
+	u := b.u
+	u.document = document
+	u.textarea.SetDocument(document)
+	u.width, u.height = batchWidth, batchHeight
+	u.layout()
+
+	for i, line := range lines {
+		u.failed = nil
+		if cmd := u.settle(u.Execute(line), batchTimeout); cmd != nil && u.failed == nil {
+			u.failed = errors.New("the command didn't finish, it needs the editor")
+		}
+		if u.failed != nil {
+			return fmt.Errorf("command %d, %s: %w", i+1, line, u.failed)
+		}
+	}
+	return nil
+}
+
+// Close releases the resources of the batch.
+func (b *Batch) Close() error {
+	b.u.Output.Close()
+	return b.u.watcher.Close()
+}
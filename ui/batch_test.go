@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fzdwx/ge/internal/macro"
+)
+
+func newTestBatch(t *testing.T) *Batch {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CACHE_HOME", home)
+
+	b, err := NewBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+func TestBatch_Run(t *testing.T) {
+	b := newTestBatch(t)
+	b.u.textarea.Registers.Clipboard.Write("X")
+	b.u.macros.saved = map[string]macro.Macro{
+		"p": {macro.ParseKey("ctrl+v"), macro.ParseKey("-")},
+	}
+
+	filename := writeFile(t, "a.txt", "one\ntwo\n")
+	document, err := b.Run(filename, []string{"filter-region tr a-z A-Z", "play-macro p 2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := document.String(); got != "X-X-ONE\nTWO" {
+		t.Errorf("Run() = %q", got)
+	}
+}
+
+func TestBatch_Run_Failed(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"unknown", "frobnicate", "unknown command"},
+		{"filter", "filter-region exit 3", "exit status 3"},
+		{"search", "search-in-files (", "missing closing )"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBatch(t)
+			filename := writeFile(t, "a.txt", "one\n")
+			document, err := b.Run(filename, []string{tt.line, "insert x"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Run() = %v, want %q", err, tt.want)
+			}
+			if document != nil && document.String() != "one" {
+				t.Errorf("Run() ran the next command, %q", document.String())
+			}
+		})
+	}
+}
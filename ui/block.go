@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// StartBlock starts a rectangular selection at the cursor, it ends with
+// Cancel or any key that isn't a movement or a block operation.
+func (m *Textarea) StartBlock() {
+	m.ClearSelection()
+	m.ClearCursors()
+	m.block = true
+	m.blockRow, m.blockCol = m.row, m.displayCol()
+}
+
+// ClearBlock ends the rectangular selection.
+func (m *Textarea) ClearBlock() {
+	m.block = false
+}
+
+// Block returns the rectangular selection, ok is false when there is none.
+func (m *Textarea) Block() (b views.Block, ok bool) {
+	if !m.block {
+		return views.Block{}, false
+	}
+	return views.NewBlock(m.blockRow, m.blockCol, m.row, m.displayCol()), true
+}
+
+// displayCol returns the display column of the cursor, it is kept while
+// moving vertically through shorter rows.
+func (m *Textarea) displayCol() int {
+	return max(m.lastCharOffset, m.document.Row(m.row).Width(m.col, m.tabWidth()))
+}
+
+// setDisplayCol moves the cursor to the display column width of its row.
+func (m *Textarea) setDisplayCol(width int) {
+	m.SetCursor(m.document.Row(m.row).ColAt(width, m.tabWidth()))
+	m.lastCharOffset = width
+}
+
+// handleBlockKey handles the keys while a rectangular selection is active.
+func (m *Textarea) handleBlockKey(msg tea.KeyMsg) tea.Cmd {
+	b, _ := m.Block()
+	switch {
+	case key.Matches(msg, m.KeyMap.Cancel):
+		m.ClearBlock()
+	case key.Matches(msg, m.KeyMap.Copy):
+		m.ClearBlock()
+		return m.yankBlock(b)
+	case key.Matches(msg, m.KeyMap.Cut):
+		cmd := m.yankBlock(b)
+		m.deleteBlock(b)
+		m.ClearBlock()
+		return cmd
+	case key.Matches(msg, m.KeyMap.Paste):
+		// the block is replaced once the clipboard is read.
+		return m.Paste
+	case key.Matches(msg, m.KeyMap.DeleteCharacterBackward):
+		if b.Left == b.Right && b.Left > 0 {
+			// a block of zero width deletes the rune before it.
+			row := m.document.Row(m.row)
+			if col := row.ColAt(b.Left, m.tabWidth()); col > 0 {
+				b.Left = row.Width(col-1, m.tabWidth())
+			} else {
+				b.Left--
+			}
+		}
+		m.deleteBlock(b)
+	case key.Matches(msg, m.KeyMap.DeleteCharacterForward):
+		if b.Left == b.Right {
+			b.Right = b.Left + 1
+		}
+		m.deleteBlock(b)
+	case key.Matches(msg, m.KeyMap.SelectLeft, m.KeyMap.MoveLeft):
+		m.moveLeft()
+	case key.Matches(msg, m.KeyMap.SelectRight, m.KeyMap.MoveRight):
+		m.moveRight()
+	case key.Matches(msg, m.KeyMap.SelectUp, m.KeyMap.MoveUp):
+		m.MoveUp()
+	case key.Matches(msg, m.KeyMap.SelectDown, m.KeyMap.MoveDown):
+		m.MoveDown()
+	case m.isMovement(msg):
+		return m.handleKey(msg)
+	case !msg.Alt && (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace):
+		m.insertBlock(b, string(msg.Runes))
+	default:
+		m.ClearBlock()
+		return m.handleKey(msg)
+	}
+	return nil
+}
+
+// yankBlock copies the text of the block, it is pasted as a block again.
+func (m *Textarea) yankBlock(b views.Block) tea.Cmd {
+	m.blockYank = m.document.Rows.BlockText(b, m.tabWidth())
+	return m.Copy(strings.Join(m.blockYank, "\n"))
+}
+
+// deleteBlock deletes the text of the block, which keeps its rows with a
+// width of zero.
+func (m *Textarea) deleteBlock(b views.Block) {
+	m.document.DeleteBlock(b)
+	m.blockCol = b.Left
+	m.setDisplayCol(b.Left)
+}
+
+// insertBlock replaces the block with s in each of its rows, rows ending
+// before the block are left alone.
+func (m *Textarea) insertBlock(b views.Block, s string) {
+	m.document.BeginGroup()
+	defer m.document.EndGroup()
+
+	if b.Left < b.Right {
+		m.document.DeleteBlock(b)
+	}
+
+	for row := b.Top; row <= b.Bottom; row++ {
+		if m.document.Row(row).TotalRuneWidth(m.tabWidth()) >= b.Left {
+			m.document.InsertBlock(row, b.Left, []string{s})
+		}
+	}
+
+	width := b.Left + views.Row(s).TotalRuneWidth(m.tabWidth())
+	m.blockCol = width
+	m.setDisplayCol(width)
+}
+
+// isBlockYank reports whether text was copied from a block.
+func (m *Textarea) isBlockYank(text string) bool {
+	return m.blockYank != nil && text == strings.Join(m.blockYank, "\n")
+}
+
+// pasteBlock inserts the lines of text as a block at the cursor, replacing
+// the rectangular selection.
+func (m *Textarea) pasteBlock(text string) {
+	m.document.BeginGroup()
+	defer m.document.EndGroup()
+
+	row, width := m.row, m.displayCol()
+	if b, ok := m.Block(); ok {
+		m.document.DeleteBlock(b)
+		row, width = b.Top, b.Left
+	}
+	m.ClearBlock()
+
+	m.document.InsertBlock(row, width, strings.Split(text, "\n"))
+	m.row = row
+	m.setDisplayCol(width)
+}
+
+// blockSpans adds the spans of the block, or carets for a block of zero width.
+func (m *Textarea) blockSpans(b views.Block, carets map[int][]int, spans map[int][]span) {
+	for row := b.Top; row <= b.Bottom && row < m.document.Height(); row++ {
+		from, to := b.Cols(m.document.Row(row), m.tabWidth())
+		switch {
+		case from < to:
+			spans[row] = append(spans[row], span{start: from, end: to})
+		case row != m.row && m.document.Row(row).TotalRuneWidth(m.tabWidth()) >= b.Left:
+			carets[row] = append(carets[row], from)
+		}
+	}
+}
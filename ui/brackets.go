@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"unicode"
+
+	"github.com/fzdwx/ge/internal/syntax"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// classCache the classes of the runes of a revision of a document, see
+// syntax.Classify.
+type classCache struct {
+	document *views.Document
+	revision int
+	typ      string
+	classes  [][]syntax.Class
+}
+
+// lexer returns the lexer of the document.
+func (m *Textarea) lexer() syntax.Lexer {
+	return syntax.LexerOf(m.document.Type())
+}
+
+// classes returns the classes of the runes of the document, they are kept
+// until the document changes.
+func (m *Textarea) classes() [][]syntax.Class {
+	c := &m.classCache
+	if c.document != m.document || c.revision != m.document.Revision() || c.typ != m.document.Type() {
+		*c = classCache{
+			document: m.document,
+			revision: m.document.Revision(),
+			typ:      m.document.Type(),
+			classes:  syntax.Classify(m.lexer(), m.document.Rows),
+		}
+	}
+	return c.classes
+}
+
+// classAt returns the class of a rune typed at the cursor.
+func (m *Textarea) classAt() syntax.Class {
+	rows := make([]views.Row, m.row+1)
+	copy(rows, m.document.Rows[:m.row])
+	rows[m.row] = append(append(views.Row{}, m.document.Row(m.row)[:m.col]...), 'x')
+	return syntax.Classify(m.lexer(), rows)[m.row][m.col]
+}
+
+// matchingBracket returns the bracket under the cursor, or the one before it,
+// and its match.
+func (m *Textarea) matchingBracket() (views.Pos, views.Pos, bool) {
+	classes := m.classes()
+	for _, col := range []int{m.col, m.col - 1} {
+		if row, match, ok := syntax.Match(m.document.Rows, classes, m.row, col); ok {
+			return views.Pos{Row: m.row, Col: col}, views.Pos{Row: row, Col: match}, true
+		}
+	}
+	return views.Pos{}, views.Pos{}, false
+}
+
+// bracketHighlights returns the columns of the highlighted brackets keyed by
+// row, the bracket at the cursor and its match.
+func (m *Textarea) bracketHighlights() map[int][]int {
+	bracket, match, ok := m.matchingBracket()
+	if !ok || !m.focus {
+		return nil
+	}
+
+	highlights := map[int][]int{match.Row: {match.Col}}
+	if bracket != m.pos() {
+		highlights[bracket.Row] = append(highlights[bracket.Row], bracket.Col)
+	}
+	return highlights
+}
+
+// JumpToMatchingBracket moves the cursor to the bracket matching the one
+// under it, or before it, false if there is none.
+func (m *Textarea) JumpToMatchingBracket() bool {
+	_, match, ok := m.matchingBracket()
+	if !ok {
+		return false
+	}
+	m.row = match.Row
+	m.SetCursor(match.Col)
+	return true
+}
+
+// insertRunes inserts the typed runes. A closing bracket or a quote before
+// the same one moves over it, an opening bracket or a quote in the code
+// inserts the closing one as well, and a closing bracket typed at the start
+// of a row removes a level of indentation.
+func (m *Textarea) insertRunes(runes []rune) {
+	if len(runes) != 1 {
+		m.InsertString(string(runes))
+		return
+	}
+
+	r, row := runes[0], m.document.Row(m.row)
+	_, closing := syntax.Opening(r)
+	quote := m.lexer().IsQuote(r)
+	if (closing || quote) && m.col < len(row) && row[m.col] == r {
+		m.SetCursor(m.col + 1)
+		return
+	}
+
+	if closing && m.col > 0 && row.Indentation() >= m.col {
+		m.outdentRow()
+	}
+
+	if pair, ok := m.pairOf(r); ok {
+		m.InsertString(string(r) + string(pair))
+		m.SetCursor(m.col - 1)
+		return
+	}
+	m.InsertString(string(r))
+}
+
+// pairOf returns the rune inserted after r to close it, ok is false unless r
+// is an opening bracket or a quote typed in the code before a space or a
+// closing rune.
+func (m *Textarea) pairOf(r rune) (rune, bool) {
+	pair, bracket := syntax.Brackets[r]
+	quote := m.lexer().IsQuote(r)
+	if !bracket && !quote {
+		return 0, false
+	}
+
+	row := m.document.Row(m.row)
+	if m.col < len(row) {
+		next := row[m.col]
+		if _, closing := syntax.Opening(next); !closing && !unicode.IsSpace(next) && next != ',' && next != ';' {
+			return 0, false
+		}
+	}
+	if quote {
+		// e.g. the apostrophe of a word.
+		if m.col > 0 && (isWordRune(row[m.col-1]) || row[m.col-1] == r) {
+			return 0, false
+		}
+		pair = r
+	}
+
+	if m.classAt() != syntax.Code {
+		return 0, false
+	}
+	return pair, true
+}
+
+// deletePair deletes an empty pair of brackets or quotes around the cursor,
+// false if the cursor isn't between one.
+func (m *Textarea) deletePair() bool {
+	row := m.document.Row(m.row)
+	if m.col <= 0 || m.col >= len(row) {
+		return false
+	}
+
+	before, after := row[m.col-1], row[m.col]
+	closing, bracket := syntax.Brackets[before]
+	if !(bracket && after == closing) && !(before == after && m.lexer().IsQuote(before)) {
+		return false
+	}
+	m.document.Replace(views.Pos{Row: m.row, Col: m.col - 1}, views.Pos{Row: m.row, Col: m.col + 1}, "")
+	m.SetCursor(m.col - 1)
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,505 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Command is a named action that can be executed from the command prompt.
+type Command struct {
+	Name string
+	Help string
+
+	// Run executes the command, arg is the text typed after the command name.
+	Run func(u *Ui, arg string) tea.Cmd
+}
+
+// RegisterCommand adds c to the commands that can be executed, a command with
+// the same name is replaced.
+func (u *Ui) RegisterCommand(c Command) {
+	u.commands[c.Name] = c
+}
+
+// Commands returns the names of all registered commands in alphabetical order.
+func (u *Ui) Commands() []string {
+	names := make([]string, 0, len(u.commands))
+	for name := range u.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Execute runs a command line, e.g. `search-in-files func main`.
+func (u *Ui) Execute(line string) tea.Cmd {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if name == "" {
+		return nil
+	}
+
+	c, ok := u.commands[name]
+	if !ok {
+		u.fail(fmt.Errorf("unknown command: %s", name))
+		return nil
+	}
+
+	// the edits of a command are undone together.
+	u.document.BeginGroup()
+	defer u.document.EndGroup()
+	return c.Run(u, strings.TrimSpace(arg))
+}
+
+func (u *Ui) registerBuiltinCommands() {
+	u.RegisterCommand(Command{
+		Name: "save",
+		Help: "save the document, or save it as the given file",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			return u.save(arg)
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "format",
+		Help: "run the formatter of the document, e.g. gofmt",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			return u.format()
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "search-in-files",
+		Help: "search a regexp in all files of the working directory",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if arg == "" {
+				return u.askSearchInFiles()
+			}
+			return u.searchInFiles(arg)
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "next-hunk",
+		Help: "go to the next git change",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.gotoHunk(1)
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "prev-hunk",
+		Help: "go to the previous git change",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.gotoHunk(-1)
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "preview-hunk",
+		Help: "preview the git change at the cursor",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.previewHunk()
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "revert-hunk",
+		Help: "revert the git change at the cursor to HEAD",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.revertHunk()
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "diff",
+		Help: "compare the buffer with the file on disk, or with the given file",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if arg == "" {
+				u.diffWithDisk()
+				return nil
+			}
+			return u.diffFiles(u.document.Filename(), arg)
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "goto",
+		Help: "go to line[:column]",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.gotoLine(arg))
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "search",
+		Help: "select the next occurrence of the text",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.searchForward(arg))
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "replace",
+		Help: "replace the matches of a regexp in every line, /regexp/replacement/",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.replaceAll(arg))
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "insert",
+		Help: "insert the text at the cursor",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.insertText(arg)
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "filter-region",
+		Help: "pipe the selection, or the document, through a shell command and replace it with the output",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if arg == "" {
+				return u.askFilterRegion()
+			}
+			return u.filterRegion(arg)
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "compile",
+		Help: "run a build command, it becomes the default, or the default one",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if arg != "" {
+				u.cfg.CompileCommand = arg
+			}
+			return u.compile(u.cfg.CompileCommand)
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "test",
+		Help: "run a test command, it becomes the default, or the default one",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if arg != "" {
+				u.cfg.TestCommand = arg
+			}
+			return u.compile(u.cfg.TestCommand)
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "kill-compile",
+		Help: "kill the running compile or test command",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.compiler.stop()
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "next-error",
+		Help: "go to the next location of the compile output",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			return u.nextLocation(1)
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "prev-error",
+		Help: "go to the previous location of the compile output",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			return u.nextLocation(-1)
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "terminal",
+		Help: "open the terminal",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			return u.openTerminal()
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "terminal-copy",
+		Help: "copy the last lines of the terminal, or all of them, to the kill ring",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			return u.copyTerminal(arg)
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "terminal-to-document",
+		Help: "open the last lines of the terminal, or all of them, in a new document",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			return u.terminalToDocument(arg)
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "record-macro",
+		Help: "record the keys into the named macro, q by default",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.recordMacro(arg)
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "stop-macro",
+		Help: "stop recording the macro",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.stopMacro()
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "play-macro",
+		Help: "play the named macro, or the last one, the given number of times",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			name, count, err := parseMacroArgs(arg)
+			if err != nil {
+				u.fail(err)
+				return nil
+			}
+			return u.playMacro(name, count)
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "save-macros",
+		Help: "save the macros to the config directory, or to the given file",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.saveMacros(arg))
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "load-macros",
+		Help: "load the macros from the config directory, or from the given file",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.loadMacros(arg))
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "copy-to-register",
+		Help: "copy the selected text to the named register",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.copyToRegister(arg)
+			return nil
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "insert-register",
+		Help: "insert the text of the named register",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.insertRegister(arg)
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "match-bracket",
+		Help: "move to the bracket matching the one at the cursor",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if !u.textarea.JumpToMatchingBracket() {
+				u.fail(errors.New("no matching bracket"))
+			}
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "expand-selection",
+		Help: "select the syntax node around the selection",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if !u.textarea.ExpandSelection() {
+				u.fail(errors.New("the whole document is selected"))
+			}
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "shrink-selection",
+		Help: "restore the selection before it was expanded",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if !u.textarea.ShrinkSelection() {
+				u.fail(errors.New("the selection was not expanded"))
+			}
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "fold",
+		Help: "fold the innermost region around the cursor",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.textarea.Fold())
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "unfold",
+		Help: "unfold the folded region at the cursor",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.textarea.Unfold())
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "toggle-fold",
+		Help: "fold or unfold the region at the cursor",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.textarea.ToggleFold())
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "fold-all",
+		Help: "fold every region of the document",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.message(fmt.Sprintf("folded %d regions", u.textarea.FoldAll()))
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "unfold-all",
+		Help: "unfold every region of the document",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.textarea.UnfoldAll()
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "preview",
+		Help: "show or hide the Markdown preview beside the document",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.toggleSide(NewPreviewPane(u.previews))
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "goto-symbol",
+		Help: "pick a symbol of the Go document to move to",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.gotoSymbol())
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "symbol-outline",
+		Help: "show or hide the symbols of the Go document beside it",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.toggleSide(NewSymbolOutlinePane(u.symbols))
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "outline",
+		Help: "list the headings of the Markdown document",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.outline())
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "next-heading",
+		Help: "move to the next heading of the Markdown document",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.gotoHeading(1))
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "prev-heading",
+		Help: "move to the previous heading of the Markdown document",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.gotoHeading(-1))
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "toggle-checkbox",
+		Help: "tick or clear the task of the list item at the cursor",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if u.document.Type() != "md" {
+				u.fail(errNotMarkdown)
+				return nil
+			}
+			u.check(u.textarea.ToggleCheckbox())
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "align-table",
+		Help: "line up the columns of the Markdown table at the cursor",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if !u.textarea.AlignTable() {
+				u.fail(errors.New("no table at the cursor"))
+			}
+			return nil
+		},
+	})
+
+	u.RegisterCommand(Command{
+		Name: "follow-link",
+		Help: "open the file of the Markdown link at the cursor",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			return u.followLink()
+		},
+	})
+	u.RegisterCommand(Command{
+		Name: "set-indent",
+		Help: "indent the document with tabs or spaces of the size, e.g. spaces 2",
+		Run: func(u *Ui, arg string) tea.Cmd {
+			u.check(u.setIndent(arg))
+			return nil
+		},
+	})
+
+	for name, run := range map[string]func(){
+		"upcase-region":   u.textarea.UpcaseSelection,
+		"downcase-region": u.textarea.DowncaseSelection,
+		"indent-region":   u.textarea.IndentSelection,
+		"outdent-region":  u.textarea.OutdentSelection,
+	} {
+		run := run
+		u.RegisterCommand(Command{
+			Name: name,
+			Help: strings.ReplaceAll(name, "-", " "),
+			Run: func(u *Ui, arg string) tea.Cmd {
+				u.textarea.forEachCursor(run)
+				return nil
+			},
+		})
+	}
+
+	for name, run := range map[string]func(){
+		"undo":                u.undo,
+		"redo":                u.redo,
+		"add-cursor-above":    func() { u.textarea.AddCursor(-1) },
+		"add-cursor-below":    func() { u.textarea.AddCursor(1) },
+		"add-next-occurrence": u.textarea.AddNextOccurrence,
+		"split-selection":     u.textarea.SplitSelection,
+		"block-select":        u.textarea.StartBlock,
+	} {
+		run := run
+		u.RegisterCommand(Command{
+			Name: name,
+			Help: strings.ReplaceAll(name, "-", " "),
+			Run: func(u *Ui, arg string) tea.Cmd {
+				run()
+				return nil
+			},
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fzdwx/ge/internal/compile"
+	"github.com/fzdwx/x/str"
+	rw "github.com/mattn/go-runewidth"
+)
+
+// maxOutputBatch the max number of lines delivered by one compileOutputMsg,
+// so that a chatty command doesn't block the update loop.
+const maxOutputBatch = 256
+
+var compileErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+
+type (
+	// compileOutputMsg delivers some lines of the command identified by id.
+	compileOutputMsg struct {
+		id    int
+		lines []string
+		done  bool
+		err   error
+	}
+
+	// locationMsg asks the Ui to go to a location of the compile output.
+	locationMsg struct {
+		location compile.Location
+	}
+
+	// compiler tracks the running build or test command.
+	compiler struct {
+		id     int
+		cancel context.CancelFunc
+		events <-chan compile.Event
+		pane   *CompilePane
+	}
+
+	// compileLine is a line of output, location is nil unless it points to a
+	// file.
+	compileLine struct {
+		text     string
+		location *compile.Location
+	}
+
+	// CompilePane shows the output of a command, the lines with a location
+	// form the quickfix list.
+	CompilePane struct {
+		command string
+		// dir the directory the command runs in, the locations are relative
+		// to it.
+		dir string
+
+		lines []compileLine
+		// locations the indexes of the lines with a location.
+		locations []int
+		// selected the index in locations of the current location, -1 before
+		// the first one is visited.
+		selected int
+		// offset is the index of the first visible line.
+		offset int
+		// follow whether the view scrolls with the output.
+		follow bool
+
+		done bool
+		err  error
+
+		width  int
+		height int
+	}
+)
+
+func NewCompilePane(command, dir string) *CompilePane {
+	return &CompilePane{command: command, dir: dir, selected: -1, follow: true}
+}
+
+// Append adds lines of output.
+func (p *CompilePane) Append(lines ...string) {
+	for _, text := range lines {
+		line := compileLine{text: text}
+		if location, ok := compile.ParseLocation(text); ok {
+			line.location = &location
+			p.locations = append(p.locations, len(p.lines))
+		}
+		p.lines = append(p.lines, line)
+	}
+
+	if p.follow {
+		p.offset = max(0, len(p.lines)-(p.height-1))
+	}
+}
+
+// Done marks the command as finished with err.
+func (p *CompilePane) Done(err error) {
+	p.done, p.err = true, err
+}
+
+// Locations returns the number of locations.
+func (p *CompilePane) Locations() int {
+	return len(p.locations)
+}
+
+// move selects the location n after the selected one and returns it, the
+// selection stops at the first and the last location.
+func (p *CompilePane) move(n int) (compile.Location, bool) {
+	if len(p.locations) == 0 {
+		return compile.Location{}, false
+	}
+
+	p.selected = clamp(p.selected+n, 0, len(p.locations)-1)
+	p.follow = false
+
+	line := p.locations[p.selected]
+	visible := p.height - 1
+	if line < p.offset {
+		p.offset = line
+	} else if line >= p.offset+visible {
+		p.offset = line - visible + 1
+	}
+
+	location := *p.lines[line].location
+	location.Filename = compile.Resolve(p.dir, location.Filename)
+	return location, true
+}
+
+func (p *CompilePane) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+func (p *CompilePane) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, resultsUp):
+			p.move(-1)
+		case key.Matches(msg, resultsDown):
+			p.move(1)
+		case key.Matches(msg, resultsEnter):
+			location, ok := p.move(0)
+			if !ok {
+				return nil
+			}
+			return func() tea.Msg {
+				return locationMsg{location: location}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *CompilePane) View() string {
+	fluent := str.NewFluent()
+	fluent.Str(paneTitleStyle.Render(rw.Truncate(fmt.Sprintf("Compile: %s  %s", p.command, p.state()), p.width, "")))
+
+	selected := -1
+	if p.selected >= 0 {
+		selected = p.locations[p.selected]
+	}
+	for i := p.offset; i < p.offset+p.height-1; i++ {
+		fluent.NewLine()
+		if i >= len(p.lines) {
+			continue
+		}
+
+		line := p.lines[i]
+		text := rw.Truncate(line.text, p.width, "")
+		switch {
+		case i == selected:
+			fluent.Str(resultCursorStyle.Render(text))
+		case line.location != nil:
+			fluent.Str(compileErrorStyle.Render(text))
+		default:
+			fluent.Str(text)
+		}
+	}
+	return fluent.String()
+}
+
+func (p *CompilePane) state() string {
+	switch {
+	case !p.done:
+		return "running..."
+	case p.err != nil:
+		return fmt.Sprintf("%s, %d locations", p.err, len(p.locations))
+	default:
+		return fmt.Sprintf("done, %d locations", len(p.locations))
+	}
+}
+
+// compile runs the shell command in the working directory, its output is
+// shown in a pane. A running command is killed.
+func (u *Ui) compile(command string) tea.Cmd {
+	dir, err := os.Getwd()
+	if err != nil {
+		u.fail(err)
+		return nil
+	}
+
+	u.compiler.stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	u.compiler.id++
+	u.compiler.cancel = cancel
+	u.compiler.pane = NewCompilePane(command, dir)
+	u.compiler.events = compile.Run(ctx, shell(), "-c", command)
+
+	u.openPane(u.compiler.pane)
+	u.focusPane(false)
+	return waitCompileOutput(u.compiler.id, u.compiler.events)
+}
+
+// stop kills the running command.
+func (c *compiler) stop() {
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
+
+// handle appends the output to the pane, returns false when msg belongs to
+// an outdated command.
+func (c *compiler) handle(msg compileOutputMsg) bool {
+	if msg.id != c.id || c.pane == nil {
+		return false
+	}
+
+	c.pane.Append(msg.lines...)
+	if msg.done {
+		c.pane.Done(msg.err)
+		c.stop()
+	}
+	return true
+}
+
+// waitCompileOutput waits for the next lines of events, and drains what is
+// already available up to maxOutputBatch.
+func waitCompileOutput(id int, events <-chan compile.Event) tea.Cmd {
+	return func() tea.Msg {
+		msg := compileOutputMsg{id: id}
+		e, ok := <-events
+		for {
+			if !ok {
+				msg.done = true
+				return msg
+			}
+			if e.Done {
+				msg.done, msg.err = true, e.Err
+				return msg
+			}
+
+			msg.lines = append(msg.lines, e.Line)
+			if len(msg.lines) >= maxOutputBatch {
+				return msg
+			}
+			select {
+			case e, ok = <-events:
+			default:
+				return msg
+			}
+		}
+	}
+}
+
+// handleCompileOutput shows the output of the command, the end of the
+// command is reported in the status line.
+func (u *Ui) handleCompileOutput(msg compileOutputMsg) tea.Cmd {
+	if !u.compiler.handle(msg) {
+		return nil
+	}
+	if !msg.done {
+		return waitCompileOutput(msg.id, u.compiler.events)
+	}
+
+	pane := u.compiler.pane
+	u.message(fmt.Sprintf("%s: %s", pane.command, pane.state()))
+	return nil
+}
+
+// nextLocation goes to the location n after the current one in the output
+// of the last command.
+func (u *Ui) nextLocation(n int) tea.Cmd {
+	if u.compiler.pane == nil {
+		u.fail(errors.New("nothing compiled yet"))
+		return nil
+	}
+
+	location, ok := u.compiler.pane.move(n)
+	if !ok {
+		u.message("no locations")
+		return nil
+	}
+	return u.gotoLocation(location)
+}
+
+// gotoLocation opens the file of location and moves the cursor to it, the
+// message of the location is shown in the status line.
+func (u *Ui) gotoLocation(location compile.Location) tea.Cmd {
+	document, err := u.open(location.Filename)
+	if err != nil {
+		u.fail(err)
+		return nil
+	}
+
+	// the columns of the tools count bytes, the cursor counts runes.
+	col := location.Col
+	if location.Row < document.Height() {
+		line := document.Row(location.Row).String()
+		col = utf8.RuneCountInString(line[:min(col, len(line))])
+	}
+
+	cmd := u.jump(jumpMsg{Filename: location.Filename, Row: location.Row, Col: col})
+	u.message(location.Message)
+	return cmd
+}
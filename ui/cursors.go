@@ -0,0 +1,357 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// cursorState is the state of one of the cursors of the textarea.
+type cursorState struct {
+	row            int
+	col            int
+	lastCharOffset int
+	anchor         views.Pos
+	selecting      bool
+	markMode       bool
+
+	// primary marks the cursor of the textarea while the cursors are sorted.
+	primary bool
+}
+
+func (c cursorState) pos() views.Pos {
+	return views.Pos{Row: c.row, Col: c.col}
+}
+
+// bounds returns the selected region in document order, or the cursor
+// position twice.
+func (c cursorState) bounds() (views.Pos, views.Pos) {
+	from, to := c.pos(), c.pos()
+	if !c.selecting {
+		return from, to
+	}
+	if c.anchor.Before(from) {
+		from = c.anchor
+	} else {
+		to = c.anchor
+	}
+	return from, to
+}
+
+// adjust moves the cursor along with a change of the document.
+func (c *cursorState) adjust(change views.Change) {
+	pos := change.Adjust(c.pos())
+	c.row, c.col = pos.Row, pos.Col
+	c.anchor = change.Adjust(c.anchor)
+}
+
+func (m *Textarea) state() cursorState {
+	return cursorState{
+		row:            m.row,
+		col:            m.col,
+		lastCharOffset: m.lastCharOffset,
+		anchor:         m.anchor,
+		selecting:      m.selecting,
+		markMode:       m.markMode,
+		primary:        true,
+	}
+}
+
+func (m *Textarea) setState(c cursorState) {
+	m.row, m.col, m.lastCharOffset = c.row, c.col, c.lastCharOffset
+	m.anchor, m.selecting, m.markMode = c.anchor, c.selecting, c.markMode
+}
+
+// Cursors returns the number of cursors.
+func (m *Textarea) Cursors() int {
+	return len(m.others) + 1
+}
+
+// ClearCursors removes all cursors but the primary one.
+func (m *Textarea) ClearCursors() {
+	m.others = nil
+}
+
+// cursors returns all cursors in document order.
+func (m *Textarea) cursors() []cursorState {
+	cursors := append([]cursorState{m.state()}, m.others...)
+	sort.SliceStable(cursors, func(i, j int) bool {
+		a, _ := cursors[i].bounds()
+		b, _ := cursors[j].bounds()
+		return a.Before(b)
+	})
+	return cursors
+}
+
+// setCursors replaces all cursors, the one marked primary becomes the cursor
+// of the textarea.
+func (m *Textarea) setCursors(cursors []cursorState) {
+	m.others = m.others[:0]
+	for _, c := range cursors {
+		if c.primary {
+			m.setState(c)
+			continue
+		}
+		m.others = append(m.others, c)
+	}
+}
+
+// forEachCursor runs f with every cursor in turn being the cursor of the
+// textarea, in document order and as a single undo step. The other cursors
+// follow the edits made by f.
+func (m *Textarea) forEachCursor(f func()) {
+	if len(m.others) <= 0 {
+		f()
+		return
+	}
+
+	cursors, active := m.cursors(), -1
+	unsubscribe := m.document.OnChange(func(change views.Change) {
+		for i := range cursors {
+			if i != active {
+				cursors[i].adjust(change)
+			}
+		}
+	})
+	defer unsubscribe()
+
+	m.document.BeginGroup()
+	defer m.document.EndGroup()
+
+	for i := range cursors {
+		active = i
+		primary := cursors[i].primary
+		m.setState(cursors[i])
+		f()
+		cursors[i] = m.state()
+		cursors[i].primary = primary
+	}
+
+	m.setCursors(cursors)
+	m.mergeCursors()
+}
+
+// mergeCursors merges the cursors that are at the same position or whose
+// selections overlap.
+func (m *Textarea) mergeCursors() {
+	if len(m.others) <= 0 {
+		return
+	}
+
+	cursors := m.cursors()
+	merged := cursors[:1]
+	for _, c := range cursors[1:] {
+		last := &merged[len(merged)-1]
+		lastFrom, lastTo := last.bounds()
+		from, to := c.bounds()
+
+		overlaps := from.Before(lastTo) || from == lastFrom ||
+			from == lastTo && (from == to || lastFrom == lastTo)
+		if !overlaps {
+			merged = append(merged, c)
+			continue
+		}
+
+		if lastTo.Before(to) {
+			last.anchor, last.row, last.col = lastFrom, to.Row, to.Col
+		}
+		last.selecting = last.selecting || c.selecting
+		last.primary = last.primary || c.primary
+	}
+
+	m.setCursors(merged)
+}
+
+// AddCursor adds a cursor in the row above (dir < 0) or below (dir > 0) the
+// outermost cursor in that direction, the new cursor becomes the primary one.
+func (m *Textarea) AddCursor(dir int) {
+	edge := m.state()
+	for _, c := range m.others {
+		if dir < 0 && c.row < edge.row || dir > 0 && c.row > edge.row {
+			edge = c
+		}
+	}
+
+	row := edge.row + dir
+	if row < 0 || row >= m.document.Height() {
+		return
+	}
+
+	current := m.state()
+	current.primary = false
+	m.others = append(m.others, current)
+	m.setState(cursorState{row: row, col: min(edge.col, len(m.document.Row(row)))})
+	m.mergeCursors()
+}
+
+// AddNextOccurrence selects the word at the cursor if nothing is selected,
+// otherwise it adds a cursor selecting the next occurrence of the selected
+// text.
+func (m *Textarea) AddNextOccurrence() {
+	from, to, ok := m.Selection()
+	if !ok {
+		start, end := m.wordAt()
+		if start != end {
+			m.Select(views.Pos{Row: m.row, Col: start}, views.Pos{Row: m.row, Col: end})
+		}
+		return
+	}
+
+	text := m.document.Text(from, to)
+	next, ok := m.document.Find(text, to)
+	for _, c := range m.cursors() {
+		if start, _ := c.bounds(); ok && start == next {
+			// every occurrence has a cursor already.
+			return
+		}
+	}
+	if !ok {
+		return
+	}
+
+	current := m.state()
+	current.primary = false
+	m.others = append(m.others, current)
+
+	end := views.Pos{Row: next.Row, Col: next.Col + len([]rune(text))}
+	if lines := strings.Split(text, "\n"); len(lines) > 1 {
+		end = views.Pos{Row: next.Row + len(lines) - 1, Col: len([]rune(lines[len(lines)-1]))}
+	}
+	m.Select(next, end)
+	m.mergeCursors()
+}
+
+// SplitSelection replaces every selection spanning several rows by a cursor
+// selecting each of its rows.
+func (m *Textarea) SplitSelection() {
+	var cursors []cursorState
+	for _, c := range m.cursors() {
+		from, to := c.bounds()
+		if from.Row == to.Row {
+			cursors = append(cursors, c)
+			continue
+		}
+
+		for row := from.Row; row <= to.Row; row++ {
+			start, end := views.Pos{Row: row}, views.Pos{Row: row, Col: len(m.document.Row(row))}
+			if row == from.Row {
+				start = from
+			}
+			if row == to.Row {
+				if to.Col == 0 {
+					break
+				}
+				end = to
+			}
+			cursors = append(cursors, cursorState{row: end.Row, col: end.Col, anchor: start, selecting: true})
+		}
+		cursors[len(cursors)-1].primary = c.primary
+	}
+
+	m.setCursors(cursors)
+	m.mergeCursors()
+}
+
+// wordAt returns the columns of the word around the cursor.
+func (m *Textarea) wordAt() (int, int) {
+	row := m.document.Row(m.row)
+	isWord := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	start, end := m.col, m.col
+	for start > 0 && isWord(row[start-1]) {
+		start--
+	}
+	for end < len(row) && isWord(row[end]) {
+		end++
+	}
+	return start, end
+}
+
+// copySelections returns the selected texts of all cursors joined by
+// newlines, the selections are deleted if cut is true.
+func (m *Textarea) copySelections(cut bool) string {
+	var texts []string
+	m.forEachCursor(func() {
+		if text := m.SelectedText(); text != "" {
+			texts = append(texts, text)
+		}
+		if cut {
+			m.DeleteSelection()
+		} else {
+			m.ClearSelection()
+		}
+	})
+	return strings.Join(texts, "\n")
+}
+
+// paste inserts text at every cursor, when there are as many lines as
+// cursors every cursor gets one of them.
+func (m *Textarea) paste(text string) {
+	lines := strings.Split(text, "\n")
+	distribute := len(m.others) > 0 && len(lines) == m.Cursors()
+
+	i := 0
+	m.forEachCursor(func() {
+		m.DeleteSelection()
+		if distribute {
+			m.InsertString(lines[i])
+			i++
+			return
+		}
+		m.InsertString(text)
+	})
+}
+
+// Undo reverts the last edit and collapses the cursors.
+func (m *Textarea) Undo() bool {
+	pos, ok := m.document.Undo()
+	if ok {
+		m.ClearCursors()
+		m.ClearSelection()
+		m.SetPosition(pos.Row, pos.Col)
+	}
+	return ok
+}
+
+// Redo applies the last undone edit again and collapses the cursors.
+func (m *Textarea) Redo() bool {
+	pos, ok := m.document.Redo()
+	if ok {
+		m.ClearCursors()
+		m.ClearSelection()
+		m.SetPosition(pos.Row, pos.Col)
+	}
+	return ok
+}
+
+func (u *Ui) undo() {
+	if !u.textarea.Undo() {
+		u.message("nothing to undo")
+	}
+}
+
+func (u *Ui) redo() {
+	if !u.textarea.Redo() {
+		u.message("nothing to redo")
+	}
+}
+
+// FollowEdits runs f, which edits the document, and moves the cursors along
+// with the edits.
+func (m *Textarea) FollowEdits(f func()) {
+	cursors := m.cursors()
+	unsubscribe := m.document.OnChange(func(change views.Change) {
+		for i := range cursors {
+			cursors[i].adjust(change)
+		}
+	})
+	f()
+	unsubscribe()
+
+	m.setCursors(cursors)
+	m.mergeCursors()
+}
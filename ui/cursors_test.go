@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// newTestTextarea returns a focused textarea editing text.
+func newTestTextarea(t *testing.T, text string) *Textarea {
+	t.Helper()
+	document := views.NewDocument()
+	if err := document.Read(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTextArea()
+	m.SetDocument(document)
+	m.Focus()
+	return m
+}
+
+// caret returns a cursor at row and col.
+func caret(row, col int) cursorState {
+	return cursorState{row: row, col: col}
+}
+
+// selection returns a cursor at row and col selecting from the anchor.
+func selection(anchorRow, anchorCol, row, col int) cursorState {
+	return cursorState{row: row, col: col, anchor: views.Pos{Row: anchorRow, Col: anchorCol}, selecting: true}
+}
+
+// cursorsString describes the cursors in document order, e.g. "*0.1 1.0-1.3"
+// for the primary cursor at row 0 and col 1 and a cursor at 1.3 selecting
+// from 1.0.
+func cursorsString(m *Textarea) string {
+	var s []string
+	for _, c := range m.cursors() {
+		d := fmt.Sprintf("%d.%d", c.row, c.col)
+		if c.selecting {
+			d = fmt.Sprintf("%d.%d-%s", c.anchor.Row, c.anchor.Col, d)
+		}
+		if c.primary {
+			d = "*" + d
+		}
+		s = append(s, d)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestTextarea_mergeCursors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursors []cursorState
+		want    string
+	}{
+		{"same position", []cursorState{caret(0, 1), caret(0, 1)}, "*0.1"},
+		{"apart", []cursorState{caret(0, 1), caret(1, 0)}, "*0.1 1.0"},
+		{"overlapping selections", []cursorState{selection(0, 0, 0, 3), selection(0, 2, 0, 5)}, "*0.0-0.5"},
+		{"nested selections", []cursorState{selection(1, 4, 0, 0), selection(0, 1, 0, 2)}, "*1.4-0.0"},
+		{"touching selections", []cursorState{selection(0, 0, 0, 2), selection(0, 2, 0, 4)}, "*0.0-0.2 0.2-0.4"},
+		{"caret at the end of a selection", []cursorState{caret(0, 2), selection(0, 0, 0, 2)}, "*0.0-0.2"},
+		{"caret at the start of a selection", []cursorState{selection(0, 0, 0, 2), caret(0, 0)}, "*0.0-0.2"},
+		{"caret inside of a selection", []cursorState{caret(1, 2), selection(1, 0, 1, 4)}, "*1.0-1.4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestTextarea(t, "hello\nworld\n")
+			tt.cursors[0].primary = true
+			m.setCursors(tt.cursors)
+			m.mergeCursors()
+			if got := cursorsString(m); got != tt.want {
+				t.Errorf("mergeCursors() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextarea_SplitSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursors []cursorState
+		want    string
+	}{
+		{"rows", []cursorState{selection(0, 1, 2, 2)}, "0.1-0.3 1.0-1.3 *2.0-2.2"},
+		{"backwards", []cursorState{selection(2, 2, 0, 1)}, "0.1-0.3 1.0-1.3 *2.0-2.2"},
+		{"up to the start of a row", []cursorState{selection(0, 0, 2, 0)}, "0.0-0.3 *1.0-1.3"},
+		{"single row", []cursorState{selection(1, 0, 1, 2), caret(2, 1)}, "*1.0-1.2 2.1"},
+		{"several selections", []cursorState{caret(2, 5), selection(0, 2, 1, 1)}, "0.2-0.3 1.0-1.1 *2.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestTextarea(t, "one\ntwo\nthree\n")
+			tt.cursors[0].primary = true
+			m.setCursors(tt.cursors)
+			m.SplitSelection()
+			if got := cursorsString(m); got != tt.want {
+				t.Errorf("SplitSelection() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextarea_Undo_Cursors(t *testing.T) {
+	m := newTestTextarea(t, "a\nb\nc\n")
+	m.setCursors([]cursorState{{row: 0, col: 1, primary: true}, caret(1, 1), caret(2, 1)})
+
+	for _, r := range "xy" {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if got := m.document.String(); got != "ax\nbx\ncx" {
+		t.Fatalf("document = %q", got)
+	}
+
+	// every key is one undo step for all the cursors.
+	for _, want := range []string{"axy\nbxy\ncxy", "ax\nbx\ncx", "a\nb\nc"} {
+		if !m.Undo() {
+			t.Fatal("Undo() = false")
+		}
+		if got := m.document.String(); got != want {
+			t.Errorf("Undo() = %q, want %q", got, want)
+		}
+	}
+	if got := cursorsString(m); got != "*0.1" {
+		t.Errorf("cursors = %q, want a single cursor", got)
+	}
+}
+
+func TestTextarea_Undo_Empty(t *testing.T) {
+	m := newTestTextarea(t, "")
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.document.String(); got != "a\n" {
+		t.Fatalf("document = %q", got)
+	}
+
+	m.Undo()
+	m.Undo()
+	if m.document.Height() != 0 || m.Position() != (views.Pos{}) {
+		t.Errorf("Undo() left %d rows, cursor %v, want an empty document", m.document.Height(), m.Position())
+	}
+	_ = m.View()
+}
@@ -0,0 +1,360 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fzdwx/ge/internal/diff"
+	"github.com/fzdwx/ge/internal/views"
+	"github.com/fzdwx/x/str"
+	rw "github.com/mattn/go-runewidth"
+)
+
+var (
+	diffRemovedLineStyle = lipgloss.NewStyle().Background(lipgloss.Color("52"))
+	diffRemovedWordStyle = lipgloss.NewStyle().Background(lipgloss.Color("124"))
+	diffAddedLineStyle   = lipgloss.NewStyle().Background(lipgloss.Color("22"))
+	diffAddedWordStyle   = lipgloss.NewStyle().Background(lipgloss.Color("28"))
+	diffFillerStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
+	diffLineNumberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	diffSelectedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+
+	diffUp        = key.NewBinding(key.WithKeys("up", "ctrl+p", "k"))
+	diffDown      = key.NewBinding(key.WithKeys("down", "ctrl+n", "j"))
+	diffPageUp    = key.NewBinding(key.WithKeys("pgup", "alt+v"))
+	diffPageDown  = key.NewBinding(key.WithKeys("pgdown", "ctrl+v", " "))
+	diffNextHunk  = key.NewBinding(key.WithKeys("n", "alt+j"))
+	diffPrevHunk  = key.NewBinding(key.WithKeys("p", "alt+k"))
+	diffCopyRight = key.NewBinding(key.WithKeys(">"))
+	diffCopyLeft  = key.NewBinding(key.WithKeys("<"))
+	diffClose     = key.NewBinding(key.WithKeys("q", "esc"))
+)
+
+const (
+	// diffLineNumberWidth the width of the line numbers of each side.
+	diffLineNumberWidth = 5
+	// diffTabWidth tabs are expanded to this many spaces.
+	diffTabWidth = 4
+)
+
+type (
+	// closeDiffViewMsg is sent when the user closes the diff view.
+	closeDiffViewMsg struct{}
+
+	// diffRow is a row of the side-by-side view, -1 means there is no line on
+	// that side.
+	diffRow struct {
+		left  int
+		right int
+		// hunk is the index of the hunk of the row, -1 for unchanged lines.
+		hunk int
+	}
+
+	// DiffView shows two documents side-by-side with their differences
+	// highlighted, both sides scroll together.
+	DiffView struct {
+		left       *views.Document
+		right      *views.Document
+		leftTitle  string
+		rightTitle string
+
+		hunks []diff.Hunk
+		rows  []diffRow
+		// revisions the revisions of the documents the rows are computed from.
+		revisions [2]int
+
+		// selected the index of the selected hunk.
+		selected int
+		// offset the index of the first visible row.
+		offset int
+
+		width  int
+		height int
+	}
+)
+
+func NewDiffView(left *views.Document, leftTitle string, right *views.Document, rightTitle string) *DiffView {
+	v := &DiffView{
+		left:       left,
+		right:      right,
+		leftTitle:  leftTitle,
+		rightTitle: rightTitle,
+		revisions:  [2]int{-1, -1},
+	}
+	v.refresh()
+	return v
+}
+
+// refresh recomputes the diff when one of the documents has changed.
+func (v *DiffView) refresh() {
+	revisions := [2]int{v.left.Revision(), v.right.Revision()}
+	if revisions == v.revisions {
+		return
+	}
+	v.revisions = revisions
+
+	v.hunks = diff.Lines(v.left.Lines(), v.right.Lines())
+	v.rows = v.rows[:0]
+
+	var a, b int
+	equal := func(aEnd int) {
+		for ; a < aEnd; a, b = a+1, b+1 {
+			v.rows = append(v.rows, diffRow{left: a, right: b, hunk: -1})
+		}
+	}
+
+	for i, hunk := range v.hunks {
+		equal(hunk.A)
+
+		la, lb := hunk.AEnd-hunk.A, hunk.BEnd-hunk.B
+		for k := 0; k < max(la, lb); k++ {
+			row := diffRow{left: -1, right: -1, hunk: i}
+			if k < la {
+				row.left = hunk.A + k
+			}
+			if k < lb {
+				row.right = hunk.B + k
+			}
+			v.rows = append(v.rows, row)
+		}
+		a, b = hunk.AEnd, hunk.BEnd
+	}
+	equal(v.left.Height())
+
+	v.selected = clamp(v.selected, 0, len(v.hunks)-1)
+	v.scroll(0)
+}
+
+func (v *DiffView) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+	v.scroll(0)
+}
+
+func (v *DiffView) scroll(n int) {
+	v.offset = clamp(v.offset+n, 0, max(0, len(v.rows)-(v.height-1)))
+}
+
+// gotoHunk selects the next hunk in dir direction and scrolls to it.
+func (v *DiffView) gotoHunk(dir int) {
+	if len(v.hunks) <= 0 {
+		return
+	}
+
+	v.selected = (v.selected + dir + len(v.hunks)) % len(v.hunks)
+	for i, row := range v.rows {
+		if row.hunk == v.selected {
+			v.offset = 0
+			v.scroll(i - (v.height-1)/3)
+			return
+		}
+	}
+}
+
+// copyHunk copies the selected hunk from one side to the other.
+func (v *DiffView) copyHunk(toRight bool) {
+	if len(v.hunks) <= 0 {
+		return
+	}
+
+	hunk := v.hunks[v.selected]
+	if toRight {
+		v.right.ReplaceLines(hunk.B, hunk.BEnd, v.left.Lines()[hunk.A:hunk.AEnd])
+	} else {
+		v.left.ReplaceLines(hunk.A, hunk.AEnd, v.right.Lines()[hunk.B:hunk.BEnd])
+	}
+	v.refresh()
+}
+
+func (v *DiffView) Update(msg tea.Msg) tea.Cmd {
+	v.refresh()
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, diffUp):
+			v.scroll(-1)
+		case key.Matches(msg, diffDown):
+			v.scroll(1)
+		case key.Matches(msg, diffPageUp):
+			v.scroll(-(v.height - 1))
+		case key.Matches(msg, diffPageDown):
+			v.scroll(v.height - 1)
+		case key.Matches(msg, diffNextHunk):
+			v.gotoHunk(1)
+		case key.Matches(msg, diffPrevHunk):
+			v.gotoHunk(-1)
+		case key.Matches(msg, diffCopyRight):
+			v.copyHunk(true)
+		case key.Matches(msg, diffCopyLeft):
+			v.copyHunk(false)
+		case key.Matches(msg, diffClose):
+			return func() tea.Msg { return closeDiffViewMsg{} }
+		}
+	}
+	return nil
+}
+
+func (v *DiffView) View() string {
+	v.refresh()
+
+	var (
+		fluent = str.NewFluent()
+		side   = (v.width - 1) / 2
+	)
+
+	title := fmt.Sprintf("%d changes, n/p: next/prev, >/<: copy to right/left, q: close", len(v.hunks))
+	fluent.Str(paneTitleStyle.Render(rw.Truncate(title, v.width, "")))
+	fluent.NewLine().
+		Str(paneTitleStyle.Render(rw.FillRight(rw.Truncate(v.leftTitle, side, ""), side))).
+		Str(" ").
+		Str(paneTitleStyle.Render(rw.Truncate(v.rightTitle, side, "")))
+
+	for i := v.offset; i < v.offset+v.height-2; i++ {
+		fluent.NewLine()
+		if i >= len(v.rows) {
+			continue
+		}
+
+		row := v.rows[i]
+		left, right := v.lineHighlights(row)
+
+		fluent.Str(v.renderSide(v.left, row.left, left, diffRemovedLineStyle, diffRemovedWordStyle, row.hunk >= 0, side))
+		if row.hunk >= 0 && row.hunk == v.selected {
+			fluent.Str(diffSelectedStyle.Render("▌"))
+		} else {
+			fluent.Str(diffFillerStyle.Render("│"))
+		}
+		fluent.Str(v.renderSide(v.right, row.right, right, diffAddedLineStyle, diffAddedWordStyle, row.hunk >= 0, side))
+	}
+
+	return fluent.String()
+}
+
+// lineHighlights returns the changed runes of the lines of row, only lines
+// that are changed on both sides have intra-line changes.
+func (v *DiffView) lineHighlights(row diffRow) ([]bool, []bool) {
+	if row.hunk < 0 || row.left < 0 || row.right < 0 {
+		return nil, nil
+	}
+
+	a, b := v.left.Row(row.left), v.right.Row(row.right)
+	left, right := make([]bool, len(a)), make([]bool, len(b))
+	for _, hunk := range diff.Runes(a, b) {
+		for i := hunk.A; i < hunk.AEnd; i++ {
+			left[i] = true
+		}
+		for i := hunk.B; i < hunk.BEnd; i++ {
+			right[i] = true
+		}
+	}
+	return left, right
+}
+
+// renderSide renders line of document in width columns, changed is the
+// intra-line changes of the line.
+func (v *DiffView) renderSide(document *views.Document, line int, changed []bool,
+	lineStyle, wordStyle lipgloss.Style, inHunk bool, width int) string {
+	if line < 0 {
+		return diffFillerStyle.Render(strings.Repeat("╱", max(0, width)))
+	}
+
+	var (
+		fluent  = str.NewFluent()
+		number  = fmt.Sprintf("%*d ", diffLineNumberWidth-1, line+1)
+		used    = rw.StringWidth(number)
+		style   = lipgloss.NewStyle()
+		segment []rune
+	)
+	if inHunk {
+		style = lineStyle
+	}
+	fluent.Str(diffLineNumberStyle.Render(number))
+
+	var segmentChanged bool
+	flush := func() {
+		if len(segment) <= 0 {
+			return
+		}
+		if segmentChanged {
+			fluent.Str(wordStyle.Render(string(segment)))
+		} else {
+			fluent.Str(style.Render(string(segment)))
+		}
+		segment = segment[:0]
+	}
+
+	for i, r := range document.Row(line) {
+		runes, w := []rune{r}, rw.RuneWidth(r)
+		if r == '\t' {
+			runes, w = repeatSpaces(diffTabWidth), diffTabWidth
+		}
+		if used+w > width {
+			break
+		}
+
+		isChanged := i < len(changed) && changed[i]
+		if isChanged != segmentChanged {
+			flush()
+			segmentChanged = isChanged
+		}
+		segment = append(segment, runes...)
+		used += w
+	}
+	flush()
+
+	return fluent.Str(style.Render(strings.Repeat(" ", max(0, width-used)))).String()
+}
+
+// diffFiles opens a and b, and compares them side-by-side. An empty document
+// is shown instead when one of them can't be opened.
+func (u *Ui) diffFiles(a, b string) tea.Cmd {
+	left, err := u.open(a)
+	if err != nil {
+		return u.diffFailed(err)
+	}
+
+	right, err := u.open(b)
+	if err != nil {
+		return u.diffFailed(err)
+	}
+
+	cmd := u.show(left)
+	u.showDiff(NewDiffView(left, a, right, b))
+	return cmd
+}
+
+// diffFailed reports err and shows an empty document, so that there is a
+// document to edit.
+func (u *Ui) diffFailed(err error) tea.Cmd {
+	u.fail(err)
+	document := views.NewDocument()
+	u.addDocument(document)
+	return u.show(document)
+}
+
+// diffWithDisk compares the file on disk with the current document.
+func (u *Ui) diffWithDisk() {
+	filename := u.document.Filename()
+	if filename == "" {
+		u.fail(errors.New("the buffer has no file"))
+		return
+	}
+
+	disk := views.NewDocument()
+	if err := disk.Load(filename); err != nil {
+		u.fail(err)
+		return
+	}
+
+	u.showDiff(NewDiffView(disk, filename+" (disk)", u.document, filename+" (buffer)"))
+}
+
+func (u *Ui) showDiff(v *DiffView) {
+	u.diffView = v
+	u.layout()
+}
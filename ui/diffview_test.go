@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fzdwx/ge/config"
+)
+
+func TestDiffFiles_Missing(t *testing.T) {
+	a := writeFile(t, "a.txt", "a\n")
+	u := newTestUi(t, config.NewDiff(a, filepath.Join(filepath.Dir(a), "missing.txt")))
+
+	if u.failed == nil {
+		t.Error("failed = nil, want the error of the missing file")
+	}
+	if u.document == nil || u.diffView != nil {
+		t.Fatalf("document = %v, diffView = %v, want an empty document", u.document, u.diffView)
+	}
+
+	typeKeys(u, "x")
+	_ = u.View()
+	if got := u.document.String(); got != "x" {
+		t.Errorf("document = %q, want %q", got, "x")
+	}
+}
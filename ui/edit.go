@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// gotoLine moves the cursor to `line[:col]`, both counted from 1.
+func (u *Ui) gotoLine(arg string) error {
+	line, col, _ := strings.Cut(arg, ":")
+	row, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || row < 1 {
+		return fmt.Errorf("invalid line: %s", arg)
+	}
+
+	column := 1
+	if col != "" {
+		if column, err = strconv.Atoi(strings.TrimSpace(col)); err != nil || column < 1 {
+			return fmt.Errorf("invalid column: %s", arg)
+		}
+	}
+	if row > u.document.Height() {
+		return fmt.Errorf("line %d out of range, the document has %d lines", row, u.document.Height())
+	}
+
+	u.textarea.ClearCursors()
+	u.textarea.ClearSelection()
+	u.textarea.SetPosition(row-1, column-1)
+	return nil
+}
+
+// searchForward selects the next occurrence of text after the cursor, the
+// search wraps around at the end of the document.
+func (u *Ui) searchForward(text string) error {
+	if text == "" {
+		return errors.New("nothing to search")
+	}
+	text = unescape(text)
+
+	cursor := u.textarea.Position()
+	from, ok := u.document.Find(text, views.Pos{Row: cursor.Row, Col: cursor.Col + 1})
+	if !ok {
+		return fmt.Errorf("not found: %s", text)
+	}
+
+	to := from
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			to = views.Pos{Row: to.Row + 1}
+		}
+		to.Col += len([]rune(line))
+	}
+	u.textarea.ClearCursors()
+	u.textarea.Select(from, to)
+	return nil
+}
+
+// replaceAll replaces the matches of a regexp in every line, arg is
+// `/regexp/replacement/`, any character can be used instead of the slash and
+// the replacement can refer to the groups of the regexp, e.g. `${1}`.
+func (u *Ui) replaceAll(arg string) error {
+	re, replacement, err := parseReplace(arg)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	u.textarea.FollowEdits(func() {
+		// the rows are replaced from the bottom, so that a replacement with a
+		// newline doesn't move the rows that are still to be replaced.
+		for row := u.document.Height() - 1; row >= 0; row-- {
+			line := u.document.Row(row).String()
+			matches := len(re.FindAllStringIndex(line, -1))
+			if matches == 0 {
+				continue
+			}
+
+			replaced := re.ReplaceAllString(line, replacement)
+			if replaced != line {
+				u.document.Replace(views.Pos{Row: row}, views.Pos{Row: row, Col: len(u.document.Row(row))}, replaced)
+			}
+			n += matches
+		}
+	})
+	u.message(fmt.Sprintf("replaced %d occurrences", n))
+	return nil
+}
+
+// parseReplace parses `/regexp/replacement/`, a backslash escapes the
+// delimiter and the last delimiter is optional.
+func parseReplace(arg string) (*regexp.Regexp, string, error) {
+	usage := errors.New("usage: replace /regexp/replacement/")
+	if arg == "" {
+		return nil, "", usage
+	}
+
+	delim, size := utf8.DecodeRuneInString(arg)
+	var (
+		parts   []string
+		current strings.Builder
+		escaped bool
+	)
+	for _, r := range arg[size:] {
+		switch {
+		case escaped && r == delim:
+			current.WriteRune(r)
+		case escaped:
+			current.WriteRune('\\')
+			current.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		case r == delim:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+		escaped = false
+	}
+	// a missing replacement is empty, /foo/ deletes the matches.
+	if current.Len() > 0 || len(parts) == 1 {
+		parts = append(parts, current.String())
+	}
+	if len(parts) != 2 {
+		return nil, "", usage
+	}
+
+	re, err := regexp.Compile(parts[0])
+	if err != nil {
+		return nil, "", err
+	}
+	return re, unescape(parts[1]), nil
+}
+
+// insertText inserts text at every cursor like typed keys, it replaces the
+// selection. `\n`, `\t` and `\\` are unescaped.
+func (u *Ui) insertText(text string) {
+	text = unescape(text)
+	u.textarea.forEachCursor(func() {
+		u.textarea.DeleteSelection()
+		u.textarea.InsertString(text)
+	})
+}
+
+var unescaper = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\\`, `\`)
+
+// unescape replaces the escapes of the command line arguments.
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
@@ -0,0 +1,33 @@
+package ui
+
+import "testing"
+
+func TestParseReplace(t *testing.T) {
+	tests := []struct {
+		arg         string
+		re          string
+		replacement string
+		ok          bool
+	}{
+		{"/foo/bar/", "foo", "bar", true},
+		{"/foo/bar", "foo", "bar", true},
+		{"/foo//", "foo", "", true},
+		{"/foo/", "foo", "", true},
+		{`|a\|b|c|`, "a|b", "c", true},
+		{"/foo", "", "", false},
+		{"/a/b/c/", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		re, replacement, err := parseReplace(tt.arg)
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("parseReplace(%q) succeeded", tt.arg)
+			}
+			continue
+		}
+		if err != nil || re.String() != tt.re || replacement != tt.replacement {
+			t.Errorf("parseReplace(%q) = %v %q %v, want %s %q", tt.arg, re, replacement, err, tt.re, tt.replacement)
+		}
+	}
+}
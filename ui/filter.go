@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// filterDoneMsg delivers the output of a command the region was piped
+// through.
+type filterDoneMsg struct {
+	command  string
+	document *views.Document
+	// revision the revision of the document when the command started, the
+	// output is dropped if the document has changed since.
+	revision int
+	from, to views.Pos
+	selected bool
+	// trimNewline whether the newline added to the input is removed from the
+	// output.
+	trimNewline bool
+
+	output []byte
+	stderr string
+	err    error
+}
+
+func (u *Ui) askFilterRegion() tea.Cmd {
+	return u.prompt.Ask("Filter through: ", "", u.filterRegion)
+}
+
+// filterRegion pipes the selection, or the whole document, through the shell
+// command, e.g. `sort` or `jq .`, and replaces it with the output.
+func (u *Ui) filterRegion(command string) tea.Cmd {
+	if command = strings.TrimSpace(command); command == "" {
+		return nil
+	}
+
+	from, to, selected := u.textarea.Selection()
+	if !selected {
+		from, to = views.Pos{}, views.Pos{Row: u.document.Height()}
+	}
+	input := u.document.Text(from, to)
+	// most commands expect lines that end with a newline.
+	trimNewline := !strings.HasSuffix(input, "\n")
+	if trimNewline {
+		input += "\n"
+	}
+
+	msg := filterDoneMsg{
+		command:     command,
+		document:    u.document,
+		revision:    u.document.Revision(),
+		from:        from,
+		to:          to,
+		selected:    selected,
+		trimNewline: trimNewline,
+	}
+	u.message(fmt.Sprintf("filtering through %s...", command))
+	return func() tea.Msg {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(shell(), "-c", command)
+		cmd.Stdin = strings.NewReader(input)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+		msg.err = cmd.Run()
+		msg.output, msg.stderr = stdout.Bytes(), stderr.String()
+		return msg
+	}
+}
+
+// handleFilterDone replaces the region with the output of the command, its
+// stderr is shown in a pane.
+func (u *Ui) handleFilterDone(msg filterDoneMsg) {
+	stderr := strings.TrimRight(msg.stderr, "\n")
+	if stderr != "" {
+		u.openPane(NewTextPane(fmt.Sprintf("Stderr of %s", msg.command), strings.Split(stderr, "\n")...))
+		u.focusPane(false)
+	}
+
+	if msg.err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(msg.err, &exitErr) {
+			u.fail(fmt.Errorf("%s failed with exit status %d, the region is unchanged", msg.command, exitErr.ExitCode()))
+		} else {
+			u.fail(fmt.Errorf("%s: %w", msg.command, msg.err))
+		}
+		return
+	}
+	if msg.document.Revision() != msg.revision {
+		u.fail(fmt.Errorf("the document changed while running %s, its output is dropped", msg.command))
+		return
+	}
+
+	output := string(msg.output)
+	if msg.trimNewline {
+		output = strings.TrimSuffix(output, "\n")
+	}
+
+	msg.document.BeginGroup()
+	end := msg.document.Replace(msg.from, msg.to, output)
+	msg.document.EndGroup()
+
+	if msg.document == u.document {
+		u.textarea.ClearCursors()
+		if msg.selected {
+			u.textarea.Select(msg.from, end)
+		} else {
+			cursor := u.textarea.Position()
+			u.textarea.SetPosition(cursor.Row, cursor.Col)
+		}
+	}
+	u.message(fmt.Sprintf("filtered through %s", msg.command))
+}
+
+// shell returns the shell that runs the commands typed by the user.
+func shell() string {
+	if sh := os.Getenv("SHELL"); sh != "" {
+		return sh
+	}
+	return "sh"
+}
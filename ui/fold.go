@@ -0,0 +1,235 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fzdwx/ge/internal/syntax"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+const (
+	// foldWidth the width of the fold indicator in the gutter.
+	foldWidth = 2
+
+	foldedIndicator   = "▸ "
+	foldableIndicator = "▾ "
+)
+
+// foldCache the fold regions of a revision of a document, see syntax.Folds.
+type foldCache struct {
+	document *views.Document
+	revision int
+	typ      string
+	tabWidth int
+	folds    []syntax.Fold
+}
+
+// folds returns the fold regions of the document, they are kept until the
+// document changes.
+func (m *Textarea) folds() []syntax.Fold {
+	c := &m.foldCache
+	if c.document != m.document || c.revision != m.document.Revision() || c.typ != m.document.Type() || c.tabWidth != m.tabWidth() {
+		*c = foldCache{
+			document: m.document,
+			revision: m.document.Revision(),
+			typ:      m.document.Type(),
+			tabWidth: m.tabWidth(),
+			folds:    syntax.Folds(m.document.Type(), m.document.Rows, m.tabWidth()),
+		}
+	}
+	return c.folds
+}
+
+// foldAt returns the fold region starting at row.
+func (m *Textarea) foldAt(row int) (syntax.Fold, bool) {
+	folds := m.folds()
+	i := sort.Search(len(folds), func(i int) bool {
+		return folds[i].Start >= row
+	})
+	if i < len(folds) && folds[i].Start == row {
+		return folds[i], true
+	}
+	return syntax.Fold{}, false
+}
+
+// foldAround returns the innermost unfolded region containing row.
+func (m *Textarea) foldAround(row int) (syntax.Fold, bool) {
+	var (
+		found syntax.Fold
+		ok    bool
+	)
+	for _, fold := range m.folds() {
+		if fold.Start > row {
+			break
+		}
+		if fold.End >= row && !m.folded[fold.Start] {
+			found, ok = fold, true
+		}
+	}
+	return found, ok
+}
+
+// hiddenRows returns whether each row of the document is folded away, nil
+// when nothing is folded.
+func (m *Textarea) hiddenRows() []bool {
+	if len(m.folded) == 0 {
+		return nil
+	}
+
+	hidden := make([]bool, m.document.Height())
+	for _, fold := range m.folds() {
+		if !m.folded[fold.Start] || hidden[fold.Start] {
+			continue
+		}
+		for row := fold.Start + 1; row <= fold.End && row < len(hidden); row++ {
+			hidden[row] = true
+		}
+	}
+	return hidden
+}
+
+// isHidden reports whether row is hidden according to hiddenRows.
+func isHidden(hidden []bool, row int) bool {
+	return row >= 0 && row < len(hidden) && hidden[row]
+}
+
+// visibleRow returns the first row after row in the direction step which is
+// not folded away, false when there is none.
+func (m *Textarea) visibleRow(row, step int) (int, bool) {
+	hidden := m.hiddenRows()
+	for row += step; row >= 0 && row < m.document.Height(); row += step {
+		if !isHidden(hidden, row) {
+			return row, true
+		}
+	}
+	return 0, false
+}
+
+// foldIndicator returns the gutter of row, whether its region is folded or
+// can be folded.
+func (m *Textarea) foldIndicator(row int) string {
+	if _, ok := m.foldAt(row); !ok {
+		return strings.Repeat(" ", foldWidth)
+	}
+	if m.folded[row] {
+		return m.style.LineNumber.Render(foldedIndicator)
+	}
+	return m.style.LineNumber.Render(foldableIndicator)
+}
+
+// foldSummary returns what is shown after the first row of a folded region.
+func (m *Textarea) foldSummary(row int) string {
+	fold, ok := m.foldAt(row)
+	if !ok || !m.folded[row] {
+		return ""
+	}
+	if n := fold.End - fold.Start; n > 1 {
+		return fmt.Sprintf(" ⋯ %d lines", n)
+	}
+	return " ⋯ 1 line"
+}
+
+// Fold folds the innermost unfolded region around the cursor, the cursor
+// moves to its first row. Folding at a folded row folds the region around it.
+func (m *Textarea) Fold() error {
+	fold, ok := m.foldAround(m.row)
+	if !ok {
+		return errors.New("nothing to fold")
+	}
+
+	m.fold(fold)
+	return nil
+}
+
+// fold folds the region, the cursor moves out of it.
+func (m *Textarea) fold(fold syntax.Fold) {
+	if m.folded == nil {
+		m.folded = map[int]bool{}
+	}
+	m.folded[fold.Start] = true
+
+	if m.row > fold.Start && m.row <= fold.End {
+		m.row = fold.Start
+		m.SetCursor(m.col)
+	}
+}
+
+// Unfold unfolds the region at the cursor, or the innermost folded one
+// around it.
+func (m *Textarea) Unfold() error {
+	if m.folded[m.row] {
+		delete(m.folded, m.row)
+		return nil
+	}
+
+	for row := m.row; row >= 0; row-- {
+		if fold, ok := m.foldAt(row); ok && m.folded[row] && fold.End >= m.row {
+			delete(m.folded, row)
+			return nil
+		}
+	}
+	return errors.New("nothing folded here")
+}
+
+// ToggleFold unfolds the region at the cursor when it is folded, and folds
+// the innermost region around the cursor otherwise.
+func (m *Textarea) ToggleFold() error {
+	if m.folded[m.row] {
+		return m.Unfold()
+	}
+	return m.Fold()
+}
+
+// FoldAll folds every region, it returns the number of folded regions.
+func (m *Textarea) FoldAll() int {
+	folds := m.folds()
+	for _, fold := range folds {
+		m.fold(fold)
+	}
+	return len(folds)
+}
+
+// UnfoldAll unfolds every region.
+func (m *Textarea) UnfoldAll() {
+	m.folded = nil
+}
+
+// revealCursor unfolds the regions hiding the cursor, e.g. after a jump.
+func (m *Textarea) revealCursor() {
+	if len(m.folded) == 0 {
+		return
+	}
+	for _, fold := range m.folds() {
+		if fold.Start < m.row && fold.End >= m.row {
+			delete(m.folded, fold.Start)
+		}
+	}
+}
+
+// followFolds keeps the folded regions at their rows while the document is
+// edited, a region whose first row is replaced is unfolded.
+func (m *Textarea) followFolds() {
+	if m.unfollowFolds != nil {
+		m.unfollowFolds()
+	}
+	m.folded = nil
+	m.unfollowFolds = m.document.OnChange(func(change views.Change) {
+		if len(m.folded) == 0 || change.End.Row == change.To.Row && change.From.Row == change.To.Row {
+			return
+		}
+
+		folded := make(map[int]bool, len(m.folded))
+		for row := range m.folded {
+			switch {
+			case row <= change.From.Row:
+				folded[row] = true
+			case row > change.To.Row:
+				folded[row+change.End.Row-change.To.Row] = true
+			}
+		}
+		m.folded = folded
+	})
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/format"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// formattedMsg delivers the output of the formatter of a document.
+type formattedMsg struct {
+	document *views.Document
+	// revision the revision of the document when the formatter started, the
+	// output is dropped if the document has changed since.
+	revision int
+	name     string
+	// save the file the document is saved as once it is formatted, "" when
+	// it is only formatted.
+	save string
+
+	output []byte
+	err    error
+}
+
+// formatDocument returns the cmd that runs the formatter of the syntax type
+// of document, nil when the type has no formatter. The formatter runs in the
+// background, a slow one doesn't block the editor.
+func (u *Ui) formatDocument(document *views.Document, save string) tea.Cmd {
+	f, ok := format.For(document.Type())
+	if !ok {
+		return nil
+	}
+
+	msg := formattedMsg{document: document, revision: document.Revision(), name: f.Name, save: save}
+	filename, src := document.Filename(), document.Bytes()
+	return func() tea.Msg {
+		msg.output, msg.err = f.Format(context.Background(), filename, src)
+		return msg
+	}
+}
+
+// applyFormat applies the output of the formatter as a diff so that the
+// cursors and the undo history are kept. The document is unchanged when the
+// formatter failed or the document changed while it ran.
+func (u *Ui) applyFormat(msg formattedMsg) error {
+	if msg.err != nil {
+		return msg.err
+	}
+	if msg.document.Revision() != msg.revision {
+		return fmt.Errorf("the document changed while running %s, its output is dropped", msg.name)
+	}
+	rows, err := views.NewRows(msg.output)
+	if err != nil {
+		return fmt.Errorf("%s: %w", msg.name, err)
+	}
+
+	rewrite := func() {
+		msg.document.Rewrite(rows.Lines())
+	}
+	if msg.document == u.document {
+		u.textarea.FollowEdits(rewrite)
+	} else {
+		rewrite()
+	}
+	return nil
+}
+
+// handleFormatted applies the output of the formatter, and saves the
+// document when it was formatted on save.
+func (u *Ui) handleFormatted(msg formattedMsg) tea.Cmd {
+	err := u.applyFormat(msg)
+	if msg.save != "" {
+		return u.write(msg.document, msg.save, err)
+	}
+	if err != nil {
+		u.fail(u.formatError(err))
+	} else {
+		u.message("formatted")
+	}
+	return nil
+}
+
+// format formats the current document.
+func (u *Ui) format() tea.Cmd {
+	cmd := u.formatDocument(u.document, "")
+	if cmd == nil {
+		u.fail(fmt.Errorf("no formatter for the %s files", u.document.Type()))
+		return nil
+	}
+	u.message("formatting...")
+	return cmd
+}
+
+// formatError returns the first line of err, the whole of it is shown in a
+// pane when the formatter reported more than one error.
+func (u *Ui) formatError(err error) error {
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) > 1 {
+		u.openPane(NewTextPane("Formatter errors", lines...))
+		u.focusPane(false)
+	}
+	return errors.New(lines[0])
+}
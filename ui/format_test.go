@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/fzdwx/ge/config"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+func TestUi_save_Format(t *testing.T) {
+	if _, err := exec.LookPath("gofmt"); err != nil {
+		t.Skip("needs gofmt")
+	}
+
+	tests := []struct {
+		name string
+		// edit edits the document while the formatter runs.
+		edit bool
+		want string
+	}{
+		{"formatted", false, "package main\n\nvar x = 1\n"},
+		{"edited", true, "// x\npackage main\nvar   x=1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := writeFile(t, "main.go", "package main\nvar   x=1\n")
+			u := newTestUi(t, config.New([]string{filename}))
+
+			cmd := u.save("")
+			if cmd == nil {
+				t.Fatal("save() formatted the document in the update")
+			}
+			if data, _ := os.ReadFile(filename); string(data) != "package main\nvar   x=1\n" {
+				t.Fatalf("save() wrote %q before the formatter was done", data)
+			}
+			if tt.edit {
+				u.document.Replace(views.Pos{}, views.Pos{}, "// x\n")
+			}
+
+			msg := cmd()
+			if _, ok := msg.(formattedMsg); !ok {
+				t.Fatalf("unexpected message %#v", msg)
+			}
+			u.Update(msg)
+			if data, _ := os.ReadFile(filename); string(data) != tt.want {
+				t.Errorf("saved %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
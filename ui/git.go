@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fzdwx/ge/internal/diff"
+	"github.com/fzdwx/ge/internal/git"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+var (
+	gitAddedSign    = Sign{Char: "┃", Style: lipgloss.NewStyle().Foreground(lipgloss.Color("2"))}
+	gitModifiedSign = Sign{Char: "┃", Style: lipgloss.NewStyle().Foreground(lipgloss.Color("4"))}
+	gitDeletedSign  = Sign{Char: "▁", Style: lipgloss.NewStyle().Foreground(lipgloss.Color("1"))}
+
+	hunkHeaderStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	hunkRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	hunkAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+)
+
+type (
+	// gitHeadMsg delivers the content of a document in the HEAD commit.
+	gitHeadMsg struct {
+		document *views.Document
+		head     []string
+		err      error
+	}
+
+	// gitChanges the changes of a document compared to HEAD.
+	gitChanges struct {
+		head []string
+		// revision is the document revision the hunks are computed from.
+		revision int
+		hunks    []diff.Hunk
+	}
+)
+
+// loadGitHead reads the HEAD version of document in the background.
+func loadGitHead(document *views.Document) tea.Cmd {
+	filename := document.Filename()
+	if filename == "" {
+		return nil
+	}
+
+	return func() tea.Msg {
+		data, err := git.Head(filename)
+		if err != nil {
+			return gitHeadMsg{document: document, err: err}
+		}
+
+		rows, err := views.NewRows(data)
+		return gitHeadMsg{document: document, head: rows.Lines(), err: err}
+	}
+}
+
+func (u *Ui) handleGitHead(msg gitHeadMsg) {
+	if msg.err != nil {
+		delete(u.git, msg.document)
+		return
+	}
+	u.git[msg.document] = &gitChanges{head: msg.head, revision: -1}
+}
+
+// refreshGitSigns recomputes the changes of the current document when it has
+// been edited, and shows them in the gutter.
+func (u *Ui) refreshGitSigns() {
+	changes, ok := u.git[u.document]
+	if !ok {
+		u.textarea.SetSigns(nil)
+		return
+	}
+
+	if changes.revision == u.document.Revision() {
+		return
+	}
+	changes.revision = u.document.Revision()
+	changes.hunks = diff.Lines(changes.head, u.document.Lines())
+
+	signs := map[int]Sign{}
+	for _, hunk := range changes.hunks {
+		if hunk.B == hunk.BEnd {
+			if _, ok := signs[hunkRow(hunk)]; !ok {
+				signs[hunkRow(hunk)] = gitDeletedSign
+			}
+			continue
+		}
+
+		sign := gitModifiedSign
+		if hunk.A == hunk.AEnd {
+			sign = gitAddedSign
+		}
+		for row := hunk.B; row < hunk.BEnd; row++ {
+			signs[row] = sign
+		}
+	}
+	u.textarea.SetSigns(signs)
+}
+
+// hunkRow returns the first row of hunk in the document, deleted lines are
+// shown on the row above them.
+func hunkRow(hunk diff.Hunk) int {
+	if hunk.B == hunk.BEnd {
+		return max(0, hunk.B-1)
+	}
+	return hunk.B
+}
+
+// hunkAtCursor returns the hunk the cursor is in.
+func (u *Ui) hunkAtCursor() (*gitChanges, diff.Hunk, bool) {
+	changes, ok := u.git[u.document]
+	if !ok {
+		u.message("not a git tracked file")
+		return nil, diff.Hunk{}, false
+	}
+
+	row := u.textarea.Position().Row
+	for _, hunk := range changes.hunks {
+		if row == hunkRow(hunk) || (row >= hunk.B && row < hunk.BEnd) {
+			return changes, hunk, true
+		}
+	}
+
+	u.message("no change at the cursor")
+	return nil, diff.Hunk{}, false
+}
+
+// gotoHunk moves the cursor to the next hunk in dir direction, wrapping around
+// the document.
+func (u *Ui) gotoHunk(dir int) {
+	changes, ok := u.git[u.document]
+	if !ok || len(changes.hunks) <= 0 {
+		u.message("no changes")
+		return
+	}
+
+	var (
+		row    = u.textarea.Position().Row
+		hunks  = changes.hunks
+		target = -1
+	)
+	if dir > 0 {
+		target = hunkRow(hunks[0])
+		for _, hunk := range hunks {
+			if hunkRow(hunk) > row {
+				target = hunkRow(hunk)
+				break
+			}
+		}
+	} else {
+		target = hunkRow(hunks[len(hunks)-1])
+		for i := len(hunks) - 1; i >= 0; i-- {
+			if hunkRow(hunks[i]) < row {
+				target = hunkRow(hunks[i])
+				break
+			}
+		}
+	}
+
+	u.textarea.SetPosition(target, 0)
+}
+
+// previewHunk shows the hunk at the cursor in a pane.
+func (u *Ui) previewHunk() {
+	changes, hunk, ok := u.hunkAtCursor()
+	if !ok {
+		return
+	}
+
+	lines := u.document.Lines()
+	preview := NewTextPane(fmt.Sprintf("Hunk of %s", relative(u.document.Filename())),
+		hunkHeaderStyle.Render(fmt.Sprintf("@@ -%d,%d +%d,%d @@", hunk.A+1, hunk.AEnd-hunk.A, hunk.B+1, hunk.BEnd-hunk.B)))
+	for _, line := range changes.head[hunk.A:hunk.AEnd] {
+		preview.Append(hunkRemovedStyle.Render("-" + line))
+	}
+	for _, line := range lines[hunk.B:hunk.BEnd] {
+		preview.Append(hunkAddedStyle.Render("+" + line))
+	}
+
+	u.openPane(preview)
+	u.focusPane(false)
+}
+
+// revertHunk replaces the hunk at the cursor with the HEAD version.
+func (u *Ui) revertHunk() {
+	changes, hunk, ok := u.hunkAtCursor()
+	if !ok {
+		return
+	}
+
+	u.document.ReplaceLines(hunk.B, hunk.BEnd, changes.head[hunk.A:hunk.AEnd])
+	u.textarea.SetPosition(hunk.B, 0)
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fzdwx/ge/internal/syntax"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// newline splits the row at the cursor, the new row is indented like the
+// current one and by one more level after an opening bracket. A closing
+// bracket right after the cursor goes to a row of its own. In Markdown the
+// lists are continued, see continueList.
+func (m *Textarea) newline() {
+	if m.isMarkdown() && m.continueList() {
+		return
+	}
+
+	row := m.document.Row(m.row)
+	indent := string(row[:min(row.Indentation(), m.col)])
+
+	// the spaces around the cursor are dropped.
+	from, to := m.col, m.col
+	for from > 0 && isBlank(row[from-1]) {
+		from--
+	}
+	for to < len(row) && isBlank(row[to]) {
+		to++
+	}
+	if from < row.Indentation() {
+		// the row is blank before the cursor, it keeps its indentation.
+		from = m.col
+	}
+
+	text := "\n" + indent
+	cursor := views.Pos{Row: m.row + 1, Col: len([]rune(indent))}
+	if from > 0 {
+		if closing, ok := syntax.Brackets[row[from-1]]; ok {
+			inner := indent + m.document.Indent().Unit()
+			text = "\n" + inner
+			cursor.Col = len([]rune(inner))
+			if to < len(row) && row[to] == closing {
+				text += "\n" + indent
+			}
+		}
+	}
+
+	m.document.Replace(views.Pos{Row: m.row, Col: from}, views.Pos{Row: m.row, Col: to}, text)
+	m.row = cursor.Row
+	m.SetCursor(cursor.Col)
+}
+
+// insertIndent inserts a level of indentation at the cursor, with spaces
+// the cursor moves to the next multiple of the indent size.
+func (m *Textarea) insertIndent() {
+	indent := m.document.Indent()
+	if indent.Tabs || indent.Size <= 0 {
+		m.InsertString("\t")
+		return
+	}
+
+	width := m.document.Row(m.row).Width(m.col, indent.TabWidth)
+	m.InsertString(strings.Repeat(" ", indent.Size-width%indent.Size))
+}
+
+// outdentRow removes a level of indentation of the current row, the cursor
+// stays on the same rune.
+func (m *Textarea) outdentRow() {
+	before := m.currentRowLen()
+	m.document.OutdentLines(m.row, m.row, m.document.Indent().Size)
+	m.SetCursor(m.col - (before - m.currentRowLen()))
+}
+
+func isBlank(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// setIndent sets the indentation of the document from arg, `tabs [width]` or
+// `spaces [size]`, an empty arg shows it.
+func (u *Ui) setIndent(arg string) error {
+	indent := u.document.Indent()
+	fields := strings.Fields(arg)
+	if len(fields) == 0 {
+		u.message(describeIndent(indent))
+		return nil
+	}
+	if len(fields) > 2 {
+		return fmt.Errorf("usage: set-indent tabs|spaces [size]")
+	}
+
+	size := 0
+	if len(fields) == 2 {
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid indent size: %s", fields[1])
+		}
+		size = n
+	}
+
+	switch fields[0] {
+	case "tabs":
+		indent.Tabs = true
+		if size > 0 {
+			indent.Size, indent.TabWidth = size, size
+		}
+	case "spaces":
+		indent.Tabs = false
+		if size > 0 {
+			indent.Size = size
+		}
+	default:
+		return fmt.Errorf("usage: set-indent tabs|spaces [size]")
+	}
+
+	u.document.SetIndent(indent)
+	u.message(describeIndent(indent))
+	return nil
+}
+
+func describeIndent(indent syntax.Indent) string {
+	if indent.Tabs {
+		return fmt.Sprintf("indent with tabs of width %d", indent.TabWidth)
+	}
+	return fmt.Sprintf("indent with %d spaces", indent.Size)
+}
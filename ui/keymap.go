@@ -6,7 +6,24 @@ import (
 )
 
 type Keymap struct {
-	quit key.Binding
+	quit          key.Binding
+	save          key.Binding
+	command       key.Binding
+	searchInFiles key.Binding
+	otherPane     key.Binding
+	closePane     key.Binding
+	nextHunk      key.Binding
+	prevHunk      key.Binding
+	previewHunk   key.Binding
+	revertHunk    key.Binding
+	recordMacro   key.Binding
+	stopMacro     key.Binding
+	playMacro     key.Binding
+	filterRegion  key.Binding
+	terminal      key.Binding
+	compile       key.Binding
+	nextError     key.Binding
+	prevError     key.Binding
 }
 
 func NewKeymap() *Keymap {
@@ -15,5 +32,73 @@ func NewKeymap() *Keymap {
 			key.WithKeys(tea.KeyCtrlC.String()),
 			key.WithHelp(tea.KeyCtrlC.String(), "quit program"),
 		),
+		save: key.NewBinding(
+			key.WithKeys(tea.KeyCtrlS.String()),
+			key.WithHelp(tea.KeyCtrlS.String(), "save the document"),
+		),
+		command: key.NewBinding(
+			key.WithKeys("alt+x"),
+			key.WithHelp("alt+x", "execute command"),
+		),
+		searchInFiles: key.NewBinding(
+			key.WithKeys("alt+g"),
+			key.WithHelp("alt+g", "search in files"),
+		),
+		otherPane: key.NewBinding(
+			key.WithKeys("alt+o"),
+			key.WithHelp("alt+o", "switch focus between the editor and the pane"),
+		),
+		closePane: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "close the pane"),
+		),
+		nextHunk: key.NewBinding(
+			key.WithKeys("alt+j"),
+			key.WithHelp("alt+j", "go to the next git change"),
+		),
+		prevHunk: key.NewBinding(
+			key.WithKeys("alt+k"),
+			key.WithHelp("alt+k", "go to the previous git change"),
+		),
+		previewHunk: key.NewBinding(
+			key.WithKeys("alt+h"),
+			key.WithHelp("alt+h", "preview the git change at the cursor"),
+		),
+		revertHunk: key.NewBinding(
+			key.WithKeys("alt+r"),
+			key.WithHelp("alt+r", "revert the git change at the cursor"),
+		),
+		recordMacro: key.NewBinding(
+			key.WithKeys("alt+("),
+			key.WithHelp("alt+(", "start recording a macro"),
+		),
+		stopMacro: key.NewBinding(
+			key.WithKeys("alt+)"),
+			key.WithHelp("alt+)", "stop recording the macro"),
+		),
+		playMacro: key.NewBinding(
+			key.WithKeys("alt+e"),
+			key.WithHelp("alt+e", "play the last macro"),
+		),
+		filterRegion: key.NewBinding(
+			key.WithKeys("alt+|"),
+			key.WithHelp("alt+|", "pipe the selection or the document through a command"),
+		),
+		terminal: key.NewBinding(
+			key.WithKeys("alt+t"),
+			key.WithHelp("alt+t", "open the terminal"),
+		),
+		compile: key.NewBinding(
+			key.WithKeys("f5"),
+			key.WithHelp("f5", "run the compile command"),
+		),
+		nextError: key.NewBinding(
+			key.WithKeys("alt+."),
+			key.WithHelp("alt+.", "go to the next error"),
+		),
+		prevError: key.NewBinding(
+			key.WithKeys("alt+,"),
+			key.WithHelp("alt+,", "go to the previous error"),
+		),
 	}
 }
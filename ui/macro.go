@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/macro"
+	"github.com/fzdwx/ge/internal/teax"
+)
+
+// defaultMacro the register of a macro recorded without a name.
+const defaultMacro = "q"
+
+// macros are the keyboard macros, sequences of keys recorded into named
+// registers and replayed.
+type macros struct {
+	saved map[string]macro.Macro
+
+	// recording whether the keys are recorded into the register name.
+	recording bool
+	name      string
+	keys      macro.Macro
+
+	// last the register of the last recorded or played macro.
+	last string
+	// playing whether a macro is being played, macros don't play other
+	// macros.
+	playing bool
+}
+
+// isMacroKey reports whether msg records or plays a macro, these keys are
+// never recorded.
+func (u *Ui) isMacroKey(msg tea.KeyMsg) bool {
+	return key.Matches(msg, u.Keymap.recordMacro, u.Keymap.stopMacro, u.Keymap.playMacro)
+}
+
+// recordKey adds msg to the macro being recorded.
+func (u *Ui) recordKey(msg tea.KeyMsg) {
+	if u.macros.recording && !u.isMacroKey(msg) {
+		u.macros.keys = append(u.macros.keys, msg)
+	}
+}
+
+func (u *Ui) askRecordMacro() tea.Cmd {
+	if u.macros.recording {
+		u.message(fmt.Sprintf("already recording macro %s", u.macros.name))
+		return nil
+	}
+
+	return u.prompt.Ask("Record macro: ", defaultMacro, func(name string) tea.Cmd {
+		u.recordMacro(name)
+		return nil
+	})
+}
+
+// recordMacro starts recording the keys into the register name.
+func (u *Ui) recordMacro(name string) {
+	if name = strings.TrimSpace(name); name == "" {
+		name = defaultMacro
+	}
+
+	u.macros.recording, u.macros.name, u.macros.keys = true, name, nil
+	u.message(fmt.Sprintf("recording macro %s", name))
+}
+
+// stopMacro stops recording and stores the macro in its register.
+func (u *Ui) stopMacro() {
+	if !u.macros.recording {
+		u.message("not recording a macro")
+		return
+	}
+
+	if u.macros.saved == nil {
+		u.macros.saved = map[string]macro.Macro{}
+	}
+	u.macros.saved[u.macros.name] = u.macros.keys
+	u.macros.last = u.macros.name
+	u.macros.recording = false
+	u.message(fmt.Sprintf("recorded macro %s, %d keys", u.macros.name, len(u.macros.keys)))
+}
+
+// playMacro replays the macro of the register name count times, all its
+// edits of the current document are undone together. The commands of a key,
+// e.g. a paste, run before the next key is played.
+func (u *Ui) playMacro(name string, count int) tea.Cmd {
+	if name == "" {
+		name = u.macros.last
+	}
+
+	keys, ok := u.macros.saved[name]
+	if !ok {
+		u.fail(fmt.Errorf("no macro %s", name))
+		return nil
+	}
+	if u.macros.playing {
+		return nil
+	}
+
+	u.macros.playing, u.macros.last = true, name
+	defer func() { u.macros.playing = false }()
+	restore := u.stillCursors()
+
+	document := u.document
+	document.BeginGroup()
+	defer document.EndGroup()
+
+	batch := teax.Batch()
+	for i := 0; i < count; i++ {
+		for _, msg := range keys {
+			_, cmd := u.Update(msg)
+			batch.Append(u.settle(cmd, settleTimeout))
+		}
+	}
+	return batch.Append(restore()).Cmd()
+}
+
+// loadMacros reads the macros from filename, or the default macro file, the
+// loaded macros replace the ones with the same name.
+func (u *Ui) loadMacros(filename string) error {
+	filename, err := macroFile(filename)
+	if err != nil {
+		return err
+	}
+
+	loaded, err := macro.Load(filename)
+	if err != nil {
+		return err
+	}
+
+	if u.macros.saved == nil {
+		u.macros.saved = map[string]macro.Macro{}
+	}
+	for name, keys := range loaded {
+		u.macros.saved[name] = keys
+	}
+	return nil
+}
+
+// saveMacros writes all macros to filename, or the default macro file.
+func (u *Ui) saveMacros(filename string) error {
+	filename, err := macroFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := macro.Save(filename, u.macros.saved); err != nil {
+		return err
+	}
+	u.message(fmt.Sprintf("saved %d macros to %s", len(u.macros.saved), filename))
+	return nil
+}
+
+func macroFile(filename string) (string, error) {
+	if filename != "" {
+		return filename, nil
+	}
+	return macro.Path()
+}
+
+// parseMacroArgs parses the arguments of play-macro, `[name] [count]`.
+func parseMacroArgs(arg string) (string, int, error) {
+	fields := strings.Fields(arg)
+	name, count := "", 1
+	if len(fields) > 0 {
+		name = fields[0]
+	}
+	if len(fields) > 1 {
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 {
+			return "", 0, fmt.Errorf("invalid count: %s", fields[1])
+		}
+		count = n
+	}
+	return name, count, nil
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/fzdwx/ge/config"
+	"github.com/fzdwx/ge/internal/clipboard"
+	"github.com/fzdwx/ge/internal/macro"
+)
+
+func TestUi_playMacro_Paste(t *testing.T) {
+	u := newTestUi(t, config.New([]string{writeFile(t, "a.txt", "")}))
+	u.textarea.Registers = NewRegisters(&clipboard.Memory{})
+	_ = u.textarea.Registers.Clipboard.Write("X")
+	u.macros.saved = map[string]macro.Macro{
+		"p": {macro.ParseKey("a"), macro.ParseKey("ctrl+v"), macro.ParseKey("b")},
+	}
+
+	cmd := u.playMacro("p", 2)
+	if got := u.document.String(); got != "aXbaXb" {
+		t.Errorf("document = %q, want the pastes between the keys", got)
+	}
+	runCmd(u, cmd)
+	if got := u.document.String(); got != "aXbaXb" {
+		t.Errorf("document = %q after the commands of the macro", got)
+	}
+
+	u.textarea.Undo()
+	if got := u.document.String(); got != "" {
+		t.Errorf("Undo() = %q, want the whole macro undone", got)
+	}
+}
@@ -0,0 +1,228 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/markdown"
+	"github.com/fzdwx/ge/internal/syntax"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+var errNotMarkdown = errors.New("the document is not Markdown")
+
+// isMarkdown reports whether the document is Markdown.
+func (m *Textarea) isMarkdown() bool {
+	return m.document.Type() == "md"
+}
+
+// continueList starts the next list item when the cursor is in the text of
+// an item, an empty item ends the list instead. The ordered items after it
+// are renumbered. It returns false if the cursor isn't in an item.
+func (m *Textarea) continueList() bool {
+	row := m.document.Row(m.row)
+	item, ok := markdown.ParseListItem(row.String())
+	if !ok || m.col < item.Content {
+		return false
+	}
+
+	if strings.TrimSpace(string(row[item.Content:])) == "" {
+		m.document.Replace(views.Pos{Row: m.row}, views.Pos{Row: m.row, Col: len(row)}, "")
+		m.SetCursor(0)
+		return true
+	}
+
+	// the spaces around the cursor are dropped.
+	from, to := m.col, m.col
+	for from > item.Content && isBlank(row[from-1]) {
+		from--
+	}
+	for to < len(row) && isBlank(row[to]) {
+		to++
+	}
+
+	next := item.Next()
+	m.document.Replace(views.Pos{Row: m.row, Col: from}, views.Pos{Row: m.row, Col: to}, "\n"+next.Marker())
+	m.row++
+	m.SetCursor(next.Content)
+	m.renumberList(m.row)
+	return true
+}
+
+// renumberList numbers the ordered items after the one at row consecutively.
+func (m *Textarea) renumberList(row int) {
+	for r, line := range markdown.Renumber(m.document.Lines(), row) {
+		m.document.Replace(views.Pos{Row: r}, views.Pos{Row: r, Col: len(m.document.Row(r))}, line)
+	}
+}
+
+// AlignTable pads the cells of the table at the cursor so that its columns
+// line up, the cursor stays in its cell. It returns false if the cursor
+// isn't in a table.
+func (m *Textarea) AlignTable() bool {
+	lines := m.document.Lines()
+	start, end, ok := markdown.TableAt(lines, m.row)
+	if !ok {
+		return false
+	}
+
+	cell, offset := markdown.Cell(lines[m.row], m.col)
+	aligned := markdown.AlignTable(lines[start:end])
+	for i, line := range aligned {
+		if row := start + i; line != lines[row] {
+			m.document.Replace(views.Pos{Row: row}, views.Pos{Row: row, Col: len(m.document.Row(row))}, line)
+		}
+	}
+	m.SetCursor(markdown.CellCol(aligned[m.row-start], cell, offset))
+	return true
+}
+
+// nextTableCell aligns the table at the cursor and moves to the next cell,
+// the first one of the next row after the last cell of a row. It returns
+// false if the cursor isn't in a table.
+func (m *Textarea) nextTableCell() bool {
+	if !m.AlignTable() {
+		return false
+	}
+
+	line := m.document.Row(m.row).String()
+	cell, _ := markdown.Cell(line, m.col)
+	if col := markdown.CellCol(line, cell+1, 0); col < len([]rune(line)) {
+		m.SetCursor(col)
+		return true
+	}
+
+	if _, end, _ := markdown.TableAt(m.document.Lines(), m.row); m.row+1 < end {
+		m.row++
+		m.SetCursor(markdown.CellCol(m.document.Row(m.row).String(), 0, 0))
+	}
+	return true
+}
+
+// ToggleCheckbox ticks or clears the task of the list item at the cursor,
+// an item without a checkbox gets one.
+func (m *Textarea) ToggleCheckbox() error {
+	line, ok := markdown.ToggleCheckbox(m.document.Row(m.row).String())
+	if !ok {
+		return errors.New("not a list item")
+	}
+
+	before := m.currentRowLen()
+	m.document.Replace(views.Pos{Row: m.row}, views.Pos{Row: m.row, Col: before}, line)
+	m.SetCursor(m.col + m.currentRowLen() - before)
+	return nil
+}
+
+// markdownHeadings returns the headings of the document, an error unless it
+// is Markdown.
+func (u *Ui) markdownHeadings() ([]syntax.Heading, error) {
+	if u.document.Type() != "md" {
+		return nil, errNotMarkdown
+	}
+	return syntax.Headings(u.document.Rows), nil
+}
+
+// gotoHeading moves the cursor to the next heading in the direction dir,
+// 1 or -1.
+func (u *Ui) gotoHeading(dir int) error {
+	headings, err := u.markdownHeadings()
+	if err != nil {
+		return err
+	}
+
+	row := u.textarea.Position().Row
+	if dir < 0 {
+		for i := len(headings) - 1; i >= 0; i-- {
+			if headings[i].Row < row {
+				u.textarea.SetPosition(headings[i].Row, 0)
+				return nil
+			}
+		}
+		return errors.New("no heading above")
+	}
+
+	for _, h := range headings {
+		if h.Row > row {
+			u.textarea.SetPosition(h.Row, 0)
+			return nil
+		}
+	}
+	return errors.New("no heading below")
+}
+
+// outline lists the headings of the document in a pane.
+func (u *Ui) outline() error {
+	headings, err := u.markdownHeadings()
+	if err != nil {
+		return err
+	}
+
+	items := make([]outlineItem, len(headings))
+	for i, h := range headings {
+		items[i] = outlineItem{label: h.Text, depth: h.Level - 1, row: h.Row}
+	}
+	u.openPane(NewOutlinePane("Outline", u.document.Filename(), items, u.textarea.Position().Row))
+	return nil
+}
+
+// followLink opens the file of the link at the cursor, the anchor of the
+// link is a heading of a Markdown file or a line, e.g. #L12.
+func (u *Ui) followLink() tea.Cmd {
+	pos := u.textarea.Position()
+	target, ok := markdown.LinkAt(u.document.Row(pos.Row).String(), pos.Col)
+	if !ok {
+		u.fail(errors.New("no link at the cursor"))
+		return nil
+	}
+	if strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+		u.fail(fmt.Errorf("not a link to a file: %s", target))
+		return nil
+	}
+
+	path, anchor, _ := strings.Cut(target, "#")
+	path, err := url.PathUnescape(path)
+	if err != nil {
+		u.fail(err)
+		return nil
+	}
+
+	filename := u.document.Filename()
+	if path != "" {
+		filename = filepath.Join(filepath.Dir(filename), filepath.FromSlash(path))
+	}
+	document, err := u.open(filename)
+	if err != nil {
+		u.fail(err)
+		return nil
+	}
+
+	row, err := anchorRow(document, anchor)
+	if err != nil {
+		u.fail(err)
+		return nil
+	}
+	return u.jump(jumpMsg{Filename: filename, Row: row})
+}
+
+// anchorRow returns the row of the anchor of a link in document, the first
+// row when anchor is empty.
+func anchorRow(document *views.Document, anchor string) (int, error) {
+	if anchor == "" {
+		return 0, nil
+	}
+	if line, err := strconv.Atoi(strings.TrimPrefix(anchor, "L")); err == nil && strings.HasPrefix(anchor, "L") {
+		return line - 1, nil
+	}
+
+	for _, h := range syntax.Headings(document.Rows) {
+		if markdown.Slug(h.Text) == strings.ToLower(anchor) {
+			return h.Row, nil
+		}
+	}
+	return 0, fmt.Errorf("no heading #%s in %s", anchor, filepath.Base(document.Filename()))
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/x/str"
+	rw "github.com/mattn/go-runewidth"
+)
+
+type (
+	// outlineItem is an entry of the outline, e.g. a heading.
+	outlineItem struct {
+		label string
+		// depth the nesting of the item, it is indented by it.
+		depth int
+		row   int
+	}
+
+	// OutlinePane lists the structure of a document, enter jumps to the
+	// selected item.
+	OutlinePane struct {
+		title    string
+		filename string
+		items    []outlineItem
+
+		// selected is the index of the selected item.
+		selected int
+		// offset is the index of the first visible item.
+		offset int
+
+		width  int
+		height int
+	}
+)
+
+// NewOutlinePane lists the items of the document filename, the last one at
+// or before row is selected.
+func NewOutlinePane(title, filename string, items []outlineItem, row int) *OutlinePane {
+	p := &OutlinePane{title: title, filename: filename, items: items}
+	for i, item := range items {
+		if item.row <= row {
+			p.selected = i
+		}
+	}
+	return p
+}
+
+func (p *OutlinePane) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.move(0)
+}
+
+// move selects the item n after the selected one, the view follows it.
+func (p *OutlinePane) move(n int) {
+	p.selected = clamp(p.selected+n, 0, max(0, len(p.items)-1))
+
+	visible := max(p.height-1, 1)
+	if p.selected < p.offset {
+		p.offset = p.selected
+	} else if p.selected >= p.offset+visible {
+		p.offset = p.selected - visible + 1
+	}
+}
+
+func (p *OutlinePane) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, resultsUp):
+			p.move(-1)
+		case key.Matches(msg, resultsDown):
+			p.move(1)
+		case key.Matches(msg, resultsEnter):
+			if len(p.items) == 0 {
+				return nil
+			}
+			item := p.items[p.selected]
+			return func() tea.Msg {
+				return jumpMsg{Filename: p.filename, Row: item.row}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *OutlinePane) View() string {
+	fluent := str.NewFluent()
+	fluent.Str(paneTitleStyle.Render(rw.Truncate(fmt.Sprintf("%s  %d items", p.title, len(p.items)), p.width, "")))
+
+	for i := p.offset; i < p.offset+p.height-1; i++ {
+		fluent.NewLine()
+		if i >= len(p.items) {
+			continue
+		}
+
+		item := p.items[i]
+		line := rw.Truncate(fmt.Sprintf("%s%s  %d", strings.Repeat("  ", item.depth), item.label, item.row+1), p.width, "")
+		if i == p.selected {
+			fluent.Str(resultCursorStyle.Render(line))
+		} else {
+			fluent.Str(line)
+		}
+	}
+	return fluent.String()
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+)
+
+// resizedMsg reports the size of the terminal of the Output, it is passed on
+// as a tea.WindowSizeMsg.
+type resizedMsg tea.WindowSizeMsg
+
+// Output is the terminal the Ui is rendered to. Its writes are serialized,
+// so an escape sequence written by a command, e.g. clipboard.OSC52, can't
+// interleave with a frame of the renderer. The program only watches the size
+// of a file, the Ui watches the size of the Output, see waitResize.
+type Output struct {
+	mu      sync.Mutex
+	file    *os.File
+	resized chan os.Signal
+}
+
+// NewOutput creates an Output writing to the terminal file.
+func NewOutput(file *os.File) *Output {
+	o := &Output{file: file, resized: make(chan os.Signal, 1)}
+	notifyResize(o.resized)
+	return o
+}
+
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.file.Write(p)
+}
+
+// Close stops watching the size of the terminal.
+func (o *Output) Close() {
+	signal.Stop(o.resized)
+	close(o.resized)
+}
+
+// size returns a command reporting the size of the terminal, nothing if the
+// output isn't one.
+func (o *Output) size() tea.Cmd {
+	return func() tea.Msg {
+		width, height, err := term.GetSize(int(o.file.Fd()))
+		if err != nil {
+			return nil
+		}
+		return resizedMsg{Width: width, Height: height}
+	}
+}
+
+// waitResize waits until the terminal of o is resized.
+func waitResize(o *Output) tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-o.resized; !ok {
+			return nil
+		}
+		return o.size()()
+	}
+}
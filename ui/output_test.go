@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fzdwx/ge/config"
+)
+
+func TestUi_Copy_Output(t *testing.T) {
+	// OSC 52 comes first over SSH.
+	t.Setenv("SSH_TTY", "/dev/pts/0")
+	t.Setenv("TMUX", "")
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdout := os.Stdout
+	os.Stdout = f
+	u := newTestUi(t, config.New(nil))
+	os.Stdout = stdout
+
+	runCmd(u, u.textarea.Copy("copy"))
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "\x1b]52;c;Y29weQ==\a") {
+		t.Errorf("output = %q, want the clipboard sequence", b)
+	}
+}
+
+func TestUi_Resized(t *testing.T) {
+	u := newTestUi(t, config.New(nil))
+	_, cmd := u.Update(resizedMsg{Width: 100, Height: 30})
+	runCmd(u, cmd)
+	if u.width != 100 || u.height != 30 {
+		t.Errorf("size = %dx%d, want the resized terminal", u.width, u.height)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package ui
+
+import "os"
+
+// notifyResize does nothing, Windows has no signal for the size of the
+// console.
+func notifyResize(chan os.Signal) {}
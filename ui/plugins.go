@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/rpcplugin"
+	"github.com/fzdwx/ge/internal/teax"
+	"github.com/fzdwx/ge/internal/views"
+	"github.com/fzdwx/ge/plugin/jsonrpc"
+	"github.com/fzdwx/ge/plugin/protocol"
+)
+
+type (
+	// pluginsStartedMsg reports the plugins started in the background.
+	pluginsStartedMsg struct {
+		plugins []*rpcplugin.Plugin
+		err     error
+	}
+
+	// pluginRequestMsg is a request of a plugin, handled on the Ui goroutine.
+	pluginRequestMsg struct {
+		request *rpcplugin.Request
+	}
+)
+
+// startPlugins starts the executables of the executable plugins directory
+// in the background, see rpcplugin.Dir.
+func (u *Ui) startPlugins() tea.Cmd {
+	requests := u.pluginRequests
+	return func() tea.Msg {
+		dir, err := rpcplugin.Dir()
+		if err != nil {
+			return pluginsStartedMsg{err: err}
+		}
+		paths, err := rpcplugin.Discover(dir)
+		if err != nil {
+			return pluginsStartedMsg{err: err}
+		}
+
+		var (
+			plugins []*rpcplugin.Plugin
+			errs    []string
+		)
+		for _, path := range paths {
+			p, err := rpcplugin.Start(path, requests)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			plugins = append(plugins, p)
+		}
+
+		msg := pluginsStartedMsg{plugins: plugins}
+		if len(errs) > 0 {
+			sort.Strings(errs)
+			msg.err = errors.New(fmt.Sprint(errs))
+		}
+		return msg
+	}
+}
+
+// waitPluginRequest waits for the next request of a plugin.
+func waitPluginRequest(requests <-chan *rpcplugin.Request) tea.Cmd {
+	return func() tea.Msg {
+		return pluginRequestMsg{request: <-requests}
+	}
+}
+
+// handlePluginsStarted registers the commands of the started plugins.
+func (u *Ui) handlePluginsStarted(msg pluginsStartedMsg) tea.Cmd {
+	for _, p := range msg.plugins {
+		p := p
+		u.plugins = append(u.plugins, p)
+		for _, info := range p.Info.Commands {
+			name := info.Name
+			u.RegisterCommand(Command{
+				Name: name,
+				Help: info.Help,
+				Run: func(u *Ui, arg string) tea.Cmd {
+					return u.runPluginCommand(p, name, arg)
+				},
+			})
+		}
+	}
+	return teax.Check(msg.err)
+}
+
+// runPluginCommand runs a command of a plugin in the background.
+func (u *Ui) runPluginCommand(p *rpcplugin.Plugin, name, arg string) tea.Cmd {
+	cursor := u.textarea.Position()
+	params := protocol.RunCommandParams{
+		Name:     name,
+		Arg:      arg,
+		Filename: u.document.Filename(),
+		Version:  u.document.Revision(),
+		Cursor:   protocol.Position{Row: cursor.Row, Col: cursor.Col},
+	}
+	return func() tea.Msg {
+		if err := p.RunCommand(params); err != nil {
+			return teax.ErrorMsg{Err: fmt.Errorf("%s: %w", name, err)}
+		}
+		return nil
+	}
+}
+
+// handlePluginRequest answers a request of a plugin.
+func (u *Ui) handlePluginRequest(r *rpcplugin.Request) {
+	switch r.Method {
+	case protocol.MethodGetDocument:
+		var params protocol.GetDocumentParams
+		if err := json.Unmarshal(r.Params, &params); err != nil {
+			r.Reply(nil, jsonrpc.InvalidParams(err))
+			return
+		}
+
+		document, err := u.pluginDocument(params.Filename)
+		if err != nil {
+			r.Reply(nil, err)
+			return
+		}
+		r.Reply(protocol.GetDocumentResult{
+			Filename: document.Filename(),
+			Version:  document.Revision(),
+			Text:     document.Text(views.Pos{}, views.Pos{Row: document.Height()}),
+		}, nil)
+	case protocol.MethodApplyEdits:
+		var params protocol.ApplyEditsParams
+		if err := json.Unmarshal(r.Params, &params); err != nil {
+			r.Reply(nil, jsonrpc.InvalidParams(err))
+			return
+		}
+		r.Reply(u.applyPluginEdits(params))
+	case protocol.MethodShowMessage:
+		var params protocol.ShowMessageParams
+		if err := json.Unmarshal(r.Params, &params); err == nil {
+			u.message(params.Text)
+		}
+		r.Reply(nil, nil)
+	default:
+		r.Reply(nil, jsonrpc.MethodNotFound(r.Method))
+	}
+}
+
+// pluginDocument returns the open document of filename, or the current one.
+func (u *Ui) pluginDocument(filename string) (*views.Document, error) {
+	if filename == "" {
+		return u.document, nil
+	}
+	for _, document := range u.documents {
+		if sameFile(document.Filename(), filename) {
+			return document, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not open", filename)
+}
+
+// applyPluginEdits applies the edits of a plugin as one undo step.
+func (u *Ui) applyPluginEdits(params protocol.ApplyEditsParams) (protocol.ApplyEditsResult, error) {
+	document, err := u.pluginDocument(params.Filename)
+	if err != nil {
+		return protocol.ApplyEditsResult{}, err
+	}
+	if params.Version != 0 && params.Version != document.Revision() {
+		return protocol.ApplyEditsResult{Version: document.Revision(), Reason: "the document changed"}, nil
+	}
+
+	edits := append([]protocol.Edit(nil), params.Edits...)
+	// the edits refer to the document before any of them, applying them
+	// from the end keeps the positions of the others valid.
+	sort.SliceStable(edits, func(i, j int) bool {
+		return toPos(edits[j].From).Before(toPos(edits[i].From))
+	})
+	for i := 1; i < len(edits); i++ {
+		if toPos(edits[i-1].From).Before(toPos(edits[i].To)) {
+			return protocol.ApplyEditsResult{Version: document.Revision(), Reason: "the edits overlap"}, nil
+		}
+	}
+
+	apply := func() {
+		document.BeginGroup()
+		defer document.EndGroup()
+		for _, e := range edits {
+			document.Replace(toPos(e.From), toPos(e.To), e.Text)
+		}
+	}
+	if document == u.document {
+		u.textarea.FollowEdits(apply)
+	} else {
+		apply()
+	}
+	return protocol.ApplyEditsResult{Applied: true, Version: document.Revision()}, nil
+}
+
+// notifyPluginsChange tells the plugins about a change of document.
+func (u *Ui) notifyPluginsChange(document *views.Document, c views.Change) {
+	var edit *protocol.Edit
+	for _, p := range u.plugins {
+		if !p.Wants(protocol.EventChange) {
+			continue
+		}
+		if edit == nil {
+			edit = &protocol.Edit{
+				From: protocol.Position{Row: c.From.Row, Col: c.From.Col},
+				To:   protocol.Position{Row: c.To.Row, Col: c.To.Col},
+				Text: document.Text(c.From, c.End),
+			}
+		}
+		p.DidChange(protocol.DidChangeParams{Filename: document.Filename(), Version: document.Revision(), Edit: *edit})
+	}
+}
+
+// notifyPluginsSave tells the plugins about the save of document.
+func (u *Ui) notifyPluginsSave(document *views.Document) {
+	for _, p := range u.plugins {
+		if p.Wants(protocol.EventSave) {
+			p.DidSave(protocol.DidSaveParams{Filename: document.Filename()})
+		}
+	}
+}
+
+// closePlugins shuts the plugins down.
+func (u *Ui) closePlugins() error {
+	var errs []string
+	for _, p := range u.plugins {
+		if err := p.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(fmt.Sprint(errs))
+	}
+	return nil
+}
+
+func toPos(p protocol.Position) views.Pos {
+	return views.Pos{Row: p.Row, Col: p.Col}
+}
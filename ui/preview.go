@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/markdown"
+	"github.com/fzdwx/ge/internal/views"
+	"github.com/fzdwx/x/str"
+	rw "github.com/mattn/go-runewidth"
+	"github.com/muesli/reflow/truncate"
+)
+
+// previewedMsg reports that a preview was rendered in the background.
+type previewedMsg struct{}
+
+// PreviewPane shows the rendered Markdown of a document beside the
+// textarea, it follows the changes of the document and the heading of the
+// cursor. The document is rendered in the background, the last preview is
+// shown meanwhile.
+type PreviewPane struct {
+	document *views.Document
+	row      int
+
+	// rendered is signaled when a preview was rendered, see waitPreview.
+	rendered chan<- struct{}
+	renderer markdown.Renderer
+
+	mu sync.Mutex
+	// previewed the state of the document rendered in preview.
+	previewed previewState
+	preview   markdown.Preview
+	err       error
+	// rendering whether a render is running, there is one at a time.
+	rendering bool
+
+	width  int
+	height int
+}
+
+// previewState a revision of a document rendered at a width.
+type previewState struct {
+	document *views.Document
+	revision int
+	width    int
+}
+
+func NewPreviewPane(rendered chan<- struct{}) *PreviewPane {
+	return &PreviewPane{rendered: rendered}
+}
+
+func (p *PreviewPane) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Sync starts rendering the document unless it is rendered already, and
+// follows the heading of the row of the cursor.
+func (p *PreviewPane) Sync(document *views.Document, row int) {
+	p.document, p.row = document, row
+	if document.Type() != "md" {
+		return
+	}
+
+	// the margin separates the preview from the textarea.
+	state := previewState{document: document, revision: document.Revision(), width: max(p.width-1, 1)}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rendering || p.previewed == state {
+		return
+	}
+
+	p.rendering = true
+	lines := document.Lines()
+	go func() {
+		preview, err := p.renderer.Render(lines, state.width)
+
+		p.mu.Lock()
+		p.previewed, p.preview, p.err = state, preview, err
+		p.rendering = false
+		p.mu.Unlock()
+
+		select {
+		case p.rendered <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+// waitPreview waits until a preview was rendered.
+func waitPreview(rendered <-chan struct{}) tea.Cmd {
+	return func() tea.Msg {
+		<-rendered
+		return previewedMsg{}
+	}
+}
+
+func (p *PreviewPane) View() string {
+	fluent := str.NewFluent()
+	fluent.Str(" ").Str(paneTitleStyle.Render(rw.Truncate(p.title(), p.width-1, "")))
+
+	p.mu.Lock()
+	var lines []string
+	switch {
+	case p.document == nil:
+	case p.document.Type() != "md":
+		lines = []string{"no preview, the document is not Markdown"}
+	case p.previewed.document != p.document:
+		lines = []string{"rendering..."}
+	case p.err != nil:
+		lines = []string{p.err.Error()}
+	default:
+		offset := clamp(p.preview.LineOf(p.row), 0, max(0, len(p.preview.Lines)-1))
+		lines = p.preview.Lines[offset:]
+	}
+	p.mu.Unlock()
+
+	for i := 0; i < p.height-1; i++ {
+		fluent.NewLine()
+		if i < len(lines) {
+			fluent.Str(" ").Str(truncate.String(lines[i], uint(p.width-1)))
+		}
+	}
+	return fluent.String()
+}
+
+func (p *PreviewPane) title() string {
+	if p.document == nil || p.document.Filename() == "" {
+		return "Preview"
+	}
+	return fmt.Sprintf("Preview: %s", filepath.Base(p.document.Filename()))
+}
+
+// toggleSide shows p beside the textarea, the side pane is closed instead
+// when it is of the same kind as p.
+func (u *Ui) toggleSide(p sidePane) {
+	if u.side != nil && reflect.TypeOf(u.side) == reflect.TypeOf(p) {
+		u.side = nil
+	} else {
+		u.side = p
+	}
+	u.layout()
+	u.syncSide()
+}
+
+// syncSide updates the side pane with the current document.
+func (u *Ui) syncSide() {
+	if u.side != nil && u.document != nil {
+		u.side.Sync(u.document, u.textarea.Position().Row)
+	}
+}
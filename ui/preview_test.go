@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// waitRendered waits until the preview of p was rendered.
+func waitRendered(t *testing.T, rendered <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-rendered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the preview wasn't rendered")
+	}
+}
+
+// plain returns the text of view without its styles.
+func plain(view string) string {
+	return regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]").ReplaceAllString(view, "")
+}
+
+func TestPreviewPane_Sync(t *testing.T) {
+	document, err := views.LoadDocument(writeFile(t, "a.md", "# Title\n\ntext\n\n## Usage\n\nmore\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered := make(chan struct{}, 1)
+	p := NewPreviewPane(rendered)
+	p.SetSize(40, 10)
+
+	p.Sync(document, 0)
+	if view := plain(p.View()); !strings.Contains(view, "rendering...") {
+		t.Errorf("View() = %q, want it rendering", view)
+	}
+	waitRendered(t, rendered)
+	if view := plain(p.View()); !strings.Contains(view, "Title") {
+		t.Errorf("View() = %q, want the document", view)
+	}
+
+	// the last preview is shown until the edited document is rendered.
+	document.Replace(views.Pos{Row: 2}, views.Pos{Row: 2, Col: 4}, "edited")
+	p.Sync(document, 0)
+	if view := plain(p.View()); !strings.Contains(view, "text") {
+		t.Errorf("View() = %q, want the last preview", view)
+	}
+	waitRendered(t, rendered)
+	p.Sync(document, 4)
+	view := plain(p.View())
+	if !strings.Contains(view, "Usage") || strings.Contains(view, "Title") {
+		t.Errorf("View() = %q, want the heading of the row", view)
+	}
+	if strings.Contains(view, "rendering...") || p.previewed.revision != document.Revision() {
+		t.Errorf("the preview isn't of the last revision")
+	}
+}
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	promptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+
+	promptConfirm = key.NewBinding(key.WithKeys("enter"))
+	promptCancel  = key.NewBinding(key.WithKeys("esc", tea.KeyCtrlG.String()))
+)
+
+// Prompt is the single line input shown at the bottom of the screen, it is used
+// to ask the user for the arguments of a command.
+type Prompt struct {
+	input  textinput.Model
+	active bool
+
+	// onDone is called with the entered value when the user confirms the input.
+	onDone func(value string) tea.Cmd
+
+	// choices when not empty, the prompt is answered by pressing one of these
+	// keys instead of entering a value.
+	choices string
+
+	// questions the questions asked while the prompt was active, they are
+	// asked once it is free.
+	questions []question
+}
+
+// question a question of Choose.
+type question struct {
+	text     string
+	choices  string
+	onChoice func(choice rune) tea.Cmd
+}
+
+func NewPrompt() *Prompt {
+	input := textinput.New()
+	input.PromptStyle = promptStyle
+	return &Prompt{input: input}
+}
+
+// Ask activates the prompt, onDone will be called once the user hits enter.
+func (p *Prompt) Ask(prompt, value string, onDone func(value string) tea.Cmd) tea.Cmd {
+	p.active = true
+	p.onDone = onDone
+	p.input.Prompt = prompt
+	p.input.SetValue(value)
+	p.input.CursorEnd()
+	return p.input.Focus()
+}
+
+// Choose activates the prompt as a question answered by a single key of
+// choices, onChoice is called with the pressed key. Cancelling the prompt
+// chooses the first choice. The question waits while the prompt is active.
+func (p *Prompt) Choose(text, choices string, onChoice func(choice rune) tea.Cmd) tea.Cmd {
+	if p.active {
+		p.questions = append(p.questions, question{text: text, choices: choices, onChoice: onChoice})
+		return nil
+	}
+
+	p.active = true
+	p.choices = choices
+	p.onDone = func(value string) tea.Cmd {
+		return onChoice([]rune(value)[0])
+	}
+	p.input.Prompt = text
+	p.input.SetValue("")
+	p.input.Blur()
+	return nil
+}
+
+// Active reports whether the prompt is waiting for input.
+func (p *Prompt) Active() bool {
+	return p.active
+}
+
+func (p *Prompt) close() {
+	p.active = false
+	p.onDone = nil
+	p.choices = ""
+	p.input.Blur()
+}
+
+// next asks the first waiting question unless the prompt is active again.
+func (p *Prompt) next() {
+	if p.active || len(p.questions) == 0 {
+		return
+	}
+	q := p.questions[0]
+	p.questions = p.questions[1:]
+	p.Choose(q.text, q.choices, q.onChoice)
+}
+
+// done closes the prompt and calls onDone with value, the next question is
+// asked afterwards.
+func (p *Prompt) done(value string) tea.Cmd {
+	onDone := p.onDone
+	p.close()
+
+	var cmd tea.Cmd
+	if onDone != nil {
+		cmd = onDone(value)
+	}
+	p.next()
+	return cmd
+}
+
+func (p *Prompt) Update(msg tea.Msg) tea.Cmd {
+	if p.choices != "" {
+		return p.updateChoice(msg)
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, promptConfirm):
+			return p.done(p.input.Value())
+		case key.Matches(msg, promptCancel):
+			p.close()
+			p.next()
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return cmd
+}
+
+func (p *Prompt) View() string {
+	return p.input.View()
+}
+
+func (p *Prompt) updateChoice(msg tea.Msg) tea.Cmd {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return nil
+	}
+
+	choice := ""
+	switch {
+	case key.Matches(keyMsg, promptCancel):
+		choice = p.choices[:1]
+	case keyMsg.Type == tea.KeyRunes && len(keyMsg.Runes) == 1 && strings.ContainsRune(p.choices, keyMsg.Runes[0]):
+		choice = string(keyMsg.Runes)
+	default:
+		return nil
+	}
+
+	return p.done(choice)
+}
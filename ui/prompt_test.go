@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPrompt_Choose_Waits(t *testing.T) {
+	var (
+		p       = NewPrompt()
+		entered string
+		chosen  []rune
+	)
+	choose := func(choice rune) tea.Cmd {
+		chosen = append(chosen, choice)
+		return nil
+	}
+
+	p.Ask("M-x ", "", func(value string) tea.Cmd {
+		entered = value
+		return nil
+	})
+	p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("sa")})
+	p.Choose("Reload? ", "yn", choose)
+	p.Choose("Recover? ", "ir", choose)
+	p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("ve")})
+	if !strings.Contains(p.View(), "M-x save") {
+		t.Fatalf("View() = %q, want the M-x line being typed", p.View())
+	}
+
+	p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if entered != "save" || !strings.Contains(p.View(), "Reload?") {
+		t.Fatalf("entered = %q, View() = %q, want the first question", entered, p.View())
+	}
+	p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	p.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if string(chosen) != "ni" || p.Active() {
+		t.Errorf("chosen = %q, active = %v, want both questions answered", string(chosen), p.Active())
+	}
+}
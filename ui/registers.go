@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/clipboard"
+	"github.com/fzdwx/ge/internal/teax"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// killRingSize the number of killed texts kept for yanking.
+const killRingSize = 32
+
+// Registers holds the kill ring, the named registers and the clipboard the
+// kill ring is shared with.
+type Registers struct {
+	Clipboard clipboard.Provider
+
+	// ring the killed texts, the most recent last.
+	ring  []string
+	named map[string]string
+}
+
+// NewRegisters creates empty registers backed by the clipboard p.
+func NewRegisters(p clipboard.Provider) *Registers {
+	return &Registers{Clipboard: p, named: map[string]string{}}
+}
+
+// Kill pushes text onto the kill ring.
+func (r *Registers) Kill(text string) {
+	r.ring = append(r.ring, text)
+	if len(r.ring) > killRingSize {
+		r.ring = r.ring[len(r.ring)-killRingSize:]
+	}
+}
+
+// AppendKill adds text to the most recent kill, in front of it if before is
+// true, so that consecutive kills are yanked together.
+func (r *Registers) AppendKill(text string, before bool) {
+	if len(r.ring) <= 0 {
+		r.Kill(text)
+		return
+	}
+
+	if before {
+		r.ring[len(r.ring)-1] = text + r.ring[len(r.ring)-1]
+	} else {
+		r.ring[len(r.ring)-1] += text
+	}
+}
+
+// Yank returns the i-th most recent kill, wrapping around the ring.
+func (r *Registers) Yank(i int) (string, bool) {
+	if len(r.ring) <= 0 {
+		return "", false
+	}
+	return r.ring[len(r.ring)-1-i%len(r.ring)], true
+}
+
+// sync pushes text read from the clipboard onto the kill ring unless it is
+// the most recent kill already, i.e. it was copied by another program.
+func (r *Registers) sync(text string) {
+	if top, ok := r.Yank(0); !ok || top != text {
+		r.Kill(text)
+	}
+}
+
+// Set stores text in the named register.
+func (r *Registers) Set(name, text string) {
+	r.named[name] = text
+}
+
+// Get returns the text of the named register.
+func (r *Registers) Get(name string) (string, bool) {
+	text, ok := r.named[name]
+	return text, ok
+}
+
+// write returns a command writing text to the clipboard.
+func (r *Registers) write(text string) tea.Cmd {
+	p := r.Clipboard
+	return func() tea.Msg {
+		if err := p.Write(text); err != nil {
+			return teax.ErrorMsg{Err: err}
+		}
+		return nil
+	}
+}
+
+// killed is the text removed by a kill of one cursor.
+type killed struct {
+	text string
+	// backward whether the text was before the cursor.
+	backward bool
+}
+
+// yank is the text inserted by the last yank, replaced by a yank-pop.
+type yank struct {
+	from   views.Pos
+	to     views.Pos
+	index  int
+	active bool
+}
+
+// Copy is a command for copying text to the kill ring and the clipboard.
+func (m *Textarea) Copy(text string) tea.Cmd {
+	m.Registers.Kill(text)
+	return m.Registers.write(text)
+}
+
+// Paste is a command for pasting from the clipboard into the text input.
+func (m *Textarea) Paste() tea.Msg {
+	str, err := m.Registers.Clipboard.Read()
+	if err != nil {
+		return pasteErrMsg{err}
+	}
+	return pasteMsg(str)
+}
+
+// killTo deletes the text between the cursor and p and remembers it for the
+// kill ring.
+func (m *Textarea) killTo(p views.Pos) {
+	from, to := m.document.Rows.Clamp(p), m.pos()
+	backward := from.Before(to)
+	if !backward {
+		from, to = to, from
+	}
+	if from == to {
+		return
+	}
+
+	m.killed = append(m.killed, killed{text: m.document.Text(from, to), backward: backward})
+	m.document.Replace(from, to, "")
+	m.SetPosition(from.Row, from.Col)
+}
+
+// flushKills moves the text killed by the last key to the kill ring, it is
+// added to the previous kill if that was made by the key before.
+func (m *Textarea) flushKills() tea.Cmd {
+	if len(m.killed) <= 0 {
+		m.lastKill = false
+		return nil
+	}
+
+	texts := make([]string, len(m.killed))
+	for i, k := range m.killed {
+		texts[i] = k.text
+	}
+	text := strings.Join(texts, "\n")
+
+	if m.lastKill && len(m.killed) == 1 {
+		m.Registers.AppendKill(text, m.killed[0].backward)
+	} else {
+		m.Registers.Kill(text)
+	}
+	m.killed, m.lastKill = nil, true
+
+	top, _ := m.Registers.Yank(0)
+	return m.Registers.write(top)
+}
+
+// yankText inserts text at the cursors and remembers where it went for a
+// yank-pop.
+func (m *Textarea) yankText(text string) {
+	m.Registers.sync(text)
+	from := m.pos()
+	if start, _, ok := m.Selection(); ok {
+		from = start
+	}
+
+	m.paste(text)
+	m.yank = yank{from: from, to: m.pos(), active: len(m.others) <= 0}
+}
+
+// YankPop replaces the text inserted by the last yank with the kill before
+// it, it returns false unless the previous action was a yank.
+func (m *Textarea) YankPop() bool {
+	if !m.yank.active {
+		return false
+	}
+
+	m.yank.index++
+	text, _ := m.Registers.Yank(m.yank.index)
+	end := m.document.Replace(m.yank.from, m.yank.to, text)
+	m.yank.to = end
+	m.SetPosition(end.Row, end.Col)
+	return true
+}
+
+// copyToRegister stores the selected text in the named register.
+func (u *Ui) copyToRegister(name string) {
+	text := u.textarea.SelectedText()
+	if name == "" || text == "" {
+		u.message("select a region and name a register")
+		return
+	}
+
+	u.textarea.Registers.Set(name, text)
+	u.textarea.ClearSelection()
+	u.message(fmt.Sprintf("copied to register %s", name))
+}
+
+// insertRegister inserts the text of the named register at the cursors.
+func (u *Ui) insertRegister(name string) {
+	text, ok := u.textarea.Registers.Get(name)
+	if !ok {
+		u.message(fmt.Sprintf("register %s is empty", name))
+		return
+	}
+	u.textarea.forEachCursor(func() {
+		u.textarea.DeleteSelection()
+		u.textarea.InsertString(text)
+	})
+}
@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fzdwx/ge/internal/search"
+	"github.com/fzdwx/ge/internal/views"
+	"github.com/fzdwx/x/str"
+	rw "github.com/mattn/go-runewidth"
+)
+
+var (
+	paneTitleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	resultFileStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	resultLineStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	resultCursorStyle = lipgloss.NewStyle().Reverse(true)
+)
+
+type (
+	// pane is a component shown below the textarea, e.g. the search results.
+	pane interface {
+		Update(msg tea.Msg) tea.Cmd
+		View() string
+		SetSize(width, height int)
+	}
+
+	// sidePane is shown beside the textarea and follows the current
+	// document and the cursor, e.g. the Markdown preview.
+	sidePane interface {
+		View() string
+		SetSize(width, height int)
+		Sync(document *views.Document, row int)
+	}
+
+	// jumpMsg asks the Ui to open Filename and move the cursor to Row and Col.
+	jumpMsg struct {
+		Filename string
+		Row      int
+		Col      int
+	}
+
+	// resultItem is a line of the results pane, either a file header or a match.
+	resultItem struct {
+		filename string
+		match    *search.Match
+		count    int
+	}
+
+	// ResultsPane lists the matches of a search grouped by file.
+	ResultsPane struct {
+		title string
+		items []resultItem
+		files int
+		total int
+		done  bool
+
+		// selected is the index of the selected match in items.
+		selected int
+		// offset is the index of the first visible item.
+		offset int
+
+		width  int
+		height int
+	}
+)
+
+var (
+	resultsUp    = key.NewBinding(key.WithKeys("up", "ctrl+p"))
+	resultsDown  = key.NewBinding(key.WithKeys("down", "ctrl+n"))
+	resultsEnter = key.NewBinding(key.WithKeys("enter"))
+)
+
+func NewResultsPane(title string) *ResultsPane {
+	return &ResultsPane{title: title, selected: -1}
+}
+
+// Append adds the results of some files, the first match is selected
+// automatically.
+func (p *ResultsPane) Append(results ...search.Result) {
+	for _, result := range results {
+		p.files++
+		p.total += len(result.Matches)
+		p.items = append(p.items, resultItem{filename: result.Filename, count: len(result.Matches)})
+		for i := range result.Matches {
+			p.items = append(p.items, resultItem{filename: result.Filename, match: &result.Matches[i]})
+		}
+	}
+
+	if p.selected < 0 {
+		p.move(1)
+	}
+}
+
+// Done marks the search as finished.
+func (p *ResultsPane) Done() {
+	p.done = true
+}
+
+func (p *ResultsPane) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// move selects the next match in dir direction, skipping file headers.
+func (p *ResultsPane) move(dir int) {
+	for i := p.selected + dir; i >= 0 && i < len(p.items); i += dir {
+		if p.items[i].match != nil {
+			p.selected = i
+			break
+		}
+	}
+
+	visible := p.height - 1
+	if p.selected < p.offset {
+		p.offset = p.selected
+	} else if p.selected >= p.offset+visible {
+		p.offset = p.selected - visible + 1
+	}
+}
+
+func (p *ResultsPane) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, resultsUp):
+			p.move(-1)
+		case key.Matches(msg, resultsDown):
+			p.move(1)
+		case key.Matches(msg, resultsEnter):
+			if p.selected < 0 {
+				return nil
+			}
+			item := p.items[p.selected]
+			return func() tea.Msg {
+				return jumpMsg{Filename: item.filename, Row: item.match.Row, Col: item.match.Col}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *ResultsPane) View() string {
+	fluent := str.NewFluent()
+
+	state := "searching..."
+	if p.done {
+		state = "done"
+	}
+	fluent.Str(paneTitleStyle.Render(rw.Truncate(fmt.Sprintf("%s  %d matches in %d files, %s", p.title, p.total, p.files, state), p.width, "")))
+
+	for i := p.offset; i < p.offset+p.height-1; i++ {
+		fluent.NewLine()
+		if i >= len(p.items) {
+			continue
+		}
+
+		item := p.items[i]
+		if item.match == nil {
+			fluent.Str(resultFileStyle.Render(rw.Truncate(fmt.Sprintf("%s (%d)", relative(item.filename), item.count), p.width, "")))
+			continue
+		}
+
+		line := rw.Truncate(fmt.Sprintf("  %d:%d: %s", item.match.Row+1, item.match.Col+1,
+			strings.TrimSpace(item.match.Text)), p.width, "")
+		if i == p.selected {
+			fluent.Str(resultCursorStyle.Render(line))
+		} else {
+			fluent.Str(resultLineStyle.Render(line))
+		}
+	}
+
+	return fluent.String()
+}
+
+// relative returns filename relative to the working directory when possible.
+func relative(filename string) string {
+	if rel, err := filepath.Rel(".", filename); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return filename
+}
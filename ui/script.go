@@ -0,0 +1,94 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/script"
+	"github.com/fzdwx/ge/internal/teax"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// scriptHost gives the plugins access to the Ui.
+type scriptHost struct {
+	u *Ui
+	// cmds the commands returned by the editor commands the plugins
+	// executed, they are run once the plugin returns.
+	cmds []tea.Cmd
+}
+
+// loadPlugins runs the plugins of the plugins directory.
+func (u *Ui) loadPlugins() tea.Cmd {
+	host := &scriptHost{u: u}
+	u.scripts = script.New(host)
+
+	dir, err := script.Dir()
+	if err == nil {
+		err = u.scripts.LoadDir(dir)
+	}
+	return teax.Batch(host.flush()).Check(err).Cmd()
+}
+
+// BindKey makes keys, e.g. "alt+!", run the command line, the binding takes
+// precedence over the builtin keys.
+func (u *Ui) BindKey(keys, line string) {
+	u.bindings[keys] = line
+}
+
+func (h *scriptHost) Document() *views.Document {
+	return h.u.document
+}
+
+func (h *scriptHost) Cursor() views.Pos {
+	return h.u.textarea.Position()
+}
+
+func (h *scriptHost) SetCursor(pos views.Pos) {
+	h.u.textarea.SetPosition(pos.Row, pos.Col)
+}
+
+func (h *scriptHost) Selection() (views.Pos, views.Pos, bool) {
+	return h.u.textarea.Selection()
+}
+
+func (h *scriptHost) Replace(from, to views.Pos, text string) views.Pos {
+	var end views.Pos
+	h.u.textarea.FollowEdits(func() {
+		end = h.u.document.Replace(from, to, text)
+	})
+	return end
+}
+
+func (h *scriptHost) Message(text string) {
+	h.u.message(text)
+}
+
+func (h *scriptHost) RegisterCommand(name, help string, run func(arg string) error) {
+	h.u.RegisterCommand(Command{
+		Name: name,
+		Help: help,
+		Run: func(u *Ui, arg string) tea.Cmd {
+			if err := run(arg); err != nil {
+				u.fail(err)
+			}
+			// the plugin may have edited the document through views.Document
+			// too, the cursor stays in it.
+			cursor := u.textarea.Position()
+			u.textarea.SetPosition(cursor.Row, cursor.Col)
+			return h.flush()
+		},
+	})
+}
+
+func (h *scriptHost) BindKey(keys, line string) {
+	h.u.BindKey(keys, line)
+}
+
+func (h *scriptHost) Execute(line string) error {
+	h.cmds = append(h.cmds, h.u.Execute(line))
+	return nil
+}
+
+func (h *scriptHost) flush() tea.Cmd {
+	cmds := h.cmds
+	h.cmds = nil
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/fzdwx/ge/config"
+	"github.com/fzdwx/ge/internal/script"
+)
+
+func TestScriptHost_Replace(t *testing.T) {
+	u := newTestUi(t, config.New([]string{writeFile(t, "a.txt", "one\ntwo\nthree\nfour")}))
+	host := &scriptHost{u: u}
+	u.scripts = script.New(host)
+	err := u.scripts.Exec("last.star", `
+def last(arg):
+    editor.replace(0, 0, 3, 0, "")
+
+def prefix(arg):
+    editor.insert(arg)
+
+editor.command("last", last)
+editor.command("prefix", prefix)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u.textarea.SetPosition(3, 4)
+	runCmd(u, u.Execute("last"))
+	if got := u.textarea.Position(); got.Row != 0 || got.Col != 4 {
+		t.Errorf("cursor = %v, want it moved along with the edit", got)
+	}
+	typeKeys(u, "x")
+	if got := u.document.String(); got != "fourx" {
+		t.Errorf("document = %q", got)
+	}
+
+	// the cursor after the insert is the one of the plugin.
+	u.textarea.SetPosition(0, 0)
+	runCmd(u, u.Execute("prefix >"))
+	typeKeys(u, "y")
+	if got := u.document.String(); got != ">yfourx" {
+		t.Errorf("document = %q", got)
+	}
+}
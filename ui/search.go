@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/search"
+)
+
+// maxResultBatch the max number of files delivered by one searchResultMsg, so
+// that a large result set doesn't block the update loop.
+const maxResultBatch = 64
+
+type (
+	// searchResultMsg delivers some results of the search identified by id.
+	searchResultMsg struct {
+		id      int
+		results []search.Result
+		done    bool
+	}
+
+	// searcher tracks the running project wide search.
+	searcher struct {
+		id      int
+		cancel  context.CancelFunc
+		results <-chan search.Result
+		pane    *ResultsPane
+	}
+)
+
+func (u *Ui) askSearchInFiles() tea.Cmd {
+	return u.prompt.Ask("Search in files: ", "", u.searchInFiles)
+}
+
+// searchInFiles starts a search of pattern in the working directory, a
+// previous search that is still running is cancelled.
+func (u *Ui) searchInFiles(pattern string) tea.Cmd {
+	if pattern == "" {
+		return nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		u.fail(err)
+		return nil
+	}
+
+	u.searcher.stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	u.searcher.id++
+	u.searcher.cancel = cancel
+	u.searcher.pane = NewResultsPane(fmt.Sprintf("Search: %s", pattern))
+	u.searcher.results = search.Search(ctx, ".", re)
+	u.openPane(u.searcher.pane)
+
+	return waitSearchResult(u.searcher.id, u.searcher.results)
+}
+
+// stop cancels the running search.
+func (s *searcher) stop() {
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+}
+
+// handle appends the results to the pane, returns false when msg belongs to
+// an outdated search.
+func (s *searcher) handle(msg searchResultMsg) bool {
+	if msg.id != s.id || s.pane == nil {
+		return false
+	}
+
+	s.pane.Append(msg.results...)
+	if msg.done {
+		s.pane.Done()
+		s.stop()
+	}
+	return true
+}
+
+// waitSearchResult waits for the next results of ch, and drains what is
+// already available up to maxResultBatch.
+func waitSearchResult(id int, ch <-chan search.Result) tea.Cmd {
+	return func() tea.Msg {
+		result, ok := <-ch
+		if !ok {
+			return searchResultMsg{id: id, done: true}
+		}
+
+		msg := searchResultMsg{id: id, results: []search.Result{result}}
+		for len(msg.results) < maxResultBatch {
+			select {
+			case result, ok := <-ch:
+				if !ok {
+					msg.done = true
+					return msg
+				}
+				msg.results = append(msg.results, result)
+			default:
+				return msg
+			}
+		}
+		return msg
+	}
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// Selection returns the selected region in document order, ok is false when
+// nothing is selected.
+func (m *Textarea) Selection() (from, to views.Pos, ok bool) {
+	if !m.selecting {
+		return views.Pos{}, views.Pos{}, false
+	}
+
+	from, to = m.document.Rows.Clamp(m.anchor), m.pos()
+	if to.Before(from) {
+		from, to = to, from
+	}
+	return from, to, from != to
+}
+
+// Select selects the region between anchor and the cursor.
+func (m *Textarea) Select(anchor views.Pos, cursor views.Pos) {
+	m.anchor = m.document.Rows.Clamp(anchor)
+	m.selecting = true
+	m.markMode = false
+	m.SetPosition(cursor.Row, cursor.Col)
+}
+
+// ClearSelection deselects the selected region.
+func (m *Textarea) ClearSelection() {
+	m.selecting = false
+	m.markMode = false
+}
+
+// startSelection anchors the selection at the cursor unless a region is
+// already selected.
+func (m *Textarea) startSelection() {
+	if m.selecting {
+		return
+	}
+	m.anchor, m.selecting = m.pos(), true
+}
+
+// SelectedText returns the text of the selected region.
+func (m *Textarea) SelectedText() string {
+	from, to, ok := m.Selection()
+	if !ok {
+		return ""
+	}
+	return m.document.Text(from, to)
+}
+
+// DeleteSelection deletes the selected region, returns false if nothing was
+// selected.
+func (m *Textarea) DeleteSelection() bool {
+	from, to, ok := m.Selection()
+	m.ClearSelection()
+	if !ok {
+		return false
+	}
+
+	m.document.Replace(from, to, "")
+	m.SetPosition(from.Row, from.Col)
+	return true
+}
+
+// replaceSelection replaces the selected region with f applied to its text,
+// the replacement stays selected.
+func (m *Textarea) replaceSelection(f func(string) string) {
+	from, to, ok := m.Selection()
+	if !ok {
+		return
+	}
+
+	end := m.document.Replace(from, to, f(m.document.Text(from, to)))
+	m.Select(from, end)
+}
+
+// UpcaseSelection converts the selected region to upper case.
+func (m *Textarea) UpcaseSelection() {
+	m.replaceSelection(strings.ToUpper)
+}
+
+// DowncaseSelection converts the selected region to lower case.
+func (m *Textarea) DowncaseSelection() {
+	m.replaceSelection(strings.ToLower)
+}
+
+// selectedRows returns the rows touched by the selection, or the cursor row.
+func (m *Textarea) selectedRows() (int, int) {
+	from, to, ok := m.Selection()
+	if !ok {
+		return m.row, m.row
+	}
+
+	// a selection ending at the start of a row doesn't include it.
+	if to.Col == 0 && to.Row > from.Row {
+		to.Row--
+	}
+	return from.Row, to.Row
+}
+
+// selectRows selects the rows in [start, end] entirely.
+func (m *Textarea) selectRows(start, end int) {
+	m.Select(views.Pos{Row: start}, views.Pos{Row: end, Col: len(m.document.Row(end))})
+}
+
+// IndentSelection indents the selected rows by one level.
+func (m *Textarea) IndentSelection() {
+	start, end := m.selectedRows()
+	m.document.IndentLines(start, end, m.document.Indent().Unit())
+	m.selectRows(start, end)
+}
+
+// OutdentSelection removes one level of indentation of the selected rows.
+func (m *Textarea) OutdentSelection() {
+	start, end := m.selectedRows()
+	m.document.OutdentLines(start, end, m.document.Indent().Size)
+	m.selectRows(start, end)
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/teax"
+)
+
+// settleTimeout how long settle waits for a command of a key played by a
+// macro, e.g. reading the clipboard.
+const settleTimeout = time.Second
+
+// settle runs cmd and updates the Ui with its messages, and with the ones of
+// the commands they return, until no command is left. The commands that
+// don't finish within timeout, e.g. the ones waiting for events, are
+// returned to run in the event loop. The cursors must not blink meanwhile,
+// see stillCursors.
+func (u *Ui) settle(cmd tea.Cmd, timeout time.Duration) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(timeout):
+		return func() tea.Msg { return <-done }
+	}
+
+	if cmds, ok := teax.Cmds(msg); ok {
+		batch := teax.Batch()
+		for _, cmd := range cmds {
+			batch.Append(u.settle(cmd, timeout))
+		}
+		return batch.Cmd()
+	}
+	switch msg {
+	case nil:
+		return nil
+	case tea.Quit():
+		return tea.Quit
+	}
+	_, next := u.Update(msg)
+	return u.settle(next, timeout)
+}
+
+// stillCursors stops the cursors of the textarea and the prompt from
+// blinking, a blink is followed by the next one and never settles. The
+// returned func restores them.
+func (u *Ui) stillCursors() func() tea.Cmd {
+	area, prompt := u.textarea.Cursor.CursorMode(), u.prompt.input.CursorMode()
+	u.textarea.Cursor.SetCursorMode(cursor.CursorStatic)
+	u.prompt.input.SetCursorMode(textinput.CursorStatic)
+	return func() tea.Cmd {
+		return tea.Batch(u.textarea.Cursor.SetCursorMode(area), u.prompt.input.SetCursorMode(prompt))
+	}
+}
@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/logx"
+	"github.com/fzdwx/ge/internal/swap"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+// swapInterval how often the swap files of the modified documents are written.
+const swapInterval = 4 * time.Second
+
+type swapTickMsg struct{}
+
+func swapTick() tea.Cmd {
+	return tea.Tick(swapInterval, func(time.Time) tea.Msg {
+		return swapTickMsg{}
+	})
+}
+
+// writeSwapFiles writes the swap file of every modified document that changed
+// since its last write, and removes the swap files of the documents that have
+// been saved.
+func (u *Ui) writeSwapFiles() {
+	for _, document := range u.documents {
+		if document.Filename() == "" {
+			continue
+		}
+
+		revision, ok := u.swapped[document]
+		if !document.Modified() {
+			if ok {
+				u.removeSwapFile(document)
+			}
+			continue
+		}
+		if ok && revision == document.Revision() {
+			continue
+		}
+
+		s := swap.Swap{Filename: document.Filename(), Content: document.String()}
+		if document == u.document {
+			pos := u.textarea.Position()
+			s.Row, s.Col = pos.Row, pos.Col
+		}
+
+		if err := swap.Write(s); err != nil {
+			logx.Warn().Err(err).Str("filename", document.Filename()).Msg("could not write swap file")
+			continue
+		}
+		u.swapped[document] = document.Revision()
+	}
+}
+
+func (u *Ui) removeSwapFile(document *views.Document) {
+	if err := swap.Remove(document.Filename()); err != nil {
+		logx.Warn().Err(err).Str("filename", document.Filename()).Msg("could not remove swap file")
+	}
+	delete(u.swapped, document)
+}
+
+// checkSwapFile looks for the swap file left by a crashed or killed ge, and
+// asks whether it should be recovered.
+func (u *Ui) checkSwapFile(document *views.Document) {
+	if document.Filename() == "" {
+		return
+	}
+
+	s, err := swap.Read(document.Filename())
+	if err != nil {
+		logx.Warn().Err(err).Str("filename", document.Filename()).Msg("could not read swap file")
+		return
+	}
+	if s == nil {
+		return
+	}
+
+	if s.Content == document.String() {
+		u.removeSwapFile(document)
+		return
+	}
+
+	question := fmt.Sprintf("%s has unsaved changes from pid %d at %s, (i)gnore, (r)ecover, (d)iff or (x) delete them? ",
+		relative(document.Filename()), s.Pid, s.Time.Format("2006-01-02 15:04"))
+	u.prompt.Choose(question, "irdx", func(choice rune) tea.Cmd {
+		switch choice {
+		case 'r':
+			document.Replace(views.Pos{}, views.Pos{Row: document.Height()}, s.Content)
+			if document == u.document {
+				u.textarea.SetPosition(s.Row, s.Col)
+			}
+			u.message(fmt.Sprintf("recovered %s", relative(document.Filename())))
+		case 'd':
+			swapped := views.NewDocument()
+			swapped.Replace(views.Pos{}, views.Pos{}, s.Content)
+
+			cmd := u.show(document)
+			u.showDiff(NewDiffView(document, document.Filename(), swapped, document.Filename()+" (swap)"))
+			return cmd
+		case 'x':
+			u.removeSwapFile(document)
+		}
+		return nil
+	})
+}
+
+// recoverPanic writes the swap files before the panic reaches bubbletea, which
+// restores the terminal.
+func (u *Ui) recoverPanic() {
+	if r := recover(); r != nil {
+		u.crashed = true
+		u.writeSwapFiles()
+		panic(r)
+	}
+}
+
+// Crashed reports whether the Ui has stopped because of a panic.
+func (u *Ui) Crashed() bool {
+	return u.crashed
+}
+
+// quit quits the Ui, it first asks whether the unsaved changes of the
+// modified documents are discarded or kept in swap files. Quitting again
+// while asked keeps them.
+func (u *Ui) quit() tea.Cmd {
+	modified := 0
+	for _, document := range u.documents {
+		if document.Modified() {
+			modified++
+		}
+	}
+	if modified == 0 || u.quitting {
+		return tea.Quit
+	}
+
+	u.quitting = true
+	question := fmt.Sprintf("%d documents have unsaved changes, quit? (n)o, (y)es and discard them or (k)eep them for recovery ", modified)
+	return u.prompt.Choose(question, "nyk", func(choice rune) tea.Cmd {
+		u.quitting = false
+		switch choice {
+		case 'y':
+			u.discarded = true
+			return tea.Quit
+		case 'k':
+			return tea.Quit
+		}
+		return nil
+	})
+}
+
+// closeSwapFiles writes the swap files of the unsaved changes when the Ui
+// exits, unless the user discarded them.
+func (u *Ui) closeSwapFiles() {
+	if !u.discarded {
+		u.writeSwapFiles()
+		return
+	}
+	for _, document := range u.documents {
+		if document.Filename() != "" {
+			u.removeSwapFile(document)
+		}
+	}
+}
+
+// Close releases the resources of the Ui once the program has exited, see
+// closeSwapFiles.
+func (u *Ui) Close() error {
+	u.closeSwapFiles()
+	u.Output.Close()
+	if err := u.closePlugins(); err != nil {
+		logx.Warn().Err(err).Msg("could not close the plugins")
+	}
+	if err := u.closeTerminal(); err != nil {
+		logx.Warn().Err(err).Msg("could not close the terminal")
+	}
+	return u.watcher.Close()
+}
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/config"
+	"github.com/fzdwx/ge/internal/swap"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+func TestInit_Panic(t *testing.T) {
+	filename := writeFile(t, "a.txt", "a\n")
+	u := newTestUi(t, config.New([]string{filename}))
+
+	// a panic while the next document is opened.
+	u.document.Replace(views.Pos{}, views.Pos{}, "b")
+	u.textarea = nil
+	func() {
+		defer func() { _ = recover() }()
+		u.Init()
+		t.Fatal("Init() didn't panic")
+	}()
+
+	if !u.Crashed() {
+		t.Error("Crashed() = false")
+	}
+	s, err := swap.Read(filename)
+	if err != nil || s == nil || s.Content != "ba" {
+		t.Errorf("swap.Read() = %+v, %v, want the unsaved changes", s, err)
+	}
+}
+
+func TestUi_quit(t *testing.T) {
+	tests := []struct {
+		choice string
+		quit   bool
+		swap   bool
+	}{
+		{"n", false, true},
+		{"y", true, false},
+		{"k", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.choice, func(t *testing.T) {
+			filename := writeFile(t, "a.txt", "a\n")
+			u := newTestUi(t, config.New([]string{filename}))
+			u.document.Replace(views.Pos{}, views.Pos{}, "b")
+			u.writeSwapFiles()
+
+			_, cmd := u.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+			if cmd != nil || !u.prompt.Active() {
+				t.Fatal("quit without asking")
+			}
+			_, cmd = u.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(tt.choice)})
+			if quit := cmd != nil && cmd() == tea.Quit(); quit != tt.quit {
+				t.Errorf("quit = %v, want %v", quit, tt.quit)
+			}
+
+			u.closeSwapFiles()
+			if s, err := swap.Read(filename); err != nil || (s != nil) != tt.swap {
+				t.Errorf("swap.Read() = %+v, %v, want a swap file %v", s, err, tt.swap)
+			}
+		})
+	}
+}
+
+func TestUi_quit_Unmodified(t *testing.T) {
+	u := newTestUi(t, config.New([]string{writeFile(t, "a.txt", "a\n")}))
+	if _, cmd := u.Update(tea.KeyMsg{Type: tea.KeyCtrlC}); cmd == nil || cmd() != tea.Quit() {
+		t.Error("an unmodified Ui didn't quit")
+	}
+}
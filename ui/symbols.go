@@ -0,0 +1,351 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fzdwx/ge/internal/fuzzy"
+	"github.com/fzdwx/ge/internal/symbols"
+	"github.com/fzdwx/ge/internal/views"
+	"github.com/fzdwx/x/str"
+	rw "github.com/mattn/go-runewidth"
+	"github.com/muesli/reflow/truncate"
+)
+
+var (
+	symbolMatchStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	symbolKindStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+)
+
+type (
+	// symbolsMsg reports that a document was indexed.
+	symbolsMsg struct{}
+
+	// symbolEntry the symbols of a revision of a document, err is the
+	// syntax error of the source, if any.
+	symbolEntry struct {
+		revision int
+		symbols  []symbols.Symbol
+		err      error
+	}
+
+	// symbolIndex parses the Go documents in the background when they
+	// change, the last symbols of a document are kept while it is parsed
+	// again.
+	symbolIndex struct {
+		mu      sync.Mutex
+		entries map[*views.Document]symbolEntry
+		// parsing the documents being parsed.
+		parsing map[*views.Document]bool
+		// updates is signaled when a document was parsed, the signals are
+		// merged until they are received.
+		updates chan struct{}
+	}
+)
+
+func newSymbolIndex() *symbolIndex {
+	return &symbolIndex{
+		entries: map[*views.Document]symbolEntry{},
+		parsing: map[*views.Document]bool{},
+		updates: make(chan struct{}, 1),
+	}
+}
+
+// refresh parses the document in the background unless its symbols are up
+// to date or it is parsed already, it has to be called by the update loop.
+func (x *symbolIndex) refresh(document *views.Document) {
+	if document.Type() != "go" {
+		return
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	entry, ok := x.entries[document]
+	if x.parsing[document] || (ok && entry.revision == document.Revision()) {
+		return
+	}
+
+	x.parsing[document] = true
+	revision, src := document.Revision(), document.Bytes()
+	go func() {
+		syms, err := symbols.Parse(src)
+
+		x.mu.Lock()
+		x.entries[document] = symbolEntry{revision: revision, symbols: syms, err: err}
+		delete(x.parsing, document)
+		x.mu.Unlock()
+
+		select {
+		case x.updates <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+// get returns the last symbols of the document.
+func (x *symbolIndex) get(document *views.Document) (symbolEntry, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	entry, ok := x.entries[document]
+	return entry, ok
+}
+
+// waitSymbols waits until a document was indexed.
+func waitSymbols(x *symbolIndex) tea.Cmd {
+	return func() tea.Msg {
+		<-x.updates
+		return symbolsMsg{}
+	}
+}
+
+// refreshSymbols indexes the current document when it changed.
+func (u *Ui) refreshSymbols() {
+	if u.document != nil {
+		u.symbols.refresh(u.document)
+	}
+}
+
+// documentSymbols returns the symbols of the current document.
+func (u *Ui) documentSymbols() ([]symbols.Symbol, error) {
+	if u.document.Type() != "go" {
+		return nil, errors.New("the document is not Go")
+	}
+	entry, ok := u.symbols.get(u.document)
+	if !ok {
+		return nil, errors.New("the symbols are not indexed yet")
+	}
+	return entry.symbols, nil
+}
+
+// gotoSymbol opens the picker of the symbols of the document.
+func (u *Ui) gotoSymbol() error {
+	syms, err := u.documentSymbols()
+	if err != nil {
+		return err
+	}
+
+	items := make([]pickerItem, len(syms))
+	for i, s := range syms {
+		items[i] = pickerItem{label: s.String(), detail: string(s.Kind), row: s.Row, col: s.Col}
+	}
+	u.openPane(NewPickerPane("Go to symbol", u.document.Filename(), items))
+	return nil
+}
+
+type (
+	// pickerItem is a choice of the picker, enter jumps to its row and col.
+	pickerItem struct {
+		label  string
+		detail string
+		row    int
+		col    int
+	}
+
+	// PickerPane filters its items by the typed query with fuzzy matching,
+	// the best matches first.
+	PickerPane struct {
+		title    string
+		filename string
+		items    []pickerItem
+		labels   []string
+
+		query   []rune
+		matches []fuzzy.Match
+
+		// selected is the index of the selected match.
+		selected int
+		// offset is the index of the first visible match.
+		offset int
+
+		width  int
+		height int
+	}
+)
+
+func NewPickerPane(title, filename string, items []pickerItem) *PickerPane {
+	p := &PickerPane{title: title, filename: filename, items: items}
+	for _, item := range items {
+		p.labels = append(p.labels, item.label)
+	}
+	p.filter()
+	return p
+}
+
+// filter matches the items against the query, the best match is selected.
+func (p *PickerPane) filter() {
+	p.matches = fuzzy.Find(string(p.query), p.labels)
+	p.selected, p.offset = 0, 0
+}
+
+func (p *PickerPane) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.move(0)
+}
+
+// move selects the match n after the selected one, the view follows it.
+func (p *PickerPane) move(n int) {
+	p.selected = clamp(p.selected+n, 0, max(0, len(p.matches)-1))
+
+	visible := max(p.height-1, 1)
+	if p.selected < p.offset {
+		p.offset = p.selected
+	} else if p.selected >= p.offset+visible {
+		p.offset = p.selected - visible + 1
+	}
+}
+
+func (p *PickerPane) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, resultsUp):
+			p.move(-1)
+		case key.Matches(msg, resultsDown):
+			p.move(1)
+		case key.Matches(msg, resultsEnter):
+			if len(p.matches) == 0 {
+				return nil
+			}
+			item := p.items[p.matches[p.selected].Index]
+			return func() tea.Msg {
+				return jumpMsg{Filename: p.filename, Row: item.row, Col: item.col}
+			}
+		case msg.Type == tea.KeyBackspace:
+			if len(p.query) > 0 {
+				p.query = p.query[:len(p.query)-1]
+				p.filter()
+			}
+		case msg.Type == tea.KeyRunes && !msg.Alt, msg.Type == tea.KeySpace:
+			p.query = append(p.query, msg.Runes...)
+			p.filter()
+		}
+	}
+	return nil
+}
+
+func (p *PickerPane) View() string {
+	fluent := str.NewFluent()
+	title := fmt.Sprintf("%s: %s", p.title, string(p.query))
+	fluent.Str(paneTitleStyle.Render(rw.Truncate(title, p.width, ""))).
+		Str(symbolKindStyle.Render(fmt.Sprintf("  %d/%d", len(p.matches), len(p.items))))
+
+	for i := p.offset; i < p.offset+p.height-1; i++ {
+		fluent.NewLine()
+		if i >= len(p.matches) {
+			continue
+		}
+
+		m := p.matches[i]
+		item := p.items[m.Index]
+		line := highlightRunes(item.label, m.Positions) + symbolKindStyle.Render(fmt.Sprintf("  %s %d", item.detail, item.row+1))
+		if i == p.selected {
+			line = resultCursorStyle.Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		fluent.Str(truncate.String(line, uint(p.width)))
+	}
+	return fluent.String()
+}
+
+// highlightRunes renders the runes of s at the positions with the match
+// style.
+func highlightRunes(s string, positions []int) string {
+	var (
+		sb strings.Builder
+		j  int
+	)
+	for i, r := range []rune(s) {
+		if j < len(positions) && positions[j] == i {
+			sb.WriteString(symbolMatchStyle.Render(string(r)))
+			j++
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// SymbolOutlinePane lists the symbols of the Go document beside the
+// textarea, the symbol at the cursor is selected.
+type SymbolOutlinePane struct {
+	index    *symbolIndex
+	document *views.Document
+	entry    symbolEntry
+	// current the index of the symbol at the cursor, -1 if there is none.
+	current int
+	// offset is the index of the first visible symbol.
+	offset int
+
+	width  int
+	height int
+}
+
+func NewSymbolOutlinePane(index *symbolIndex) *SymbolOutlinePane {
+	return &SymbolOutlinePane{index: index, current: -1}
+}
+
+func (p *SymbolOutlinePane) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Sync shows the last symbols of the document, the view follows the symbol
+// of the row of the cursor.
+func (p *SymbolOutlinePane) Sync(document *views.Document, row int) {
+	p.document = document
+	p.entry, _ = p.index.get(document)
+	p.current = symbols.At(p.entry.symbols, row)
+	if p.current < 0 {
+		return
+	}
+
+	visible := max(p.height-1, 1)
+	if p.current < p.offset {
+		p.offset = p.current
+	} else if p.current >= p.offset+visible {
+		p.offset = p.current - visible + 1
+	}
+}
+
+func (p *SymbolOutlinePane) View() string {
+	fluent := str.NewFluent()
+	title := "Outline"
+	if p.document != nil && p.document.Filename() != "" {
+		title += ": " + filepath.Base(p.document.Filename())
+	}
+	if p.entry.err != nil {
+		title += " (syntax errors)"
+	}
+	fluent.Str(" ").Str(paneTitleStyle.Render(rw.Truncate(title, p.width-1, "")))
+
+	if p.document != nil && p.document.Type() != "go" {
+		fluent.NewLine().Str(" no outline, the document is not Go")
+		return fluent.String()
+	}
+
+	syms := p.entry.symbols
+	p.offset = clamp(p.offset, 0, max(0, len(syms)-1))
+	for i := p.offset; i < p.offset+p.height-1; i++ {
+		fluent.NewLine()
+		if i >= len(syms) {
+			continue
+		}
+
+		s := syms[i]
+		line := rw.Truncate(fmt.Sprintf("%-6s %s", s.Kind, s.String()), p.width-1, "")
+		if i == p.current {
+			fluent.Str(" ").Str(resultCursorStyle.Render(line))
+		} else {
+			fluent.Str(" ").Str(line)
+		}
+	}
+	return fluent.String()
+}
@@ -0,0 +1,345 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fzdwx/ge/internal/term"
+	"github.com/fzdwx/ge/internal/views"
+	"github.com/fzdwx/x/str"
+	rw "github.com/mattn/go-runewidth"
+)
+
+type (
+	// terminalOutputMsg reports that the screen of the terminal changed.
+	terminalOutputMsg struct {
+		pane *TerminalPane
+	}
+
+	// terminalExitedMsg reports that the shell of the terminal exited.
+	terminalExitedMsg struct {
+		pane *TerminalPane
+	}
+)
+
+// TerminalPane runs a shell in a terminal emulator, the keys are sent to
+// the shell while the pane is focused.
+type TerminalPane struct {
+	term   *term.Terminal
+	shell  string
+	exited bool
+
+	width  int
+	height int
+}
+
+// NewTerminalPane starts the shell of the user in a terminal of the size.
+func NewTerminalPane(width, height int) (*TerminalPane, error) {
+	sh := shell()
+	t, err := term.Start(max(width, 1), max(height-1, 1), sh)
+	if err != nil {
+		return nil, err
+	}
+	return &TerminalPane{term: t, shell: sh, width: width, height: height}, nil
+}
+
+// waitTerminal waits for the next change of the terminal of p.
+func waitTerminal(p *TerminalPane) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case <-p.term.Updates():
+			return terminalOutputMsg{pane: p}
+		case <-p.term.Done():
+			return terminalExitedMsg{pane: p}
+		}
+	}
+}
+
+func (p *TerminalPane) SetSize(width, height int) {
+	p.width, p.height = width, height
+	if !p.exited {
+		// the first line shows the title.
+		_ = p.term.Resize(max(width, 1), max(height-1, 1))
+	}
+}
+
+func (p *TerminalPane) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if p.exited {
+			return nil
+		}
+
+		var appCursorKeys bool
+		p.term.Screen(func(s *term.Screen) {
+			appCursorKeys = s.AppCursorKeys()
+		})
+		if b := keyBytes(msg, appCursorKeys); len(b) > 0 {
+			_, _ = p.term.Write(b)
+		}
+	}
+	return nil
+}
+
+func (p *TerminalPane) View() string {
+	fluent := str.NewFluent()
+	p.term.Screen(func(s *term.Screen) {
+		title := s.Title
+		if title == "" {
+			title = p.shell
+		}
+		if p.exited {
+			title += " (exited)"
+		}
+		fluent.Str(paneTitleStyle.Render(rw.Truncate("Terminal: "+title, p.width, "")))
+
+		width, height := s.Size()
+		cx, cy, visible := s.Cursor()
+		for y := 0; y < height && y < p.height-1; y++ {
+			fluent.NewLine()
+			cursor := -1
+			if visible && !p.exited && y == cy {
+				cursor = cx
+			}
+			fluent.Str(renderTerminalLine(s, y, min(width, p.width), cursor))
+		}
+	})
+	return fluent.String()
+}
+
+// Text returns the text of the scrollback and the screen of the terminal.
+func (p *TerminalPane) Text() string {
+	var text string
+	p.term.Screen(func(s *term.Screen) {
+		text = s.Text()
+	})
+	return text
+}
+
+// Close kills the shell.
+func (p *TerminalPane) Close() error {
+	return p.term.Close()
+}
+
+// renderTerminalLine renders the row y of s, the runs of cells with the same
+// attributes are rendered together.
+func renderTerminalLine(s *term.Screen, y, width, cursor int) string {
+	var (
+		sb  strings.Builder
+		run strings.Builder
+		cur term.Attr
+	)
+	flush := func() {
+		if run.Len() > 0 {
+			sb.WriteString(terminalStyle(cur).Render(run.String()))
+			run.Reset()
+		}
+	}
+
+	for x := 0; x < width; x++ {
+		cell := s.Cell(x, y)
+		if cell.Continuation() {
+			continue
+		}
+
+		attr := cell.Attr
+		if x == cursor {
+			attr.Reverse = !attr.Reverse
+		}
+		if attr != cur {
+			flush()
+			cur = attr
+		}
+
+		if cell.Rune == 0 {
+			run.WriteByte(' ')
+		} else {
+			run.WriteRune(cell.Rune)
+		}
+	}
+	flush()
+	return sb.String()
+}
+
+func terminalStyle(a term.Attr) lipgloss.Style {
+	style := lipgloss.NewStyle().
+		Bold(a.Bold).
+		Faint(a.Faint).
+		Italic(a.Italic).
+		Underline(a.Underline).
+		Reverse(a.Reverse)
+	if a.Fg != term.DefaultColor {
+		style = style.Foreground(lipgloss.Color(a.Fg.String()))
+	}
+	if a.Bg != term.DefaultColor {
+		style = style.Background(lipgloss.Color(a.Bg.String()))
+	}
+	return style
+}
+
+// keyBytes returns the bytes a terminal sends for msg.
+func keyBytes(msg tea.KeyMsg, appCursorKeys bool) []byte {
+	var b []byte
+	switch msg.Type {
+	case tea.KeyRunes:
+		b = []byte(string(msg.Runes))
+	case tea.KeySpace:
+		b = []byte{' '}
+	case tea.KeyUp, tea.KeyDown, tea.KeyRight, tea.KeyLeft:
+		final := map[tea.KeyType]byte{tea.KeyUp: 'A', tea.KeyDown: 'B', tea.KeyRight: 'C', tea.KeyLeft: 'D'}[msg.Type]
+		if appCursorKeys {
+			b = []byte{0x1b, 'O', final}
+		} else {
+			b = []byte{0x1b, '[', final}
+		}
+	default:
+		if msg.Type >= 0 {
+			// the control keys are their code, e.g. ctrl+c, enter or tab.
+			b = []byte{byte(msg.Type)}
+		} else if seq, ok := keySequences[msg.Type]; ok {
+			b = []byte(seq)
+		}
+	}
+
+	if msg.Alt && len(b) > 0 {
+		b = append([]byte{0x1b}, b...)
+	}
+	return b
+}
+
+// keySequences the escape sequences of the special keys sent by xterm.
+var keySequences = map[tea.KeyType]string{
+	tea.KeyHome:           "\x1b[H",
+	tea.KeyEnd:            "\x1b[F",
+	tea.KeyPgUp:           "\x1b[5~",
+	tea.KeyPgDown:         "\x1b[6~",
+	tea.KeyDelete:         "\x1b[3~",
+	tea.KeyShiftTab:       "\x1b[Z",
+	tea.KeyCtrlUp:         "\x1b[1;5A",
+	tea.KeyCtrlDown:       "\x1b[1;5B",
+	tea.KeyCtrlRight:      "\x1b[1;5C",
+	tea.KeyCtrlLeft:       "\x1b[1;5D",
+	tea.KeyShiftUp:        "\x1b[1;2A",
+	tea.KeyShiftDown:      "\x1b[1;2B",
+	tea.KeyShiftRight:     "\x1b[1;2C",
+	tea.KeyShiftLeft:      "\x1b[1;2D",
+	tea.KeyCtrlShiftUp:    "\x1b[1;6A",
+	tea.KeyCtrlShiftDown:  "\x1b[1;6B",
+	tea.KeyCtrlShiftRight: "\x1b[1;6C",
+	tea.KeyCtrlShiftLeft:  "\x1b[1;6D",
+	tea.KeyF1:             "\x1bOP",
+	tea.KeyF2:             "\x1bOQ",
+	tea.KeyF3:             "\x1bOR",
+	tea.KeyF4:             "\x1bOS",
+	tea.KeyF5:             "\x1b[15~",
+	tea.KeyF6:             "\x1b[17~",
+	tea.KeyF7:             "\x1b[18~",
+	tea.KeyF8:             "\x1b[19~",
+	tea.KeyF9:             "\x1b[20~",
+	tea.KeyF10:            "\x1b[21~",
+	tea.KeyF11:            "\x1b[23~",
+	tea.KeyF12:            "\x1b[24~",
+}
+
+// terminalFocused reports whether the keys go to the terminal.
+func (u *Ui) terminalFocused() bool {
+	return u.terminal != nil && u.paneFocused && u.pane == u.terminal
+}
+
+// openTerminal shows the terminal pane and focuses it, a shell is started
+// unless one is running.
+func (u *Ui) openTerminal() tea.Cmd {
+	if u.terminal != nil && !u.terminal.exited {
+		u.openPane(u.terminal)
+		return nil
+	}
+
+	if u.terminal != nil {
+		_ = u.terminal.Close()
+	}
+	p, err := NewTerminalPane(u.width, u.height/3)
+	if err != nil {
+		u.terminal = nil
+		u.fail(fmt.Errorf("could not start the terminal: %w", err))
+		return nil
+	}
+	u.terminal = p
+	u.openPane(p)
+	return waitTerminal(p)
+}
+
+// handleTerminalExited keeps the output of the terminal whose shell exited.
+func (u *Ui) handleTerminalExited(p *TerminalPane) {
+	p.exited = true
+	if err := p.term.Err(); err != nil {
+		u.message(fmt.Sprintf("terminal: %s", err))
+	} else {
+		u.message("the terminal exited")
+	}
+	if u.terminalFocused() {
+		u.focusPane(false)
+	}
+}
+
+// terminalLines returns the last n lines of the terminal, or all of them
+// when arg is empty.
+func (u *Ui) terminalLines(arg string) (string, error) {
+	if u.terminal == nil {
+		return "", errors.New("no terminal, open one with the terminal command")
+	}
+
+	text := u.terminal.Text()
+	if arg == "" {
+		return text, nil
+	}
+
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return "", fmt.Errorf("invalid number of lines: %s", arg)
+	}
+	lines := strings.Split(text, "\n")
+	return strings.Join(lines[max(len(lines)-n, 0):], "\n"), nil
+}
+
+// copyTerminal copies the text of the terminal to the kill ring, ready to be
+// yanked into a document.
+func (u *Ui) copyTerminal(arg string) tea.Cmd {
+	text, err := u.terminalLines(arg)
+	if err != nil {
+		u.fail(err)
+		return nil
+	}
+
+	u.message(fmt.Sprintf("copied %d lines of the terminal", strings.Count(text, "\n")+1))
+	return u.textarea.Copy(text)
+}
+
+// terminalToDocument opens a new document with the text of the terminal.
+func (u *Ui) terminalToDocument(arg string) tea.Cmd {
+	text, err := u.terminalLines(arg)
+	if err != nil {
+		u.fail(err)
+		return nil
+	}
+
+	document := views.NewDocument()
+	if err := document.Read(strings.NewReader(text)); err != nil {
+		u.fail(err)
+		return nil
+	}
+	u.addDocument(document)
+	u.focusPane(false)
+	return u.show(document)
+}
+
+// closeTerminal kills the shell of the terminal, if any.
+func (u *Ui) closeTerminal() error {
+	if u.terminal == nil {
+		return nil
+	}
+	return u.terminal.Close()
+}
@@ -2,12 +2,12 @@ package ui
 
 import (
 	"fmt"
+	"github.com/fzdwx/ge/internal/clipboard"
 	"github.com/fzdwx/ge/internal/views"
 	"github.com/fzdwx/x/str"
 	"strings"
 	"unicode"
 
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -24,6 +24,7 @@ const (
 	defaultCharLimit = -1
 	maxHeight        = 99
 	maxWidth         = 500
+	signWidth        = 1
 )
 
 // Internal messages for clipboard operations.
@@ -48,6 +49,32 @@ type KeyMap struct {
 	Paste                   key.Binding
 	WordLeft                key.Binding
 	WordRight               key.Binding
+
+	SelectLeft  key.Binding
+	SelectRight key.Binding
+	SelectUp    key.Binding
+	SelectDown  key.Binding
+	SetMark     key.Binding
+	Cancel      key.Binding
+	Copy        key.Binding
+	Cut         key.Binding
+	Indent      key.Binding
+	Outdent     key.Binding
+	Upcase      key.Binding
+	Downcase    key.Binding
+
+	Undo              key.Binding
+	Redo              key.Binding
+	AddCursorAbove    key.Binding
+	AddCursorBelow    key.Binding
+	AddNextOccurrence key.Binding
+	SplitSelection    key.Binding
+	BlockSelect       key.Binding
+	YankPop           key.Binding
+	MatchBracket      key.Binding
+	ToggleFold        key.Binding
+	ExpandSelection   key.Binding
+	ShrinkSelection   key.Binding
 }
 
 // DefaultKeyMap is the default set of key bindings for navigating and acting
@@ -69,6 +96,32 @@ var DefaultKeyMap = KeyMap{
 	LineStart:               key.NewBinding(key.WithKeys("home", "ctrl+a")),
 	LineEnd:                 key.NewBinding(key.WithKeys("end", "ctrl+e")),
 	Paste:                   key.NewBinding(key.WithKeys("ctrl+v")),
+
+	SelectLeft:  key.NewBinding(key.WithKeys("shift+left")),
+	SelectRight: key.NewBinding(key.WithKeys("shift+right")),
+	SelectUp:    key.NewBinding(key.WithKeys("shift+up")),
+	SelectDown:  key.NewBinding(key.WithKeys("shift+down")),
+	SetMark:     key.NewBinding(key.WithKeys("ctrl+@")),
+	Cancel:      key.NewBinding(key.WithKeys("esc", "ctrl+g")),
+	Copy:        key.NewBinding(key.WithKeys("alt+w")),
+	Cut:         key.NewBinding(key.WithKeys("ctrl+x")),
+	Indent:      key.NewBinding(key.WithKeys("tab")),
+	Outdent:     key.NewBinding(key.WithKeys("shift+tab")),
+	Upcase:      key.NewBinding(key.WithKeys("alt+u")),
+	Downcase:    key.NewBinding(key.WithKeys("alt+l")),
+
+	Undo:              key.NewBinding(key.WithKeys("ctrl+z", "ctrl+_")),
+	Redo:              key.NewBinding(key.WithKeys("ctrl+r")),
+	AddCursorAbove:    key.NewBinding(key.WithKeys("ctrl+up")),
+	AddCursorBelow:    key.NewBinding(key.WithKeys("ctrl+down")),
+	AddNextOccurrence: key.NewBinding(key.WithKeys("alt+n")),
+	SplitSelection:    key.NewBinding(key.WithKeys("alt+s")),
+	BlockSelect:       key.NewBinding(key.WithKeys("alt+v")),
+	YankPop:           key.NewBinding(key.WithKeys("alt+y")),
+	MatchBracket:      key.NewBinding(key.WithKeys("alt+m")),
+	ToggleFold:        key.NewBinding(key.WithKeys("alt+z")),
+	ExpandSelection:   key.NewBinding(key.WithKeys("alt+up")),
+	ShrinkSelection:   key.NewBinding(key.WithKeys("alt+down")),
 }
 
 // LineInfo is a helper for keeping track of line information regarding
@@ -96,6 +149,12 @@ type LineInfo struct {
 	CharOffset int
 }
 
+// Sign is a marker shown in the gutter in front of a row, e.g. a git change.
+type Sign struct {
+	Char  string
+	Style lipgloss.Style
+}
+
 // Style that will be applied to the text area.
 //
 // Style can be applied to focused and unfocused states to change the styles
@@ -109,7 +168,14 @@ type Style struct {
 	EndOfBuffer      lipgloss.Style
 	LineNumber       lipgloss.Style
 	Prompt           lipgloss.Style
+	SecondaryCursor  lipgloss.Style
+	Selection        lipgloss.Style
+	MatchingBracket  lipgloss.Style
 	Text             lipgloss.Style
+	Keyword          lipgloss.Style
+	String           lipgloss.Style
+	Comment          lipgloss.Style
+	Number           lipgloss.Style
 }
 
 // Textarea is the Bubble Tea model for this text area element.
@@ -118,6 +184,8 @@ type Textarea struct {
 
 	// General settings.
 	ShowLineNumbers      bool
+	ShowSigns            bool
+	ShowFolds            bool
 	EndOfBufferCharacter rune
 	KeyMap               KeyMap
 
@@ -157,6 +225,28 @@ type Textarea struct {
 	// Cursor row.
 	row int
 
+	// anchor is the other end of the selection, the selected region is
+	// between the anchor and the cursor.
+	anchor views.Pos
+	// selecting whether a region is selected.
+	selecting bool
+	// markMode whether the selection was started by SetMark, in which case
+	// it is extended by the plain movements as well.
+	markMode bool
+
+	// others the cursors besides the primary one, which is described by the
+	// fields above.
+	others []cursorState
+
+	// block whether a rectangular selection is active, it spans from the row
+	// blockRow and the display column blockCol to the cursor.
+	block    bool
+	blockRow int
+	blockCol int
+	// blockYank the lines last copied from a block, they are pasted as a
+	// block again.
+	blockYank []string
+
 	// Last character offset, used to maintain state when the cursor is moved
 	// vertically such that we can maintain the same navigating position.
 	lastCharOffset int
@@ -164,11 +254,39 @@ type Textarea struct {
 	// lineNumberFormat is the format string used to display line numbers.
 	lineNumberFormat string
 
+	// Registers holds the kill ring and the named registers.
+	Registers *Registers
+	// killed the text killed by the current key, see flushKills.
+	killed []killed
+	// lastKill whether the previous key killed text.
+	lastKill bool
+	// yank the text inserted by the last yank.
+	yank yank
+
 	// viewport is the vertically-scrollable viewport of the multi-line text
 	// input.
 	viewport *viewport.Model
 
 	document *views.Document
+	// classCache the classes of the runes of the document, see classes.
+	classCache classCache
+	// highlightCache the highlighted tokens of the document, see highlights.
+	highlightCache highlightCache
+	// expansions the selections before they were expanded and expandedRange
+	// the expanded one, see ExpandSelection.
+	expansions    []expansion
+	expandedRange selectedRange
+
+	// signs the markers shown in the gutter, keyed by row.
+	signs map[int]Sign
+
+	// folded the first rows of the folded regions, see fold.go.
+	folded map[int]bool
+	// foldCache the fold regions of the document, see folds.
+	foldCache foldCache
+	// unfollowFolds stops moving the folded regions along with the edits
+	// of the document.
+	unfollowFolds func()
 }
 
 // NewTextArea creates a new model with default settings.
@@ -186,8 +304,11 @@ func NewTextArea() *Textarea {
 		BlurredStyle:         blurredStyle,
 		EndOfBufferCharacter: '~',
 		ShowLineNumbers:      true,
+		ShowSigns:            true,
+		ShowFolds:            true,
 		Cursor:               cur,
 		KeyMap:               DefaultKeyMap,
+		Registers:            NewRegisters(&clipboard.Memory{}), // the Ui's is written through its Output
 
 		focus:            false,
 		col:              0,
@@ -212,7 +333,14 @@ func DefaultStyles() (Style, Style) {
 		EndOfBuffer:      lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "254", Dark: "0"}),
 		LineNumber:       lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "249", Dark: "7"}),
 		Prompt:           lipgloss.NewStyle().Foreground(lipgloss.Color("7")),
+		SecondaryCursor:  lipgloss.NewStyle().Reverse(true),
+		Selection:        lipgloss.NewStyle().Background(lipgloss.AdaptiveColor{Light: "252", Dark: "238"}),
+		MatchingBracket:  lipgloss.NewStyle().Bold(true).Background(lipgloss.AdaptiveColor{Light: "250", Dark: "240"}),
 		Text:             lipgloss.NewStyle(),
+		Keyword:          lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "127", Dark: "176"}),
+		String:           lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "28", Dark: "114"}),
+		Comment:          lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "244", Dark: "243"}),
+		Number:           lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "130", Dark: "179"}),
 	}
 	blurred := Style{
 		Base:             lipgloss.NewStyle(),
@@ -220,7 +348,14 @@ func DefaultStyles() (Style, Style) {
 		EndOfBuffer:      lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "254", Dark: "0"}),
 		LineNumber:       lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "249", Dark: "7"}),
 		Prompt:           lipgloss.NewStyle().Foreground(lipgloss.Color("7")),
+		SecondaryCursor:  lipgloss.NewStyle().Reverse(true),
+		Selection:        lipgloss.NewStyle().Background(lipgloss.AdaptiveColor{Light: "252", Dark: "238"}),
+		MatchingBracket:  lipgloss.NewStyle().Bold(true).Background(lipgloss.AdaptiveColor{Light: "250", Dark: "240"}),
 		Text:             lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "245", Dark: "7"}),
+		Keyword:          lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "127", Dark: "176"}),
+		String:           lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "28", Dark: "114"}),
+		Comment:          lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "244", Dark: "243"}),
+		Number:           lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "130", Dark: "179"}),
 	}
 
 	return focused, blurred
@@ -239,8 +374,55 @@ func (m *Textarea) currentRowLen() int {
 	return len(m.document.Row(m.row))
 }
 
+// currentRuneWidth returns the display width of the rune under the cursor.
 func (m *Textarea) currentRuneWidth() int {
-	return m.document.Row(m.row).RuneWidth(m.col)
+	row := m.document.Row(m.row)
+	if m.col >= len(row) {
+		return 0
+	}
+	return views.RuneWidth(row[m.col], row.Width(m.col, m.tabWidth()), m.tabWidth())
+}
+
+// tabWidth returns the display width of a tab in the document.
+func (m *Textarea) tabWidth() int {
+	return m.document.Indent().TabWidth
+}
+
+// segmentWidth returns the display width of the columns in [start, end) of
+// the current row, the columns after the row count as spaces.
+func (m *Textarea) segmentWidth(start, end int) int {
+	row := m.document.Row(m.row)
+	w := row.Width(end, m.tabWidth()) - row.Width(start, m.tabWidth())
+	if end > len(row) {
+		w += end - max(start, len(row))
+	}
+	return w
+}
+
+// SetPosition moves the cursor to the given row and column, both are clamped
+// to the document.
+func (m *Textarea) SetPosition(row, col int) {
+	m.row = clamp(row, 0, m.document.Height()-1)
+	m.SetCursor(col)
+	m.repositionView()
+}
+
+// Position returns the cursor position.
+func (m *Textarea) Position() views.Pos {
+	return m.pos()
+}
+
+// SetSigns sets the markers shown in the gutter, keyed by row.
+func (m *Textarea) SetSigns(signs map[int]Sign) {
+	m.signs = signs
+}
+
+func (m *Textarea) sign(row int) string {
+	sign, ok := m.signs[row]
+	if !ok {
+		return strings.Repeat(" ", signWidth)
+	}
+	return sign.Style.Render(sign.Char)
 }
 
 // CursorStart moves the cursor to the start of the input field.
@@ -278,6 +460,9 @@ func (m *Textarea) Blur() {
 func (m *Textarea) Reset() {
 	m.col = 0
 	m.row = 0
+	m.ClearSelection()
+	m.ClearCursors()
+	m.ClearBlock()
 	m.viewport.GotoTop()
 	m.SetCursor(0)
 }
@@ -301,19 +486,19 @@ func (m *Textarea) LineInfo() LineInfo {
 				RowOffset:    i + 1,
 				StartColumn:  m.col,
 				Width:        len(grid[i+1]),
-				CharWidth:    rw.StringWidth(string(line)),
+				CharWidth:    m.segmentWidth(counter, counter+len(line)),
 			}
 		}
 
 		if counter+len(line) >= m.col {
 			return LineInfo{
-				CharOffset:   rw.StringWidth(string(line[:max(0, m.col-counter)])),
+				CharOffset:   m.segmentWidth(counter, max(counter, m.col)),
 				ColumnOffset: m.col - counter,
 				Height:       len(grid),
 				RowOffset:    i,
 				StartColumn:  counter,
 				Width:        len(line),
-				CharWidth:    rw.StringWidth(string(line)),
+				CharWidth:    m.segmentWidth(counter, counter+len(line)),
 			}
 		}
 
@@ -361,6 +546,12 @@ func (m *Textarea) SetWidth(w int) {
 	if m.ShowLineNumbers {
 		inputWidth -= rw.StringWidth(fmt.Sprintf(m.lineNumberFormat, 0))
 	}
+	if m.ShowSigns {
+		inputWidth -= signWidth
+	}
+	if m.ShowFolds {
+		inputWidth -= foldWidth
+	}
 
 	// Account for base style borders and padding.
 	inputWidth -= m.style.Base.GetHorizontalFrameSize()
@@ -386,37 +577,27 @@ func (m *Textarea) Update(msg tea.Msg) (*Textarea, tea.Cmd) {
 
 	var cmds []tea.Cmd
 
+	// the rows may have changed behind the cursor, e.g. by a plugin.
 	if !m.document.Rows.Has(m.row) {
-		m.document.Rows = m.document.Rows.NewLine()
+		m.SetPosition(m.row, m.col)
 	}
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch {
-		case key.Matches(msg, m.KeyMap.MoveLeft):
-			if m.col == 0 && m.row != 0 {
-				m.row--
-				m.CursorEnd()
-				break
-			}
-			if m.col > 0 {
-				m.SetCursor(m.col - 1)
-			}
-		case key.Matches(msg, m.KeyMap.MoveRight):
-			if m.col < m.currentRowLen() {
-				m.SetCursor(m.col + 1)
-			} else {
-				if m.row < m.document.Height()-1 {
-					m.row++
-					m.CursorStart()
-				}
-			}
-		case key.Matches(msg, m.KeyMap.MoveDown):
-			m.MoveDown()
-		case key.Matches(msg, m.KeyMap.MoveUp):
-			m.MoveUp()
+		if !key.Matches(msg, m.KeyMap.YankPop) {
+			m.yank.active = false
 		}
-
+		m.document.BeginGroup()
+		cmds = append(cmds, m.handleKeys(msg), m.flushKills())
+		m.document.EndGroup()
+	case pasteMsg:
+		if m.block || m.isBlockYank(string(msg)) {
+			m.pasteBlock(string(msg))
+			break
+		}
+		m.yankText(string(msg))
+	case pasteErrMsg:
+		m.Err = msg
 	}
 
 	vp, cmd := m.viewport.Update(msg)
@@ -431,71 +612,474 @@ func (m *Textarea) Update(msg tea.Msg) (*Textarea, tea.Cmd) {
 	}
 	cmds = append(cmds, cmd)
 
+	m.revealCursor()
 	m.repositionView()
 
 	return m, tea.Batch(cmds...)
 }
 
+// handleKeys handles the key bindings concerning all cursors and runs
+// handleKey for each cursor otherwise.
+func (m *Textarea) handleKeys(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.KeyMap.Undo):
+		m.Undo()
+		return nil
+	case key.Matches(msg, m.KeyMap.Redo):
+		m.Redo()
+		return nil
+	case key.Matches(msg, m.KeyMap.AddCursorAbove):
+		m.AddCursor(-1)
+		return nil
+	case key.Matches(msg, m.KeyMap.AddCursorBelow):
+		m.AddCursor(1)
+		return nil
+	case key.Matches(msg, m.KeyMap.AddNextOccurrence):
+		m.AddNextOccurrence()
+		return nil
+	case key.Matches(msg, m.KeyMap.SplitSelection):
+		m.SplitSelection()
+		return nil
+	case key.Matches(msg, m.KeyMap.YankPop):
+		m.YankPop()
+		return nil
+	case key.Matches(msg, m.KeyMap.BlockSelect):
+		m.StartBlock()
+		return nil
+	case key.Matches(msg, m.KeyMap.ToggleFold):
+		_ = m.ToggleFold()
+		return nil
+	case key.Matches(msg, m.KeyMap.ExpandSelection):
+		m.ExpandSelection()
+		return nil
+	case key.Matches(msg, m.KeyMap.ShrinkSelection):
+		m.ShrinkSelection()
+		return nil
+	case m.block:
+		return m.handleBlockKey(msg)
+	case len(m.others) <= 0:
+		return m.handleKey(msg)
+	case key.Matches(msg, m.KeyMap.Cancel):
+		// the first cancel deselects, the next one leaves a single cursor.
+		selecting := false
+		m.forEachCursor(func() {
+			selecting = selecting || m.selecting
+			m.ClearSelection()
+		})
+		if !selecting {
+			m.ClearCursors()
+		}
+		return nil
+	case key.Matches(msg, m.KeyMap.Copy, m.KeyMap.Cut):
+		return m.Copy(m.copySelections(key.Matches(msg, m.KeyMap.Cut)))
+	case key.Matches(msg, m.KeyMap.Paste):
+		return m.Paste
+	}
+
+	var cmds []tea.Cmd
+	m.forEachCursor(func() {
+		cmds = append(cmds, m.handleKey(msg))
+	})
+	return tea.Batch(cmds...)
+}
+
+// handleKey handles the key bindings of the KeyMap for the cursor.
+func (m *Textarea) handleKey(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.KeyMap.SetMark):
+		m.anchor, m.selecting, m.markMode = m.pos(), true, true
+		return nil
+	case key.Matches(msg, m.KeyMap.Cancel):
+		m.ClearSelection()
+		return nil
+	case key.Matches(msg, m.KeyMap.SelectLeft):
+		m.startSelection()
+		m.moveLeft()
+		return nil
+	case key.Matches(msg, m.KeyMap.SelectRight):
+		m.startSelection()
+		m.moveRight()
+		return nil
+	case key.Matches(msg, m.KeyMap.SelectUp):
+		m.startSelection()
+		m.MoveUp()
+		return nil
+	case key.Matches(msg, m.KeyMap.SelectDown):
+		m.startSelection()
+		m.MoveDown()
+		return nil
+	}
+
+	if m.selecting {
+		switch {
+		case key.Matches(msg, m.KeyMap.Copy):
+			text := m.SelectedText()
+			m.ClearSelection()
+			return m.Copy(text)
+		case key.Matches(msg, m.KeyMap.Cut):
+			text := m.SelectedText()
+			m.DeleteSelection()
+			return m.Copy(text)
+		case key.Matches(msg, m.KeyMap.Indent):
+			m.IndentSelection()
+			return nil
+		case key.Matches(msg, m.KeyMap.Outdent):
+			m.OutdentSelection()
+			return nil
+		case key.Matches(msg, m.KeyMap.Upcase):
+			m.UpcaseSelection()
+			return nil
+		case key.Matches(msg, m.KeyMap.Downcase):
+			m.DowncaseSelection()
+			return nil
+		case key.Matches(msg, m.KeyMap.DeleteCharacterBackward, m.KeyMap.DeleteCharacterForward):
+			m.DeleteSelection()
+			return nil
+		case key.Matches(msg, m.KeyMap.Paste):
+			// the selection is replaced once the clipboard is read.
+			return m.Paste
+		case key.Matches(msg, m.KeyMap.InsertNewline),
+			!msg.Alt && (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace):
+			m.DeleteSelection()
+		case m.markMode && m.isMovement(msg):
+			// extend the selection
+		default:
+			m.ClearSelection()
+		}
+	}
+
+	switch {
+	case key.Matches(msg, m.KeyMap.DeleteAfterCursor):
+		if m.col >= m.currentRowLen() {
+			m.killTo(views.Pos{Row: m.row + 1})
+			break
+		}
+		m.killTo(views.Pos{Row: m.row, Col: m.currentRowLen()})
+	case key.Matches(msg, m.KeyMap.DeleteBeforeCursor):
+		m.killTo(views.Pos{Row: m.row})
+	case key.Matches(msg, m.KeyMap.DeleteCharacterBackward):
+		if m.col <= 0 {
+			m.mergeLineAbove(m.row)
+			break
+		}
+		if !m.deletePair() {
+			m.deleteTo(m.col - 1)
+		}
+	case key.Matches(msg, m.KeyMap.DeleteCharacterForward):
+		if m.col >= m.currentRowLen() {
+			m.mergeLineBelow(m.row)
+			break
+		}
+		m.document.Replace(m.pos(), views.Pos{Row: m.row, Col: m.col + 1}, "")
+	case key.Matches(msg, m.KeyMap.DeleteWordBackward):
+		if m.col <= 0 && m.row > 0 {
+			m.killTo(views.Pos{Row: m.row - 1, Col: len(m.document.Row(m.row - 1))})
+			break
+		}
+		m.killTo(views.Pos{Row: m.row, Col: m.wordLeft()})
+	case key.Matches(msg, m.KeyMap.DeleteWordForward):
+		if m.col >= m.currentRowLen() {
+			m.killTo(views.Pos{Row: m.row + 1})
+			break
+		}
+		m.killTo(views.Pos{Row: m.row, Col: m.wordRight()})
+	case key.Matches(msg, m.KeyMap.InsertNewline):
+		m.newline()
+	case key.Matches(msg, m.KeyMap.Outdent):
+		m.outdentRow()
+	case key.Matches(msg, m.KeyMap.LineEnd):
+		m.CursorEnd()
+	case key.Matches(msg, m.KeyMap.LineStart):
+		m.CursorStart()
+	case key.Matches(msg, m.KeyMap.WordLeft):
+		m.SetCursor(m.wordLeft())
+	case key.Matches(msg, m.KeyMap.WordRight):
+		m.SetCursor(m.wordRight())
+	case key.Matches(msg, m.KeyMap.Paste):
+		return m.Paste
+	case key.Matches(msg, m.KeyMap.MoveLeft):
+		m.moveLeft()
+	case key.Matches(msg, m.KeyMap.MoveRight):
+		m.moveRight()
+	case key.Matches(msg, m.KeyMap.MoveDown):
+		m.MoveDown()
+	case key.Matches(msg, m.KeyMap.MoveUp):
+		m.MoveUp()
+	case key.Matches(msg, m.KeyMap.MatchBracket):
+		m.JumpToMatchingBracket()
+	default:
+		switch {
+		case msg.Alt:
+			// unbound alt combinations are not text.
+		case msg.Type == tea.KeyRunes, msg.Type == tea.KeySpace:
+			m.insertRunes(msg.Runes)
+			if m.isMarkdown() && string(msg.Runes) == "|" {
+				m.AlignTable()
+			}
+		case msg.Type == tea.KeyTab:
+			if !m.isMarkdown() || !m.nextTableCell() {
+				m.insertIndent()
+			}
+		}
+	}
+	return nil
+}
+
+// isMovement reports whether msg only moves the cursor.
+func (m *Textarea) isMovement(msg tea.KeyMsg) bool {
+	return key.Matches(msg, m.KeyMap.MoveLeft, m.KeyMap.MoveRight, m.KeyMap.MoveUp, m.KeyMap.MoveDown,
+		m.KeyMap.LineStart, m.KeyMap.LineEnd, m.KeyMap.WordLeft, m.KeyMap.WordRight, m.KeyMap.MatchBracket)
+}
+
+func (m *Textarea) moveLeft() {
+	if m.col == 0 && m.row != 0 {
+		m.row, _ = m.visibleRow(m.row, -1)
+		m.CursorEnd()
+		return
+	}
+	if m.col > 0 {
+		m.SetCursor(m.col - 1)
+	}
+}
+
+func (m *Textarea) moveRight() {
+	if m.col < m.currentRowLen() {
+		m.SetCursor(m.col + 1)
+	} else {
+		if next, ok := m.visibleRow(m.row, 1); ok {
+			m.row = next
+			m.CursorStart()
+		}
+	}
+}
+
 // View renders the text area in its current state.
 func (m *Textarea) View() string {
 	fluent := str.NewFluent()
 
-	lineInfo := m.LineInfo()
+	carets, spans := m.secondaryCursors()
+	brackets := m.bracketHighlights()
+	tokens := m.highlights()
+	m.revealCursor()
+	hidden := m.hiddenRows()
 	for l, line := range m.document.Rows {
+		if isHidden(hidden, l) {
+			continue
+		}
+
+		if m.ShowSigns {
+			fluent.Str(m.sign(l))
+		}
 
 		// write line number
 		if m.ShowLineNumbers {
 			fluent.Str(fmt.Sprintf(m.lineNumberFormat, l+1))
 		}
+		if m.ShowFolds {
+			fluent.Str(m.foldIndicator(l))
+		}
 
-		s := line.String()
+		s := expandTabs(line, m.tabWidth())
 		sWidth := rw.StringWidth(s)
 		padding := m.width - sWidth
 		if sWidth > m.width {
 			padding -= m.width - sWidth
 		}
+		summary := m.foldSummary(l)
+		padding -= rw.StringWidth(summary)
 
-		if m.row == l /*&& lineInfo.RowOffset == l*/ {
-			fluent.Str(line[:lineInfo.ColumnOffset].String())
-			if m.col >= len(line) /*&& lineInfo.CharOffset >= m.width*/ {
-				m.Cursor.SetChar(" ")
-				fluent.Str(m.Cursor.View())
-			} else {
-				m.Cursor.SetChar(string(line[lineInfo.ColumnOffset]))
-				fluent.Str(m.Cursor.View())
-				fluent.Str(line[lineInfo.ColumnOffset+1:].String())
+		if m.row == l || len(carets[l]) > 0 || len(spans[l]) > 0 || len(brackets[l]) > 0 || len(tokens[l]) > 0 {
+			cursor := -1
+			if m.row == l {
+				cursor = m.col
+			}
+			if cursor >= len(line) || hasCaretAfter(carets[l], len(line)) {
+				padding--
 			}
+			fluent.Str(m.renderLine(line, cursor, carets[l], spans[l], brackets[l], tokens[l]))
 		} else {
 			fluent.Str(s)
 		}
+		if summary != "" {
+			fluent.Str(m.style.LineNumber.Render(summary))
+		}
 
 		fluent.Space(max(0, padding)).NewLine()
 	}
 
 	// write blank
 	for i := 0; i < m.height; i++ {
+		if m.ShowSigns {
+			fluent.Space(signWidth)
+		}
 		if m.ShowLineNumbers {
 			lineNumber := m.style.EndOfBuffer.Render(fmt.Sprintf(m.lineNumberFormat, string(m.EndOfBufferCharacter)))
 			fluent.Str(lineNumber)
 		}
+		if m.ShowFolds {
+			fluent.Space(foldWidth)
+		}
 		fluent.NewLine()
 	}
 
 	m.viewport.SetContent(fluent.String())
+	// the content may have changed since the last Update, e.g. a jump to
+	// another document.
+	m.repositionView()
 
 	return m.style.Base.Render(m.viewport.View())
 }
 
+// renderLine renders line with the cursor at column cursor, the secondary
+// cursors at the columns carets, the runes in the spans selected, the
+// brackets at the columns highlighted and the tokens in their style, cursor
+// is -1 when the cursor is not on the line.
+func (m *Textarea) renderLine(line views.Row, cursor int, carets []int, spans []span, brackets []int, tokens []tokenSpan) string {
+	var (
+		fluent  = str.NewFluent()
+		segment []rune
+		style   *lipgloss.Style
+	)
+	flush := func() {
+		if len(segment) <= 0 {
+			return
+		}
+		if style != nil {
+			fluent.Str(style.Render(string(segment)))
+		} else {
+			fluent.Str(string(segment))
+		}
+		segment = segment[:0]
+	}
+
+	width := 0
+	for i, r := range line {
+		// a tab is shown as the spaces up to the next tab stop.
+		runes := []rune{r}
+		if r == '\t' {
+			runes = repeatSpaces(views.RuneWidth(r, width, m.tabWidth()))
+		}
+		width += views.RuneWidth(r, width, m.tabWidth())
+
+		if i == cursor {
+			flush()
+			m.Cursor.SetChar(string(runes[0]))
+			fluent.Str(m.Cursor.View())
+			fluent.Str(string(runes[1:]))
+			continue
+		}
+
+		var s *lipgloss.Style
+		switch {
+		case containsInt(carets, i):
+			s = &m.style.SecondaryCursor
+		case containsInt(brackets, i):
+			s = &m.style.MatchingBracket
+		case inSpans(spans, i):
+			s = &m.style.Selection
+		default:
+			for len(tokens) > 0 && tokens[0].end <= i {
+				tokens = tokens[1:]
+			}
+			if len(tokens) > 0 && tokens[0].start <= i {
+				s = m.tokenStyle(tokens[0].kind)
+			}
+		}
+		if s != style {
+			flush()
+			style = s
+		}
+		segment = append(segment, runes...)
+	}
+	flush()
+
+	switch {
+	case cursor >= len(line):
+		m.Cursor.SetChar(" ")
+		fluent.Str(m.Cursor.View())
+	case hasCaretAfter(carets, len(line)):
+		fluent.Str(m.style.SecondaryCursor.Render(" "))
+	}
+	return fluent.String()
+}
+
+// span is a selected range of columns of a row.
+type span struct {
+	start int
+	end   int
+}
+
+// secondaryCursors returns the columns of the cursors besides the primary one
+// and the selected spans of all cursors, keyed by row.
+func (m *Textarea) secondaryCursors() (map[int][]int, map[int][]span) {
+	carets, spans := map[int][]int{}, map[int][]span{}
+	for _, c := range m.cursors() {
+		if !c.primary {
+			carets[c.row] = append(carets[c.row], c.col)
+		}
+
+		if b, ok := m.Block(); ok && c.primary {
+			m.blockSpans(b, carets, spans)
+			continue
+		}
+
+		from, to := c.bounds()
+		for row := from.Row; row <= to.Row && from != to; row++ {
+			s := span{start: 0, end: len(m.document.Row(row))}
+			if row == from.Row {
+				s.start = from.Col
+			}
+			if row == to.Row {
+				s.end = to.Col
+			}
+			spans[row] = append(spans[row], s)
+		}
+	}
+	return carets, spans
+}
+
+// hasCaretAfter reports whether one of the carets is at or after col.
+func hasCaretAfter(carets []int, col int) bool {
+	for _, c := range carets {
+		if c >= col {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func inSpans(spans []span, col int) bool {
+	for _, s := range spans {
+		if col >= s.start && col < s.end {
+			return true
+		}
+	}
+	return false
+}
+
 // Blink returns the blink command for the cursor.
 func Blink() tea.Msg {
 	return cursor.Blink()
 }
 
 // cursorLineNumber returns the line number that the cursor is on.
-// This accounts for soft wrapped lines.
+// This accounts for soft wrapped lines and the folded rows.
 func (m *Textarea) cursorLineNumber() int {
 	line := 0
+	hidden := m.hiddenRows()
 	for i := 0; i < m.row; i++ {
+		if isHidden(hidden, i) {
+			continue
+		}
 		// Calculate the number of lines that the current line will be split
 		// into.
 		line += len(wrap(m.document.Row(i), m.width))
@@ -510,18 +1094,7 @@ func (m *Textarea) mergeLineBelow(row int) {
 		return
 	}
 
-	// To perform a merge, we will need to combine the two lines and then
-	m.document.Rows[row] = append(m.document.Rows[row], m.document.Rows[row+1]...)
-
-	// Shift all lines up by one
-	for i := row + 1; i < m.document.Height()-1; i++ {
-		m.document.Rows[i] = m.document.Rows[i+1]
-	}
-
-	// And, remove the last line
-	if m.document.Height() > 0 {
-		m.document.Rows = m.document.Rows[:m.document.Height()-1]
-	}
+	m.document.Replace(views.Pos{Row: row, Col: len(m.document.Row(row))}, views.Pos{Row: row + 1}, "")
 }
 
 // mergeLineAbove merges the current line the cursor is on with the line above.
@@ -530,31 +1103,71 @@ func (m *Textarea) mergeLineAbove(row int) {
 		return
 	}
 
-	m.col = len(m.document.Rows[row-1])
+	m.col = len(m.document.Row(row - 1))
 	m.row = m.row - 1
 
-	// To perform a merge, we will need to combine the two lines and then
-	m.document.Rows[row-1] = append(m.document.Rows[row-1], m.document.Rows[row]...)
+	m.document.Replace(views.Pos{Row: row - 1, Col: m.col}, views.Pos{Row: row}, "")
+}
+
+// InsertString inserts s at the cursor and moves the cursor after it.
+func (m *Textarea) InsertString(s string) {
+	end := m.document.Replace(m.pos(), m.pos(), s)
+	m.row = end.Row
+	m.SetCursor(end.Col)
+}
 
-	// Shift all lines up by one
-	for i := row; i < m.document.Height()-1; i++ {
-		m.document.Rows[i] = m.document.Rows[i+1]
+// deleteTo deletes the runes between col and the cursor in the current row.
+func (m *Textarea) deleteTo(col int) {
+	from, to := views.Pos{Row: m.row, Col: col}, m.pos()
+	if to.Before(from) {
+		from, to = to, from
 	}
+	m.document.Replace(from, to, "")
+	m.SetCursor(from.Col)
+}
 
-	// And, remove the last line
-	if m.document.Height() > 0 {
-		m.document.Rows = m.document.Rows[:m.document.Height()-1]
+// deleteAfterCursor deletes all text after the cursor in the current row.
+func (m *Textarea) deleteAfterCursor() {
+	m.deleteTo(m.currentRowLen())
+}
+
+// deleteBeforeCursor deletes all text before the cursor in the current row.
+func (m *Textarea) deleteBeforeCursor() {
+	m.deleteTo(0)
+}
+
+// wordLeft returns the column of the start of the word before the cursor.
+func (m *Textarea) wordLeft() int {
+	row, col := m.document.Row(m.row), m.col
+	for col > 0 && unicode.IsSpace(row[col-1]) {
+		col--
 	}
+	for col > 0 && !unicode.IsSpace(row[col-1]) {
+		col--
+	}
+	return col
 }
 
-func (m *Textarea) splitLine(row, col int) {
-	m.document.SplitLine(row, col)
-	m.col = 0
-	m.row++
+// wordRight returns the column of the end of the word after the cursor.
+func (m *Textarea) wordRight() int {
+	row, col := m.document.Row(m.row), m.col
+	for col < len(row) && unicode.IsSpace(row[col]) {
+		col++
+	}
+	for col < len(row) && !unicode.IsSpace(row[col]) {
+		col++
+	}
+	return col
+}
+
+// pos returns the cursor position.
+func (m *Textarea) pos() views.Pos {
+	return views.Pos{Row: m.row, Col: m.col}
 }
 
 func (m *Textarea) SetDocument(document *views.Document) {
 	m.document = document
+	m.followFolds()
 	m.Reset()
 }
 
@@ -564,7 +1177,7 @@ func (m *Textarea) MoveUp() {
 	m.lastCharOffset = charOffset
 
 	if li.RowOffset <= 0 && m.row > 0 {
-		m.row--
+		m.row, _ = m.visibleRow(m.row, -1)
 		m.col = m.currentRowLen()
 	} else {
 		// Move the cursor to the end of the previous line.
@@ -596,8 +1209,8 @@ func (m *Textarea) MoveDown() {
 	charOffset := max(m.lastCharOffset, li.CharOffset)
 	m.lastCharOffset = charOffset
 
-	if li.RowOffset+1 >= li.Height && m.row < m.document.Rows.Len()-1 {
-		m.row++
+	if next, ok := m.visibleRow(m.row, 1); li.RowOffset+1 >= li.Height && ok {
+		m.row = next
 		m.col = 0
 	} else {
 		// Move the cursor to the start of the next line. So that we can get
@@ -623,15 +1236,6 @@ func (m *Textarea) MoveDown() {
 	}
 }
 
-// Paste is a command for pasting from the clipboard into the text input.
-func Paste() tea.Msg {
-	str, err := clipboard.ReadAll()
-	if err != nil {
-		return pasteErrMsg{err}
-	}
-	return pasteMsg(str)
-}
-
 func wrap(runes []rune, width int) [][]rune {
 	var (
 		lines  = [][]rune{{}}
@@ -701,6 +1305,27 @@ func repeatSpaces(n int) []rune {
 	return []rune(strings.Repeat(string(' '), n))
 }
 
+// expandTabs returns the row with the tabs replaced by the spaces up to the
+// next tab stop.
+func expandTabs(row views.Row, tabWidth int) string {
+	if !strings.ContainsRune(string(row), '\t') {
+		return row.String()
+	}
+
+	var sb strings.Builder
+	width := 0
+	for _, r := range row {
+		w := views.RuneWidth(r, width, tabWidth)
+		if r == '\t' {
+			sb.WriteString(strings.Repeat(" ", w))
+		} else {
+			sb.WriteRune(r)
+		}
+		width += w
+	}
+	return sb.String()
+}
+
 func clamp(v, low, high int) int {
 	if high < low {
 		low, high = high, low
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fzdwx/x/str"
+	rw "github.com/mattn/go-runewidth"
+)
+
+// TextPane shows some read-only lines, e.g. the preview of a git hunk, the
+// lines may be styled.
+type TextPane struct {
+	title string
+	lines []string
+
+	// offset is the index of the first visible line.
+	offset int
+
+	width  int
+	height int
+}
+
+func NewTextPane(title string, lines ...string) *TextPane {
+	return &TextPane{title: title, lines: lines}
+}
+
+// Append adds lines to the end of the pane.
+func (p *TextPane) Append(lines ...string) {
+	p.lines = append(p.lines, lines...)
+}
+
+func (p *TextPane) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+func (p *TextPane) scroll(n int) {
+	p.offset = clamp(p.offset+n, 0, max(0, len(p.lines)-(p.height-1)))
+}
+
+func (p *TextPane) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, resultsUp):
+			p.scroll(-1)
+		case key.Matches(msg, resultsDown):
+			p.scroll(1)
+		}
+	}
+	return nil
+}
+
+func (p *TextPane) View() string {
+	fluent := str.NewFluent()
+	fluent.Str(paneTitleStyle.Render(rw.Truncate(p.title, p.width, "")))
+
+	for i := p.offset; i < p.offset+p.height-1; i++ {
+		fluent.NewLine()
+		if i < len(p.lines) {
+			fluent.Str(lipgloss.NewStyle().MaxWidth(p.width).Render(p.lines[i]))
+		}
+	}
+
+	return fluent.String()
+}
@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fzdwx/ge/internal/syntax"
+	"github.com/fzdwx/ge/internal/views"
+)
+
+type (
+	// tokenSpan the columns of a row covered by a highlighted token.
+	tokenSpan struct {
+		start int
+		end   int
+		kind  syntax.Kind
+	}
+
+	// highlightCache the highlighted tokens of a revision of a document,
+	// keyed by row.
+	highlightCache struct {
+		document *views.Document
+		revision int
+		typ      string
+		tokens   map[int][]tokenSpan
+	}
+
+	// expansion the selection before it was expanded, see ExpandSelection.
+	expansion struct {
+		anchor    views.Pos
+		cursor    views.Pos
+		selecting bool
+	}
+
+	// selectedRange a selected region of a revision of a document.
+	selectedRange struct {
+		document *views.Document
+		from     views.Pos
+		to       views.Pos
+		revision int
+	}
+)
+
+// highlights returns the highlighted tokens of the document keyed by row,
+// they are kept until the document changes.
+func (m *Textarea) highlights() map[int][]tokenSpan {
+	c := &m.highlightCache
+	if c.document == m.document && c.revision == m.document.Revision() && c.typ == m.document.Type() {
+		return c.tokens
+	}
+
+	*c = highlightCache{document: m.document, revision: m.document.Revision(), typ: m.document.Type()}
+	tree := m.document.Tree()
+	if !tree.Highlighted() {
+		return nil
+	}
+
+	c.tokens = map[int][]tokenSpan{}
+	for _, token := range tree.Tokens(0, m.document.Height()) {
+		if m.tokenStyle(token.Kind) == nil {
+			continue
+		}
+		for row := token.Start.Row; row <= token.End.Row; row++ {
+			s := tokenSpan{start: 0, end: len(m.document.Row(row)), kind: token.Kind}
+			if row == token.Start.Row {
+				s.start = token.Start.Col
+			}
+			if row == token.End.Row {
+				s.end = token.End.Col
+			}
+			if s.start < s.end {
+				c.tokens[row] = append(c.tokens[row], s)
+			}
+		}
+	}
+	return c.tokens
+}
+
+// tokenStyle returns the style of the tokens of kind, nil if they aren't
+// highlighted.
+func (m *Textarea) tokenStyle(kind syntax.Kind) *lipgloss.Style {
+	switch kind {
+	case syntax.KeywordNode:
+		return &m.style.Keyword
+	case syntax.StringNode:
+		return &m.style.String
+	case syntax.CommentNode:
+		return &m.style.Comment
+	case syntax.NumberNode:
+		return &m.style.Number
+	}
+	return nil
+}
+
+// ExpandSelection selects the smallest node of the parse tree around the
+// selection, or around the cursor. It returns false if the whole document
+// is selected already.
+func (m *Textarea) ExpandSelection() bool {
+	from, to, ok := m.Selection()
+	if !ok {
+		from, to = m.pos(), m.pos()
+	}
+	if !m.expanded() {
+		m.expansions = nil
+	}
+
+	start, end, ok := m.document.Tree().Expand(toPoint(from), toPoint(to))
+	if !ok {
+		return false
+	}
+	m.expansions = append(m.expansions, expansion{anchor: m.anchor, cursor: m.pos(), selecting: m.selecting})
+	m.Select(fromPoint(start), fromPoint(end))
+	m.expandedRange = m.selectedRange()
+	return true
+}
+
+// ShrinkSelection restores the selection before the last ExpandSelection,
+// false if the selection wasn't expanded.
+func (m *Textarea) ShrinkSelection() bool {
+	if !m.expanded() || len(m.expansions) == 0 {
+		m.expansions = nil
+		return false
+	}
+
+	last := m.expansions[len(m.expansions)-1]
+	m.expansions = m.expansions[:len(m.expansions)-1]
+	if !last.selecting {
+		m.ClearSelection()
+		m.SetPosition(last.cursor.Row, last.cursor.Col)
+		return true
+	}
+	m.Select(last.anchor, last.cursor)
+	m.expandedRange = m.selectedRange()
+	return true
+}
+
+// expanded reports whether the selection is the one left by the last
+// ExpandSelection or ShrinkSelection, i.e. it was neither moved nor edited
+// since.
+func (m *Textarea) expanded() bool {
+	_, _, ok := m.Selection()
+	return ok && m.selectedRange() == m.expandedRange
+}
+
+func (m *Textarea) selectedRange() selectedRange {
+	from, to, _ := m.Selection()
+	return selectedRange{document: m.document, from: from, to: to, revision: m.document.Revision()}
+}
+
+func toPoint(p views.Pos) syntax.Point {
+	return syntax.Point{Row: p.Row, Col: p.Col}
+}
+
+func fromPoint(p syntax.Point) views.Pos {
+	return views.Pos{Row: p.Row, Col: p.Col}
+}
@@ -1,12 +1,21 @@
 package ui
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/fzdwx/ge/config"
+	"github.com/fzdwx/ge/internal/clipboard"
+	"github.com/fzdwx/ge/internal/rpcplugin"
+	"github.com/fzdwx/ge/internal/script"
 	"github.com/fzdwx/ge/internal/teax"
 	"github.com/fzdwx/ge/internal/views"
+	"github.com/fzdwx/ge/internal/watch"
+	rw "github.com/mattn/go-runewidth"
 )
 
 type (
@@ -15,10 +24,70 @@ type (
 
 		// current document
 		document *views.Document
+		// documents all open documents
+		documents []*views.Document
 
 		textarea *Textarea
+		prompt   *Prompt
+
+		// pane is shown below the textarea, nil when closed.
+		pane pane
+		// paneFocused whether the key events goes to the pane instead of the textarea.
+		paneFocused bool
+
+		// terminal the terminal pane, it keeps running while another pane is
+		// shown.
+		terminal *TerminalPane
+
+		// side is shown beside the textarea, e.g. the Markdown preview, nil
+		// when closed.
+		side sidePane
+
+		// diffView replaces the textarea while two documents are compared.
+		diffView *DiffView
+
+		// status the message shown in the last line.
+		status string
+		// failed the last error reported by a command, checked by Batch.
+		failed error
+
+		commands map[string]Command
+		searcher searcher
+		compiler compiler
+		// symbols the symbols of the Go documents, see symbolIndex.
+		symbols *symbolIndex
+		// git the changes of the git tracked documents.
+		git map[*views.Document]*gitChanges
+		// watcher reports the changes of the open files made by other processes.
+		watcher watch.Watcher
+		// swapped the revisions of the documents written to their swap file.
+		swapped map[*views.Document]int
+		// crashed whether a panic has stopped the Ui.
+		crashed bool
+		// quitting whether the user is asked to quit, discarded whether the
+		// user quit without keeping the unsaved changes in swap files.
+		quitting  bool
+		discarded bool
+		macros    macros
+
+		// scripts runs the plugins.
+		scripts *script.Engine
+		// bindings the command lines run by keys, bound by the plugins.
+		bindings map[string]string
+		// plugins the running executable plugins, their requests are sent to
+		// pluginRequests.
+		plugins        []*rpcplugin.Plugin
+		pluginRequests chan *rpcplugin.Request
+
+		// previews is signaled when the preview pane rendered a document
+		// in the background.
+		previews chan struct{}
+
+		width  int
+		height int
 
 		Program *tea.Program
+		Output  *Output
 		Keymap  *Keymap
 	}
 )
@@ -32,6 +101,8 @@ var (
 
 	blurredBorderStyle = lipgloss.NewStyle().
 				Border(lipgloss.HiddenBorder())
+
+	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
 )
 
 func New(cfg *config.Config) *Ui {
@@ -41,37 +112,189 @@ func New(cfg *config.Config) *Ui {
 	area.FocusedStyle.Base = focusedBorderStyle
 	area.BlurredStyle.Base = blurredBorderStyle
 	area.Focus()
+	out := NewOutput(os.Stdout)
+	area.Registers = NewRegisters(clipboard.New(out))
 	this := &Ui{
+		Output:   out,
 		Keymap:   NewKeymap(),
 		textarea: area,
+		prompt:   NewPrompt(),
+		commands: map[string]Command{},
+		git:      map[*views.Document]*gitChanges{},
+		watcher:  watch.New(),
+		swapped:  map[*views.Document]int{},
+		symbols:  newSymbolIndex(),
+		bindings: map[string]string{},
 		cfg:      cfg,
+
+		pluginRequests: make(chan *rpcplugin.Request),
+		previews:       make(chan struct{}, 1),
 	}
+	this.registerBuiltinCommands()
 	return this
 }
 
 func (u *Ui) Init() tea.Cmd {
-	batch := teax.Batch(Blink)
+	defer u.recoverPanic()
+
+	batch := teax.Batch(Blink, waitFileEvent(u.watcher), waitSymbols(u.symbols), waitPreview(u.previews), swapTick())
+	batch.Append(u.Output.size()).Append(waitResize(u.Output))
+	batch.Check(u.loadMacros(""))
+
+	if u.cfg.Diff && len(u.cfg.Filenames) >= 2 {
+		return batch.Append(u.diffFiles(u.cfg.Filenames[0], u.cfg.Filenames[1])).Cmd()
+	}
 
 	document, err := views.LoadDocument(u.cfg.Filenames...)
-	u.document = document
-	u.textarea.SetDocument(u.document)
+	u.addDocument(document)
+	batch.Append(u.show(document))
 	batch.Check(err)
+	// the plugins see the first document.
+	batch.Append(u.loadPlugins()).
+		Append(u.startPlugins()).
+		Append(waitPluginRequest(u.pluginRequests))
 	return batch.Cmd()
 }
 
 func (u *Ui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer u.recoverPanic()
+	defer u.refreshGitSigns()
+	defer u.syncSide()
+	defer u.refreshSymbols()
+
 	batch := teax.Batch()
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		u.status = ""
+		if u.terminalFocused() && !u.prompt.Active() && !key.Matches(msg, u.Keymap.otherPane) {
+			// the terminal gets every key, ctrl+c interrupts its program.
+			return u, u.terminal.Update(msg)
+		}
+		if key.Matches(msg, u.Keymap.quit) {
+			return u, u.quit()
+		}
+
+		u.recordKey(msg)
+		if u.prompt.Active() {
+			return u, u.prompt.Update(msg)
+		}
+
+		if line, ok := u.bindings[msg.String()]; ok {
+			return u, u.Execute(line)
+		}
+
 		switch {
-		case key.Matches(msg, u.Keymap.quit):
-			return u, tea.Quit
+		case key.Matches(msg, u.Keymap.recordMacro):
+			return u, u.askRecordMacro()
+		case key.Matches(msg, u.Keymap.stopMacro):
+			u.stopMacro()
+			return u, nil
+		case key.Matches(msg, u.Keymap.playMacro):
+			return u, u.playMacro("", 1)
+		case key.Matches(msg, u.Keymap.compile):
+			return u, u.compile(u.cfg.CompileCommand)
+		case key.Matches(msg, u.Keymap.nextError):
+			return u, u.nextLocation(1)
+		case key.Matches(msg, u.Keymap.prevError):
+			return u, u.nextLocation(-1)
+		case key.Matches(msg, u.Keymap.terminal):
+			return u, u.openTerminal()
+		case key.Matches(msg, u.Keymap.filterRegion):
+			return u, u.askFilterRegion()
+		case key.Matches(msg, u.Keymap.command):
+			return u, u.prompt.Ask("M-x ", "", u.Execute)
+		case key.Matches(msg, u.Keymap.save):
+			return u, u.save("")
+		case key.Matches(msg, u.Keymap.searchInFiles):
+			return u, u.askSearchInFiles()
+		case key.Matches(msg, u.Keymap.nextHunk):
+			u.gotoHunk(1)
+			return u, nil
+		case key.Matches(msg, u.Keymap.prevHunk):
+			u.gotoHunk(-1)
+			return u, nil
+		case key.Matches(msg, u.Keymap.previewHunk):
+			u.previewHunk()
+			return u, nil
+		case key.Matches(msg, u.Keymap.revertHunk):
+			u.revertHunk()
+			return u, nil
+		case key.Matches(msg, u.Keymap.otherPane):
+			u.focusPane(!u.paneFocused)
+			return u, nil
+		case key.Matches(msg, u.Keymap.closePane):
+			if u.pane != nil {
+				u.closePane()
+				return u, nil
+			}
 		}
+
+		if u.diffView != nil {
+			return u, u.diffView.Update(msg)
+		}
+
+		if u.paneFocused {
+			return u, u.pane.Update(msg)
+		}
+	case resizedMsg:
+		return u, teax.Batch(func() tea.Msg { return tea.WindowSizeMsg(msg) }, waitResize(u.Output)).Cmd()
 	case tea.WindowSizeMsg:
-		u.textarea.SetHeight(msg.Height - 2)
-		u.textarea.SetWidth(msg.Width)
+		u.width = msg.Width
+		u.height = msg.Height
+		u.layout()
 	case teax.ErrorMsg:
-		// todo handle error msg
+		u.fail(msg.Err)
+	case searchResultMsg:
+		if u.searcher.handle(msg) && !msg.done {
+			batch.Append(waitSearchResult(msg.id, u.searcher.results))
+		}
+		return u, batch.Cmd()
+	case symbolsMsg:
+		return u, waitSymbols(u.symbols)
+	case previewedMsg:
+		return u, waitPreview(u.previews)
+	case compileOutputMsg:
+		return u, u.handleCompileOutput(msg)
+	case locationMsg:
+		return u, u.gotoLocation(msg.location)
+	case jumpMsg:
+		return u, u.jump(msg)
+	case gitHeadMsg:
+		u.handleGitHead(msg)
+		return u, nil
+	case swapTickMsg:
+		u.writeSwapFiles()
+		return u, swapTick()
+	case fileChangedMsg:
+		return u, teax.Batch(u.handleFileChanged(msg), waitFileEvent(u.watcher)).Cmd()
+	case pluginsStartedMsg:
+		return u, u.handlePluginsStarted(msg)
+	case pluginRequestMsg:
+		u.handlePluginRequest(msg.request)
+		return u, waitPluginRequest(u.pluginRequests)
+	case terminalOutputMsg:
+		if msg.pane != u.terminal {
+			return u, nil
+		}
+		return u, waitTerminal(msg.pane)
+	case terminalExitedMsg:
+		if msg.pane == u.terminal {
+			u.handleTerminalExited(msg.pane)
+		}
+		return u, nil
+	case filterDoneMsg:
+		u.handleFilterDone(msg)
+		return u, nil
+	case formattedMsg:
+		return u, u.handleFormatted(msg)
+	case closeDiffViewMsg:
+		u.diffView = nil
+		u.layout()
+		return u, nil
+	}
+
+	if u.prompt.Active() {
+		batch.Append(u.prompt.Update(msg))
 	}
 
 	textarea, cmd := u.textarea.Update(msg)
@@ -82,5 +305,151 @@ func (u *Ui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (u *Ui) View() string {
-	return u.textarea.View()
+	defer u.recoverPanic()
+
+	views := []string{u.textarea.View()}
+	if u.side != nil {
+		views[0] = lipgloss.JoinHorizontal(lipgloss.Top, views[0], u.side.View())
+	}
+	if u.diffView != nil {
+		views = []string{u.diffView.View()}
+	} else if u.pane != nil {
+		views = append(views, u.pane.View())
+	}
+
+	if u.prompt.Active() {
+		views = append(views, u.prompt.View())
+	} else {
+		views = append(views, statusStyle.Render(rw.Truncate(u.status, u.width, "")))
+	}
+
+	return strings.Join(views, "\n")
+}
+
+// message shows msg in the status line until the next key press.
+func (u *Ui) message(msg string) {
+	u.status = msg
+}
+
+// fail reports the error of a command in the status line.
+func (u *Ui) fail(err error) {
+	u.failed = err
+	u.message(err.Error())
+}
+
+// check reports err if it is not nil, see fail.
+func (u *Ui) check(err error) {
+	if err != nil {
+		u.fail(err)
+	}
+}
+
+// layout resizes the components to fit in the window.
+func (u *Ui) layout() {
+	// the last line is used by the status line.
+	height := u.height - 1
+	if u.diffView != nil {
+		u.diffView.SetSize(u.width, height)
+	}
+
+	if u.pane != nil {
+		paneHeight := height / 3
+		u.pane.SetSize(u.width, paneHeight)
+		height -= paneHeight
+	}
+
+	width := u.width
+	if u.side != nil {
+		sideWidth := u.width / 2
+		if _, ok := u.side.(*SymbolOutlinePane); ok {
+			sideWidth = u.width / 3
+		}
+		u.side.SetSize(sideWidth, height)
+		width -= sideWidth
+	}
+
+	// the textarea border takes 2 lines.
+	u.textarea.SetHeight(height - 2)
+	u.textarea.SetWidth(width)
+}
+
+// openPane shows p below the textarea and moves the focus to it.
+func (u *Ui) openPane(p pane) {
+	u.pane = p
+	u.layout()
+	u.focusPane(true)
+}
+
+func (u *Ui) closePane() {
+	if u.pane == u.searcher.pane {
+		u.searcher.stop()
+	}
+	u.pane = nil
+	u.layout()
+	u.focusPane(false)
+}
+
+// focusPane moves the focus to the pane or back to the textarea.
+func (u *Ui) focusPane(focus bool) {
+	u.paneFocused = focus && u.pane != nil
+	if u.paneFocused {
+		u.textarea.Blur()
+	} else {
+		u.textarea.Focus()
+	}
+}
+
+// open returns the document of filename, it is loaded if it is not open yet.
+func (u *Ui) open(filename string) (*views.Document, error) {
+	for _, document := range u.documents {
+		if sameFile(document.Filename(), filename) {
+			return document, nil
+		}
+	}
+
+	document, err := views.LoadDocument(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	u.addDocument(document)
+	return document, nil
+}
+
+// show makes document the current document.
+func (u *Ui) show(document *views.Document) tea.Cmd {
+	if u.document == document {
+		return nil
+	}
+	u.document = document
+	u.textarea.SetDocument(document)
+
+	if _, ok := u.git[document]; ok {
+		return nil
+	}
+	return loadGitHead(document)
+}
+
+// jump opens the file of msg and moves the cursor to the location.
+func (u *Ui) jump(msg jumpMsg) tea.Cmd {
+	document, err := u.open(msg.Filename)
+	if err != nil {
+		u.fail(err)
+		return nil
+	}
+
+	cmd := u.show(document)
+	u.focusPane(false)
+	u.textarea.SetPosition(msg.Row, msg.Col)
+	return cmd
+}
+
+func sameFile(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
 }
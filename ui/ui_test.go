@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/config"
+	"github.com/fzdwx/ge/internal/teax"
+)
+
+// newTestUi returns a started Ui of cfg in a window of 80x24, the
+// configuration and the swap files are kept in a temporary directory.
+func newTestUi(t *testing.T, cfg *config.Config) *Ui {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+
+	u := New(cfg)
+	u.Init()
+	u.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	t.Cleanup(func() { _ = u.Close() })
+	return u
+}
+
+// writeFile writes content to the file name in a temporary directory and
+// returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// runCmd runs cmd and updates u with its messages, and with the messages of
+// the commands they return, the commands that block are dropped.
+func runCmd(u *Ui, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	select {
+	case msg := <-done:
+		if msg == nil {
+			return
+		}
+		if cmds, ok := teax.Cmds(msg); ok {
+			for _, cmd := range cmds {
+				runCmd(u, cmd)
+			}
+			return
+		}
+		_, cmd := u.Update(msg)
+		runCmd(u, cmd)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// typeKeys sends the keys to u, a string is typed rune by rune.
+func typeKeys(u *Ui, keys ...interface{}) {
+	for _, k := range keys {
+		switch k := k.(type) {
+		case string:
+			for _, r := range k {
+				_, cmd := u.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+				runCmd(u, cmd)
+			}
+		case tea.KeyMsg:
+			_, cmd := u.Update(k)
+			runCmd(u, cmd)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fzdwx/ge/internal/logx"
+	"github.com/fzdwx/ge/internal/views"
+	"github.com/fzdwx/ge/internal/watch"
+)
+
+// fileChangedMsg reports that the file of an open document was written by
+// another process.
+type fileChangedMsg struct {
+	filename string
+}
+
+// waitFileEvent waits for the next event of w.
+func waitFileEvent(w watch.Watcher) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-w.Events()
+		if !ok {
+			return nil
+		}
+		return fileChangedMsg{filename: event.Filename}
+	}
+}
+
+// addDocument registers a newly loaded document and starts watching its file.
+func (u *Ui) addDocument(document *views.Document) {
+	u.documents = append(u.documents, document)
+	document.OnChange(func(c views.Change) {
+		u.notifyPluginsChange(document, c)
+	})
+	u.watchDocument(document)
+	u.checkSwapFile(document)
+}
+
+func (u *Ui) watchDocument(document *views.Document) {
+	if document.Filename() == "" {
+		return
+	}
+
+	if err := u.watcher.Add(document.Filename()); err != nil {
+		logx.Warn().Err(err).Str("filename", document.Filename()).Msg("could not watch file")
+	}
+}
+
+// unwatchFile stops watching filename unless a document is still of it.
+func (u *Ui) unwatchFile(filename string) {
+	for _, d := range u.documents {
+		if sameFile(d.Filename(), filename) {
+			return
+		}
+	}
+	u.watcher.Remove(filename)
+}
+
+// handleFileChanged reloads the document of msg when it has no changes,
+// otherwise asks the user what to do.
+func (u *Ui) handleFileChanged(msg fileChangedMsg) tea.Cmd {
+	var document *views.Document
+	for _, d := range u.documents {
+		if sameFile(d.Filename(), msg.filename) {
+			document = d
+		}
+	}
+	if document == nil {
+		return nil
+	}
+
+	changed, err := document.ChangedOnDisk()
+	if err != nil {
+		if os.IsNotExist(err) {
+			u.message(fmt.Sprintf("%s was removed on disk", relative(document.Filename())))
+		}
+		return nil
+	}
+	if !changed {
+		// our own save, or a write that didn't change anything.
+		return nil
+	}
+
+	if !document.Modified() {
+		return u.reload(document)
+	}
+
+	question := fmt.Sprintf("%s changed on disk, (k)eep your changes, (r)eload or (d)iff? ", relative(document.Filename()))
+	return u.prompt.Choose(question, "krd", func(choice rune) tea.Cmd {
+		switch choice {
+		case 'r':
+			return u.reload(document)
+		case 'd':
+			cmd := u.show(document)
+			u.diffWithDisk()
+			return cmd
+		}
+		return nil
+	})
+}
+
+// reload loads the file of document again.
+func (u *Ui) reload(document *views.Document) tea.Cmd {
+	if err := document.Reload(); err != nil {
+		u.fail(err)
+		return nil
+	}
+
+	if document == u.document {
+		pos := u.textarea.Position()
+		u.textarea.SetPosition(pos.Row, pos.Col)
+	}
+	u.removeSwapFile(document)
+	u.message(fmt.Sprintf("reloaded %s", relative(document.Filename())))
+
+	// HEAD may have changed as well, e.g. after a `git checkout`.
+	return loadGitHead(document)
+}
+
+// save writes the current document, asking for a filename if it has none.
+func (u *Ui) save(filename string) tea.Cmd {
+	if filename == "" {
+		filename = u.document.Filename()
+	}
+	if filename == "" {
+		return u.prompt.Ask("Save as: ", "", func(value string) tea.Cmd {
+			if value == "" {
+				return nil
+			}
+			return u.save(value)
+		})
+	}
+
+	// the document is saved once the formatter is done.
+	if u.cfg.FormatOnSave {
+		if cmd := u.formatDocument(u.document, filename); cmd != nil {
+			u.message(fmt.Sprintf("formatting %s...", relative(filename)))
+			return cmd
+		}
+	}
+	return u.write(u.document, filename, nil)
+}
+
+// write writes document to filename, formatErr is the error of its formatter,
+// the document is saved as it is when the formatter failed.
+func (u *Ui) write(document *views.Document, filename string, formatErr error) tea.Cmd {
+	previous := document.Filename()
+	renamed := !sameFile(filename, previous)
+	if renamed && previous != "" {
+		u.removeSwapFile(document)
+	}
+
+	var err error
+	saveAs := func() {
+		// the settings of the file, e.g. trim_trailing_whitespace of its
+		// .editorconfig, may edit the document.
+		err = document.SaveAs(filename)
+	}
+	if document == u.document {
+		u.textarea.FollowEdits(saveAs)
+	} else {
+		saveAs()
+	}
+	if err != nil {
+		u.fail(err)
+		return nil
+	}
+	u.removeSwapFile(document)
+	u.notifyPluginsSave(document)
+	if formatErr != nil {
+		u.message(fmt.Sprintf("saved %s without formatting, %s", relative(filename), u.formatError(formatErr)))
+	} else {
+		u.message(fmt.Sprintf("saved %s", relative(filename)))
+	}
+
+	if !renamed {
+		return nil
+	}
+	if previous != "" {
+		u.unwatchFile(previous)
+	}
+	u.watchDocument(document)
+	return loadGitHead(document)
+}
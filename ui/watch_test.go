@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fzdwx/ge/config"
+	"github.com/fzdwx/ge/internal/watch"
+)
+
+// fakeWatcher records the watched files.
+type fakeWatcher struct {
+	files  map[string]bool
+	events chan watch.Event
+}
+
+func (w *fakeWatcher) Add(filename string) error {
+	w.files[filename] = true
+	return nil
+}
+
+func (w *fakeWatcher) Remove(filename string) {
+	delete(w.files, filename)
+}
+
+func (w *fakeWatcher) Events() <-chan watch.Event { return w.events }
+func (w *fakeWatcher) Close() error               { return nil }
+
+func TestUi_save_Renamed(t *testing.T) {
+	old := writeFile(t, "a.txt", "a\n")
+	u := newTestUi(t, config.New([]string{old}))
+	_ = u.watcher.Close()
+	w := &fakeWatcher{files: map[string]bool{old: true}, events: make(chan watch.Event)}
+	u.watcher = w
+
+	renamed := filepath.Join(t.TempDir(), "b.txt")
+	runCmd(u, u.save(renamed))
+	if u.document.Filename() != renamed {
+		t.Fatalf("Filename() = %s", u.document.Filename())
+	}
+	if w.files[old] || !w.files[renamed] || len(w.files) != 1 {
+		t.Errorf("watched %v, want only %s", w.files, renamed)
+	}
+}